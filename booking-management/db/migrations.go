@@ -0,0 +1,28 @@
+// Package db holds the SQL migrations of the booking-management database,
+// embedded into the binary.
+//
+// Migrations only ever add to the schema, so a service keeps working with a
+// database migrated past the version it was built for. Each service checks
+// on startup for the oldest version it needs:
+//
+//	booking-management  the newest migration embedded here
+//	booking             7 (booking/internal/repository.SchemaVersion)
+//	worker              6 (worker/internal/repository.SchemaVersion)
+//
+// Raise a service's version, here and in the service, only when it starts to
+// rely on a new migration.
+package db
+
+import "embed"
+
+// Migrations holds migrations/<version>_<name>.up.sql and .down.sql pairs.
+// Optional migrations, such as sample data, are named
+// <version>_<name>.optional.up.sql.
+//
+//go:embed migrations/*.sql
+var Migrations embed.FS
+
+// BookingOverlapConstraint is the exclusion constraint migration 5 adds to
+// bookings: no two Pending or Accepted bookings hold the same room for
+// overlapping dates. A write it refuses fails with SQLSTATE 23P01.
+const BookingOverlapConstraint = "bookings_no_overlap"
@@ -0,0 +1,9 @@
+-- The booking ID handed out by the booking service, so the worker stores
+-- each booking once even when its event is redelivered.
+
+ALTER TABLE bookings ADD COLUMN IF NOT EXISTS external_id VARCHAR(64);
+UPDATE bookings SET external_id = 'booking_legacy_' || id WHERE external_id IS NULL;
+ALTER TABLE bookings ALTER COLUMN external_id SET NOT NULL;
+
+ALTER TABLE bookings DROP CONSTRAINT IF EXISTS bookings_external_id_key;
+ALTER TABLE bookings ADD CONSTRAINT bookings_external_id_key UNIQUE (external_id);
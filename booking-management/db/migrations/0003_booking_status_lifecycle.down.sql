@@ -0,0 +1,12 @@
+-- Pending bookings have no place in the old statuses, and bookings without
+-- a user or room cannot be kept
+UPDATE bookings SET status = 'Refused' WHERE status = 'Pending';
+DELETE FROM bookings WHERE user_id IS NULL OR room_id IS NULL;
+
+ALTER TABLE bookings DROP CONSTRAINT IF EXISTS check_references;
+ALTER TABLE bookings DROP CONSTRAINT IF EXISTS check_status;
+ALTER TABLE bookings ADD CONSTRAINT check_status CHECK (status IN ('Accepted', 'Cancelled', 'Refused'));
+
+ALTER TABLE bookings ALTER COLUMN user_id SET NOT NULL;
+ALTER TABLE bookings ALTER COLUMN room_id SET NOT NULL;
+ALTER TABLE bookings DROP COLUMN IF EXISTS refusal_reason;
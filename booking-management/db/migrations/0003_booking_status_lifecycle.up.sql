@@ -0,0 +1,12 @@
+-- Bookings are Pending until the worker decides them. Refused bookings keep
+-- their reason and may reference a user or room that does not exist.
+
+ALTER TABLE bookings ADD COLUMN IF NOT EXISTS refusal_reason TEXT;
+ALTER TABLE bookings ALTER COLUMN user_id DROP NOT NULL;
+ALTER TABLE bookings ALTER COLUMN room_id DROP NOT NULL;
+
+ALTER TABLE bookings DROP CONSTRAINT IF EXISTS check_status;
+ALTER TABLE bookings ADD CONSTRAINT check_status CHECK (status IN ('Pending', 'Accepted', 'Cancelled', 'Refused'));
+
+ALTER TABLE bookings DROP CONSTRAINT IF EXISTS check_references;
+ALTER TABLE bookings ADD CONSTRAINT check_references CHECK (status = 'Refused' OR (user_id IS NOT NULL AND room_id IS NOT NULL));
@@ -0,0 +1,12 @@
+DELETE FROM bookings WHERE external_id LIKE 'booking_seed_%';
+DELETE FROM rooms WHERE internal_id IN (
+    'room_ocean_001', 'room_mountain_002', 'room_penthouse_003', 'room_garden_004', 'room_sky_005',
+    'room_luxury_006', 'room_cozy_007', 'room_family_008', 'room_executive_009', 'room_budget_010',
+    'room_deluxe_011', 'room_presidential_012', 'room_standard_013', 'room_junior_014', 'room_superior_015',
+    'room_economy_016', 'room_honeymoon_017', 'room_business_018', 'room_connecting_019', 'room_accessible_020'
+) AND NOT EXISTS (SELECT 1 FROM bookings WHERE bookings.room_id = rooms.id);
+DELETE FROM users WHERE username IN (
+    'johndoe', 'janesmith', 'mikejohnson', 'sarahwilson', 'davidbrown',
+    'emilydavis', 'chrismiller', 'lisagarcia', 'tomanderson', 'annataylor',
+    'robertwhite', 'marialopez', 'jamesharris', 'jessicaclark', 'kevinlewis'
+) AND NOT EXISTS (SELECT 1 FROM bookings WHERE bookings.user_id = users.id);
@@ -0,0 +1,80 @@
+-- Sample users, rooms and bookings for local runs and demos. Applied only
+-- with migrate up -seed.
+
+-- Insert fake data for Users
+INSERT INTO users (email, username, date_of_birth, name, surname) VALUES
+    ('john.doe@email.com', 'johndoe', '1990-05-15', 'John', 'Doe'),
+    ('jane.smith@email.com', 'janesmith', '1985-08-22', 'Jane', 'Smith'),
+    ('mike.johnson@email.com', 'mikejohnson', '1992-12-03', 'Mike', 'Johnson'),
+    ('sarah.wilson@email.com', 'sarahwilson', '1988-03-18', 'Sarah', 'Wilson'),
+    ('david.brown@email.com', 'davidbrown', '1995-07-09', 'David', 'Brown'),
+    ('emily.davis@email.com', 'emilydavis', '1987-11-27', 'Emily', 'Davis'),
+    ('chris.miller@email.com', 'chrismiller', '1993-01-14', 'Chris', 'Miller'),
+    ('lisa.garcia@email.com', 'lisagarcia', '1989-06-30', 'Lisa', 'Garcia'),
+    ('tom.anderson@email.com', 'tomanderson', '1991-04-08', 'Tom', 'Anderson'),
+    ('anna.taylor@email.com', 'annataylor', '1994-09-12', 'Anna', 'Taylor'),
+    ('robert.white@email.com', 'robertwhite', '1986-02-25', 'Robert', 'White'),
+    ('maria.lopez@email.com', 'marialopez', '1990-10-07', 'Maria', 'Lopez'),
+    ('james.harris@email.com', 'jamesharris', '1992-08-16', 'James', 'Harris'),
+    ('jessica.clark@email.com', 'jessicaclark', '1987-12-04', 'Jessica', 'Clark'),
+    ('kevin.lewis@email.com', 'kevinlewis', '1995-05-21', 'Kevin', 'Lewis')
+ON CONFLICT (email) DO NOTHING;
+
+-- Insert fake data for Rooms
+INSERT INTO rooms (internal_id, name, floor, bathrooms, beds, capacity) VALUES
+    ('room_ocean_001', 'Ocean View Suite', 3, 2, 2, 4),
+    ('room_mountain_002', 'Mountain Cabin', 1, 1, 1, 2),
+    ('room_penthouse_003', 'City Penthouse', 10, 3, 3, 6),
+    ('room_garden_004', 'Garden Villa', 1, 2, 2, 4),
+    ('room_sky_005', 'Sky Loft', 8, 1, 1, 2),
+    ('room_luxury_006', 'Luxury Suite', 5, 2, 2, 4),
+    ('room_cozy_007', 'Cozy Studio', 2, 1, 1, 2),
+    ('room_family_008', 'Family Room', 4, 2, 3, 6),
+    ('room_executive_009', 'Executive Suite', 7, 2, 2, 4),
+    ('room_budget_010', 'Budget Room', 1, 1, 1, 2),
+    ('room_deluxe_011', 'Deluxe Double', 6, 1, 2, 3),
+    ('room_presidential_012', 'Presidential Suite', 12, 4, 4, 8),
+    ('room_standard_013', 'Standard Twin', 3, 1, 2, 2),
+    ('room_junior_014', 'Junior Suite', 9, 1, 1, 3),
+    ('room_superior_015', 'Superior Room', 4, 1, 2, 4),
+    ('room_economy_016', 'Economy Single', 2, 1, 1, 1),
+    ('room_honeymoon_017', 'Honeymoon Suite', 11, 2, 1, 2),
+    ('room_business_018', 'Business Room', 8, 1, 1, 2),
+    ('room_connecting_019', 'Connecting Rooms', 5, 2, 4, 8),
+    ('room_accessible_020', 'Accessible Room', 1, 1, 2, 3)
+ON CONFLICT (internal_id) DO NOTHING;
+
+-- Insert fake data for Bookings, referencing users by username and rooms by
+-- internal ID
+INSERT INTO bookings (external_id, user_id, room_id, number_of_guests, start_date, end_date, payment_id, status)
+SELECT b.external_id, u.id, r.id, b.number_of_guests, b.start_date, b.end_date, b.payment_id, b.status
+FROM (VALUES
+    ('booking_seed_001', 'johndoe', 'room_ocean_001', 2, DATE '2024-01-15', DATE '2024-01-18', 'pay_abc123', 'Accepted'),
+    ('booking_seed_002', 'janesmith', 'room_penthouse_003', 4, DATE '2024-02-01', DATE '2024-02-05', 'pay_def456', 'Accepted'),
+    ('booking_seed_003', 'mikejohnson', 'room_mountain_002', 1, DATE '2024-01-20', DATE '2024-01-22', 'pay_ghi789', 'Cancelled'),
+    ('booking_seed_004', 'sarahwilson', 'room_family_008', 5, DATE '2024-03-10', DATE '2024-03-15', 'pay_jkl012', 'Accepted'),
+    ('booking_seed_005', 'davidbrown', 'room_sky_005', 2, DATE '2024-02-14', DATE '2024-02-16', 'pay_mno345', 'Refused'),
+    ('booking_seed_006', 'emilydavis', 'room_presidential_012', 6, DATE '2024-04-01', DATE '2024-04-07', 'pay_pqr678', 'Accepted'),
+    ('booking_seed_007', 'chrismiller', 'room_cozy_007', 1, DATE '2024-01-25', DATE '2024-01-27', 'pay_stu901', 'Accepted'),
+    ('booking_seed_008', 'lisagarcia', 'room_garden_004', 3, DATE '2024-02-20', DATE '2024-02-25', 'pay_vwx234', 'Cancelled'),
+    ('booking_seed_009', 'tomanderson', 'room_budget_010', 2, DATE '2024-03-05', DATE '2024-03-08', 'pay_yza567', 'Accepted'),
+    ('booking_seed_010', 'annataylor', 'room_superior_015', 4, DATE '2024-05-01', DATE '2024-05-05', 'pay_bcd890', 'Accepted'),
+    ('booking_seed_011', 'robertwhite', 'room_luxury_006', 3, DATE '2024-02-10', DATE '2024-02-13', 'pay_efg123', 'Accepted'),
+    ('booking_seed_012', 'marialopez', 'room_executive_009', 2, DATE '2024-03-20', DATE '2024-03-23', 'pay_hij456', 'Refused'),
+    ('booking_seed_013', 'jamesharris', 'room_deluxe_011', 2, DATE '2024-04-15', DATE '2024-04-18', 'pay_klm789', 'Accepted'),
+    ('booking_seed_014', 'jessicaclark', 'room_junior_014', 1, DATE '2024-01-30', DATE '2024-02-02', 'pay_nop012', 'Cancelled'),
+    ('booking_seed_015', 'kevinlewis', 'room_honeymoon_017', 2, DATE '2024-06-01', DATE '2024-06-03', 'pay_qrs345', 'Accepted'),
+    ('booking_seed_016', 'johndoe', 'room_standard_013', 2, DATE '2024-07-10', DATE '2024-07-12', 'pay_tuv678', 'Accepted'),
+    ('booking_seed_017', 'janesmith', 'room_economy_016', 1, DATE '2024-03-01', DATE '2024-03-03', 'pay_wxy901', 'Accepted'),
+    ('booking_seed_018', 'mikejohnson', 'room_business_018', 2, DATE '2024-05-15', DATE '2024-05-17', 'pay_zab234', 'Cancelled'),
+    ('booking_seed_019', 'sarahwilson', 'room_connecting_019', 7, DATE '2024-08-01', DATE '2024-08-10', 'pay_cde567', 'Accepted'),
+    ('booking_seed_020', 'davidbrown', 'room_accessible_020', 2, DATE '2024-04-20', DATE '2024-04-22', 'pay_fgh890', 'Refused'),
+    ('booking_seed_021', 'emilydavis', 'room_ocean_001', 3, DATE '2024-09-01', DATE '2024-09-05', 'pay_ijk123', 'Accepted'),
+    ('booking_seed_022', 'chrismiller', 'room_penthouse_003', 4, DATE '2024-06-15', DATE '2024-06-20', 'pay_lmn456', 'Accepted'),
+    ('booking_seed_023', 'lisagarcia', 'room_mountain_002', 1, DATE '2024-07-25', DATE '2024-07-28', 'pay_opq789', 'Accepted'),
+    ('booking_seed_024', 'tomanderson', 'room_family_008', 6, DATE '2024-10-01', DATE '2024-10-07', 'pay_rst012', 'Cancelled'),
+    ('booking_seed_025', 'annataylor', 'room_sky_005', 2, DATE '2024-11-10', DATE '2024-11-12', 'pay_uvw345', 'Accepted')
+) AS b (external_id, username, room_internal_id, number_of_guests, start_date, end_date, payment_id, status)
+JOIN users u ON u.username = b.username
+JOIN rooms r ON r.internal_id = b.room_internal_id
+ON CONFLICT (external_id) DO NOTHING;
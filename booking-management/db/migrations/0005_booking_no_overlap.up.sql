@@ -0,0 +1,12 @@
+-- No two Pending or Accepted bookings may hold the same room for
+-- overlapping dates, whichever service writes them. Stays are half-open, so
+-- a booking may start on the day another ends. Fails if existing bookings
+-- already overlap; refuse or cancel one of each pair first.
+
+CREATE EXTENSION IF NOT EXISTS btree_gist;
+
+ALTER TABLE bookings DROP CONSTRAINT IF EXISTS bookings_no_overlap;
+ALTER TABLE bookings ADD CONSTRAINT bookings_no_overlap EXCLUDE USING gist (
+    room_id WITH =,
+    daterange(start_date, end_date, '[)') WITH &&
+) WHERE (status IN ('Pending', 'Accepted'));
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS divert_consumers;
+DROP TABLE IF EXISTS booking_outbox;
+DROP TABLE IF EXISTS booking_sagas;
@@ -0,0 +1,39 @@
+-- Tables private to the other services sharing this database: the booking
+-- service's sagas and outbox, and the worker's divert claims. Those services
+-- used to create them on startup, so they may already exist.
+
+CREATE TABLE IF NOT EXISTS booking_sagas (
+    booking_id VARCHAR(64) PRIMARY KEY,
+    step VARCHAR(20) NOT NULL,
+    status VARCHAR(20) NOT NULL,
+    event JSONB NOT NULL,
+    error TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+CREATE INDEX IF NOT EXISTS idx_booking_sagas_unfinished
+    ON booking_sagas (updated_at) WHERE status IN ('running', 'compensating');
+
+CREATE TABLE IF NOT EXISTS booking_outbox (
+    id BIGSERIAL PRIMARY KEY,
+    topic VARCHAR(255) NOT NULL,
+    key VARCHAR(255) NOT NULL,
+    payload JSONB NOT NULL,
+    headers JSONB NOT NULL DEFAULT '{}',
+    attempts INTEGER NOT NULL DEFAULT 0,
+    last_error TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    next_attempt_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    sent_at TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_booking_outbox_unsent
+    ON booking_outbox (key, id) WHERE sent_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_booking_outbox_sent_at
+    ON booking_outbox (sent_at) WHERE sent_at IS NOT NULL;
+
+CREATE TABLE IF NOT EXISTS divert_consumers (
+    divert_key VARCHAR(63) NOT NULL,
+    consumer_id VARCHAR(255) NOT NULL,
+    last_heartbeat TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (divert_key, consumer_id)
+);
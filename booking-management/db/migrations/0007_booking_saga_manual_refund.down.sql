@@ -0,0 +1 @@
+ALTER TABLE booking_sagas DROP COLUMN IF EXISTS manual_refund;
@@ -0,0 +1,5 @@
+-- A booking saga whose refund the payment service rejected still releases
+-- its room first; the flag keeps that the payment must be refunded by hand
+-- until the saga is marked failed.
+
+ALTER TABLE booking_sagas ADD COLUMN IF NOT EXISTS manual_refund BOOLEAN NOT NULL DEFAULT FALSE;
@@ -11,6 +11,9 @@ type Config struct {
 	DBUser string
 	DBPass string
 	DBName string
+
+	OTLPEndpoint string
+	TracesFile   string
 }
 
 func Load() *Config {
@@ -21,6 +24,9 @@ func Load() *Config {
 		DBUser: getEnv("DB_USER", "postgres"),
 		DBPass: getEnv("DB_PASS", "postgres"),
 		DBName: getEnv("DB_NAME", "booking_management"),
+
+		OTLPEndpoint: getEnv("OTEL_EXPORTER_OTLP_ENDPOINT", ""),
+		TracesFile:   getEnv("OTEL_TRACES_FILE", ""),
 	}
 }
 
@@ -1,14 +1,212 @@
 package handlers
 
 import (
+	"context"
+	"database/sql"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	"booking-management/internal/database"
 	"booking-management/internal/logger"
 	"booking-management/internal/models"
+
+	"github.com/gorilla/mux"
 )
 
+// bookingColumns lists the columns scanBooking reads, in order.
+const bookingColumns = `id, external_id, user_id, room_id, number_of_guests, start_date, end_date, payment_id, status, refusal_reason, created_at, updated_at`
+
+var bookingSorts = sortColumns[models.Booking]{
+	"id":         func(booking models.Booking) string { return strconv.Itoa(booking.ID) },
+	"start_date": func(booking models.Booking) string { return formatDate(booking.StartDate) },
+	"end_date":   func(booking models.Booking) string { return formatDate(booking.EndDate) },
+	"created_at": func(booking models.Booking) string { return formatTime(booking.CreatedAt) },
+}
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+type rowQuerier interface {
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+func scanBooking(row rowScanner, booking *models.Booking) error {
+	return row.Scan(
+		&booking.ID,
+		&booking.ExternalID,
+		&booking.UserID,
+		&booking.RoomID,
+		&booking.NumberOfGuests,
+		&booking.StartDate,
+		&booking.EndDate,
+		&booking.PaymentID,
+		&booking.Status,
+		&booking.RefusalReason,
+		&booking.CreatedAt,
+		&booking.UpdatedAt,
+	)
+}
+
+// getBookingByExternalID returns sql.ErrNoRows when no booking has the ID.
+func getBookingByExternalID(ctx context.Context, q rowQuerier, externalID string) (*models.Booking, error) {
+	query := `SELECT ` + bookingColumns + ` FROM bookings WHERE external_id = $1`
+
+	var booking models.Booking
+	if err := scanBooking(q.QueryRowContext(ctx, query, externalID), &booking); err != nil {
+		return nil, err
+	}
+	return &booking, nil
+}
+
+// getBooking returns sql.ErrNoRows when no booking has the ID. lock keeps
+// the row locked until the transaction of q ends.
+func getBooking(ctx context.Context, q rowQuerier, id int, lock bool) (*models.Booking, error) {
+	query := `SELECT ` + bookingColumns + ` FROM bookings WHERE id = $1`
+	if lock {
+		query += ` FOR UPDATE`
+	}
+
+	var booking models.Booking
+	if err := scanBooking(q.QueryRowContext(ctx, query, id), &booking); err != nil {
+		return nil, err
+	}
+	return &booking, nil
+}
+
+// roomAvailability returns why a room with the given ID and capacity cannot
+// take guests from start to end, if it cannot. Callers lock the room row
+// first, so concurrent bookings cannot both take it. The booking with ID
+// excludeID is ignored, so a booking does not conflict with itself.
+func roomAvailability(ctx context.Context, q rowQuerier, roomID, capacity, guests int, start, end time.Time, excludeID int) ([]string, error) {
+	var reasons []string
+	if guests > capacity {
+		reasons = append(reasons, fmt.Sprintf("Number of guests (%d) exceeds room capacity (%d)", guests, capacity))
+	}
+
+	query := `
+		SELECT NOT EXISTS (
+			SELECT 1 FROM bookings
+			WHERE room_id = $1
+			AND id <> $4
+			AND status IN ('Pending', 'Accepted')
+			AND start_date < $3
+			AND end_date > $2
+		)
+	`
+	var available bool
+	if err := q.QueryRowContext(ctx, query, roomID, start, end, excludeID).Scan(&available); err != nil {
+		return nil, err
+	}
+	if !available {
+		reasons = append(reasons, "Room is not available for the specified dates")
+	}
+	return reasons, nil
+}
+
+var bookingStatuses = map[string]bool{
+	"Pending":   true,
+	"Accepted":  true,
+	"Cancelled": true,
+	"Refused":   true,
+}
+
+// applyBookingRequest copies the fields set in req onto booking and returns
+// why the result is invalid, if it is. replace requires every field but
+// external_id, payment_id and refusal_reason, and clears those left out.
+func applyBookingRequest(booking *models.Booking, req models.BookingRequest, replace bool) []string {
+	if replace {
+		var missing []string
+		if req.NumberOfGuests == nil {
+			missing = append(missing, "number_of_guests is required")
+		}
+		if req.StartDate == nil {
+			missing = append(missing, "start_date is required")
+		}
+		if req.EndDate == nil {
+			missing = append(missing, "end_date is required")
+		}
+		if req.Status == nil {
+			missing = append(missing, "status is required")
+		}
+		if len(missing) > 0 {
+			return missing
+		}
+		booking.UserID = req.UserID
+		booking.RoomID = req.RoomID
+		booking.PaymentID = req.PaymentID
+		booking.RefusalReason = req.RefusalReason
+	}
+
+	var reasons []string
+	if req.UserID != nil {
+		booking.UserID = req.UserID
+	}
+	if req.RoomID != nil {
+		booking.RoomID = req.RoomID
+	}
+	if req.NumberOfGuests != nil {
+		booking.NumberOfGuests = *req.NumberOfGuests
+	}
+	if req.StartDate != nil {
+		startDate, err := parseDateParam(*req.StartDate)
+		if err != nil {
+			reasons = append(reasons, "start_date must be a date (YYYY-MM-DD) or RFC 3339 time")
+		} else {
+			booking.StartDate = startDate
+		}
+	}
+	if req.EndDate != nil {
+		endDate, err := parseDateParam(*req.EndDate)
+		if err != nil {
+			reasons = append(reasons, "end_date must be a date (YYYY-MM-DD) or RFC 3339 time")
+		} else {
+			booking.EndDate = endDate
+		}
+	}
+	if req.PaymentID != nil {
+		booking.PaymentID = req.PaymentID
+	}
+	if req.Status != nil {
+		booking.Status = *req.Status
+		// A refusal reason does not outlive the refusal
+		if booking.Status != "Refused" {
+			booking.RefusalReason = nil
+		}
+	}
+	if req.RefusalReason != nil {
+		booking.RefusalReason = req.RefusalReason
+	}
+	if len(reasons) > 0 {
+		return reasons
+	}
+
+	if !bookingStatuses[booking.Status] {
+		reasons = append(reasons, "status must be Pending, Accepted, Cancelled or Refused")
+	}
+	if booking.Status != "Refused" {
+		if booking.UserID == nil || booking.RoomID == nil {
+			reasons = append(reasons, "user_id and room_id are required unless the booking is Refused")
+		}
+		if booking.RefusalReason != nil {
+			reasons = append(reasons, "refusal_reason is only allowed on Refused bookings")
+		}
+	}
+	if booking.NumberOfGuests <= 0 {
+		reasons = append(reasons, "Number of guests must be greater than 0")
+	}
+	if !booking.EndDate.After(booking.StartDate) {
+		reasons = append(reasons, "End date must be after start date")
+	}
+	return reasons
+}
+
 type BookingHandler struct {
 	db *database.DB
 }
@@ -17,17 +215,50 @@ func NewBookingHandler(db *database.DB) *BookingHandler {
 	return &BookingHandler{db: db}
 }
 
+// GetBookings lists bookings a page at a time, see parseListPage. user_id,
+// room_id and status filter on those columns; from and to keep the bookings
+// whose stay overlaps them.
 func (h *BookingHandler) GetBookings(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
-	logger.Info(ctx, "Fetching bookings")
+	params := r.URL.Query()
 
-	query := `
-		SELECT id, user_id, room_id, number_of_guests, start_date, end_date, payment_id, status, created_at, updated_at
-		FROM bookings
-		ORDER BY id ASC
-	`
+	page, err := parseListPage(r, bookingSorts)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 
-	rows, err := h.db.Query(query)
+	var q listQuery
+	for _, filter := range []struct {
+		param string
+		cond  string
+		date  bool
+	}{
+		{"user_id", "user_id = %s", false},
+		{"room_id", "room_id = %s", false},
+		{"from", "end_date > %s", true},
+		{"to", "start_date < %s", true},
+	} {
+		filterFunc := q.intFilter
+		if filter.date {
+			filterFunc = q.dateFilter
+		}
+		if err := filterFunc(params, filter.param, filter.cond); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	if status := params.Get("status"); status != "" {
+		if !bookingStatuses[status] {
+			http.Error(w, "status must be Pending, Accepted, Cancelled or Refused", http.StatusBadRequest)
+			return
+		}
+		q.where("status = " + q.arg(status))
+	}
+
+	logger.Info(ctx, "Fetching bookings", "limit", page.limit, "sort", page.sort)
+
+	rows, err := h.db.QueryContext(ctx, q.build(bookingColumns, "bookings", page), q.args...)
 	if err != nil {
 		logger.Error(ctx, "Failed to fetch bookings from database", "error", err)
 		http.Error(w, "Failed to fetch bookings", http.StatusInternalServerError)
@@ -38,19 +269,7 @@ func (h *BookingHandler) GetBookings(w http.ResponseWriter, r *http.Request) {
 	var bookings []models.Booking
 	for rows.Next() {
 		var booking models.Booking
-		err := rows.Scan(
-			&booking.ID,
-			&booking.UserID,
-			&booking.RoomID,
-			&booking.NumberOfGuests,
-			&booking.StartDate,
-			&booking.EndDate,
-			&booking.PaymentID,
-			&booking.Status,
-			&booking.CreatedAt,
-			&booking.UpdatedAt,
-		)
-		if err != nil {
+		if err := scanBooking(rows, &booking); err != nil {
 			logger.Error(ctx, "Failed to scan booking", "error", err)
 			http.Error(w, "Failed to scan booking", http.StatusInternalServerError)
 			return
@@ -65,13 +284,346 @@ func (h *BookingHandler) GetBookings(w http.ResponseWriter, r *http.Request) {
 	}
 
 	logger.Info(ctx, "Successfully fetched bookings", "count", len(bookings))
+	writePage(w, r, bookings, page, bookingSorts)
+}
+
+// GetBookingByExternalID returns the booking with the ID the booking service
+// handed out when it was requested, e.g. booking_1700000000_123456.
+func (h *BookingHandler) GetBookingByExternalID(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	externalID := mux.Vars(r)["externalId"]
+	logger.Info(ctx, "Fetching booking by external ID", "externalId", externalID)
+
+	booking, err := getBookingByExternalID(ctx, h.db, externalID)
+	if errors.Is(err, sql.ErrNoRows) {
+		logger.Info(ctx, "Booking not found", "externalId", externalID)
+		http.Error(w, "Booking not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		logger.Error(ctx, "Failed to fetch booking from database", "externalId", externalID, "error", err)
+		http.Error(w, "Failed to fetch booking", http.StatusInternalServerError)
+		return
+	}
+
+	logger.Info(ctx, "Successfully fetched booking", "externalId", externalID, "id", booking.ID)
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 
-	if err := json.NewEncoder(w).Encode(bookings); err != nil {
+	if err := json.NewEncoder(w).Encode(booking); err != nil {
 		logger.Error(ctx, "Failed to encode response", "error", err)
 		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
 		return
 	}
+}
+
+func (h *BookingHandler) GetBooking(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id, _ := strconv.Atoi(mux.Vars(r)["id"])
+
+	booking, err := getBooking(ctx, h.db, id, false)
+	if errors.Is(err, sql.ErrNoRows) {
+		http.Error(w, "Booking not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		logger.Error(ctx, "Failed to fetch booking", "id", id, "error", err)
+		http.Error(w, "Failed to fetch booking", http.StatusInternalServerError)
+		return
+	}
+
+	writeEntity(w, http.StatusOK, booking.UpdatedAt, booking)
+}
+
+// CreateBooking stores a booking made by staff, e.g. over the phone. Unlike
+// bookings made through the booking service it is not charged for.
+func (h *BookingHandler) CreateBooking(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	logger.Info(ctx, "Creating booking")
+
+	var req models.BookingRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logger.Error(ctx, "Failed to decode booking request", "error", err)
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	booking := models.Booking{
+		ExternalID: fmt.Sprintf("booking_%d_%s", time.Now().Unix(), generateRandomString(6)),
+	}
+	if req.ExternalID != nil {
+		booking.ExternalID = strings.TrimSpace(*req.ExternalID)
+	}
+	if req.Status == nil {
+		accepted := "Accepted"
+		req.Status = &accepted
+	}
+
+	var reasons []string
+	if booking.ExternalID == "" || len(booking.ExternalID) > 64 {
+		reasons = append(reasons, "external_id must be 1 to 64 characters")
+	}
+	reasons = append(reasons, applyBookingRequest(&booking, req, true)...)
+	if len(reasons) > 0 {
+		writeJSON(w, http.StatusBadRequest, models.ValidationResponse{IsValid: false, Reasons: reasons})
+		return
+	}
+
+	tx, err := h.db.BeginTx(ctx, nil)
+	if err != nil {
+		logger.Error(ctx, "Failed to begin transaction", "error", err)
+		http.Error(w, "Failed to create booking", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	if !h.checkBooking(ctx, w, tx, &booking, "Failed to create booking") {
+		return
+	}
+
+	query := `
+		INSERT INTO bookings (external_id, user_id, room_id, number_of_guests, start_date, end_date, payment_id, status, refusal_reason)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING ` + bookingColumns
+
+	row := tx.QueryRowContext(ctx, query, booking.ExternalID, booking.UserID, booking.RoomID, booking.NumberOfGuests,
+		booking.StartDate, booking.EndDate, booking.PaymentID, booking.Status, booking.RefusalReason)
+	if err := scanBooking(row, &booking); err != nil {
+		writeDBError(ctx, w, err, "Failed to create booking")
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		writeDBError(ctx, w, err, "Failed to create booking")
+		return
+	}
+
+	logger.Info(ctx, "Booking created", "externalId", booking.ExternalID, "id", booking.ID)
+	w.Header().Set("Location", "/bookings/"+strconv.Itoa(booking.ID))
+	writeEntity(w, http.StatusCreated, booking.UpdatedAt, booking)
+}
+
+// ReplaceBooking handles PUT, which sets every field of the booking.
+func (h *BookingHandler) ReplaceBooking(w http.ResponseWriter, r *http.Request) {
+	h.updateBooking(w, r, true)
+}
+
+// PatchBooking handles PATCH, which sets only the fields in the body.
+func (h *BookingHandler) PatchBooking(w http.ResponseWriter, r *http.Request) {
+	h.updateBooking(w, r, false)
+}
+
+func (h *BookingHandler) updateBooking(w http.ResponseWriter, r *http.Request, replace bool) {
+	ctx := r.Context()
+	id, _ := strconv.Atoi(mux.Vars(r)["id"])
+	logger.Info(ctx, "Updating booking", "id", id, "replace", replace)
+
+	var req models.BookingRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logger.Error(ctx, "Failed to decode booking request", "error", err)
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	tx, err := h.db.BeginTx(ctx, nil)
+	if err != nil {
+		logger.Error(ctx, "Failed to begin transaction", "error", err)
+		http.Error(w, "Failed to update booking", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	booking, err := getBooking(ctx, tx, id, true)
+	if errors.Is(err, sql.ErrNoRows) {
+		http.Error(w, "Booking not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		logger.Error(ctx, "Failed to fetch booking", "id", id, "error", err)
+		http.Error(w, "Failed to update booking", http.StatusInternalServerError)
+		return
+	}
+	if !ifMatch(r, etag(booking.UpdatedAt)) {
+		writePreconditionFailed(w, booking.UpdatedAt)
+		return
+	}
+
+	if req.ExternalID != nil && *req.ExternalID != booking.ExternalID {
+		http.Error(w, "external_id cannot be changed", http.StatusBadRequest)
+		return
+	}
+	previous := *booking
+	if reasons := applyBookingRequest(booking, req, replace); len(reasons) > 0 {
+		writeJSON(w, http.StatusBadRequest, models.ValidationResponse{IsValid: false, Reasons: reasons})
+		return
+	}
+
+	// Bookings that keep their room, dates, guests and status are not checked
+	// again, so e.g. a payment_id can be fixed on a booking made before a
+	// room's capacity was lowered.
+	if takesRoom(*booking) && (!takesRoom(previous) || !sameIntPtr(previous.RoomID, booking.RoomID) ||
+		!previous.StartDate.Equal(booking.StartDate) || !previous.EndDate.Equal(booking.EndDate) ||
+		previous.NumberOfGuests != booking.NumberOfGuests) {
+		if !h.checkBooking(ctx, w, tx, booking, "Failed to update booking") {
+			return
+		}
+	} else if !h.checkReferences(ctx, w, tx, booking, "Failed to update booking") {
+		return
+	}
+
+	query := `
+		UPDATE bookings
+		SET user_id = $2, room_id = $3, number_of_guests = $4, start_date = $5, end_date = $6,
+		    payment_id = $7, status = $8, refusal_reason = $9, updated_at = NOW()
+		WHERE id = $1
+		RETURNING ` + bookingColumns
+
+	row := tx.QueryRowContext(ctx, query, booking.ID, booking.UserID, booking.RoomID, booking.NumberOfGuests,
+		booking.StartDate, booking.EndDate, booking.PaymentID, booking.Status, booking.RefusalReason)
+	if err := scanBooking(row, booking); err != nil {
+		writeDBError(ctx, w, err, "Failed to update booking")
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		writeDBError(ctx, w, err, "Failed to update booking")
+		return
+	}
+
+	logger.Info(ctx, "Booking updated", "id", id, "status", booking.Status)
+	writeEntity(w, http.StatusOK, booking.UpdatedAt, booking)
+}
+
+func (h *BookingHandler) DeleteBooking(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id, _ := strconv.Atoi(mux.Vars(r)["id"])
+	logger.Info(ctx, "Deleting booking", "id", id)
+
+	tx, err := h.db.BeginTx(ctx, nil)
+	if err != nil {
+		logger.Error(ctx, "Failed to begin transaction", "error", err)
+		http.Error(w, "Failed to delete booking", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	booking, err := getBooking(ctx, tx, id, true)
+	if errors.Is(err, sql.ErrNoRows) {
+		http.Error(w, "Booking not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		logger.Error(ctx, "Failed to fetch booking", "id", id, "error", err)
+		http.Error(w, "Failed to delete booking", http.StatusInternalServerError)
+		return
+	}
+	if !ifMatch(r, etag(booking.UpdatedAt)) {
+		writePreconditionFailed(w, booking.UpdatedAt)
+		return
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM bookings WHERE id = $1`, booking.ID); err != nil {
+		writeDBError(ctx, w, err, "Failed to delete booking")
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		writeDBError(ctx, w, err, "Failed to delete booking")
+		return
+	}
+
+	logger.Info(ctx, "Booking deleted", "id", id)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// checkBooking checks that the user and room of booking exist and, if the
+// booking takes the room, that the room can take it. It answers the request
+// and returns false if not. The room row stays locked until tx ends.
+func (h *BookingHandler) checkBooking(ctx context.Context, w http.ResponseWriter, tx *sql.Tx, booking *models.Booking, message string) bool {
+	if !takesRoom(*booking) {
+		return h.checkReferences(ctx, w, tx, booking, message)
+	}
+
+	var reasons []string
+	var userExists bool
+	if err := tx.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM users WHERE id = $1)`, *booking.UserID).Scan(&userExists); err != nil {
+		logger.Error(ctx, "Failed to fetch user", "user_id", *booking.UserID, "error", err)
+		http.Error(w, message, http.StatusInternalServerError)
+		return false
+	}
+	if !userExists {
+		reasons = append(reasons, "User does not exist")
+	}
+
+	var capacity int
+	err := tx.QueryRowContext(ctx, `SELECT capacity FROM rooms WHERE id = $1 FOR UPDATE`, *booking.RoomID).Scan(&capacity)
+	if errors.Is(err, sql.ErrNoRows) {
+		reasons = append(reasons, "Room does not exist")
+	} else if err != nil {
+		logger.Error(ctx, "Failed to fetch room", "room_id", *booking.RoomID, "error", err)
+		http.Error(w, message, http.StatusInternalServerError)
+		return false
+	} else {
+		availability, err := roomAvailability(ctx, tx, *booking.RoomID, capacity, booking.NumberOfGuests, booking.StartDate, booking.EndDate, booking.ID)
+		if err != nil {
+			logger.Error(ctx, "Failed to check room availability", "error", err)
+			http.Error(w, message, http.StatusInternalServerError)
+			return false
+		}
+		reasons = append(reasons, availability...)
+	}
+
+	if len(reasons) > 0 {
+		logger.Info(ctx, "Booking rejected", "externalId", booking.ExternalID, "reasons", reasons)
+		writeJSON(w, http.StatusConflict, models.ValidationResponse{IsValid: false, Reasons: reasons})
+		return false
+	}
+	return true
+}
+
+// checkReferences checks that the user and room of booking exist, if it has
+// them. It answers the request and returns false if not.
+func (h *BookingHandler) checkReferences(ctx context.Context, w http.ResponseWriter, tx *sql.Tx, booking *models.Booking, message string) bool {
+	query := `
+		SELECT ($1::INTEGER IS NULL OR EXISTS (SELECT 1 FROM users WHERE id = $1)),
+		       ($2::INTEGER IS NULL OR EXISTS (SELECT 1 FROM rooms WHERE id = $2))
+	`
+	var userExists, roomExists bool
+	if err := tx.QueryRowContext(ctx, query, booking.UserID, booking.RoomID).Scan(&userExists, &roomExists); err != nil {
+		logger.Error(ctx, "Failed to check booking references", "error", err)
+		http.Error(w, message, http.StatusInternalServerError)
+		return false
+	}
+
+	var reasons []string
+	if !userExists {
+		reasons = append(reasons, "User does not exist")
+	}
+	if !roomExists {
+		reasons = append(reasons, "Room does not exist")
+	}
+	if len(reasons) > 0 {
+		writeJSON(w, http.StatusConflict, models.ValidationResponse{IsValid: false, Reasons: reasons})
+		return false
+	}
+	return true
+}
+
+// takesRoom reports whether booking holds its room for its dates.
+func takesRoom(booking models.Booking) bool {
+	return booking.Status == "Pending" || booking.Status == "Accepted"
+}
+
+func sameIntPtr(a, b *int) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+func generateRandomString(length int) string {
+	const charset = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+	b := make([]byte, length)
+	for i := range b {
+		b[i] = charset[rand.Intn(len(charset))]
+	}
+	return string(b)
 }
\ No newline at end of file
@@ -0,0 +1,150 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"booking-management/internal/models"
+)
+
+func TestApplyBookingRequest(t *testing.T) {
+	userID, roomID := 1, 2
+	current := func() models.Booking {
+		booking := models.Booking{UserID: &userID, RoomID: &roomID, NumberOfGuests: 1, Status: "Accepted"}
+		booking.StartDate, _ = parseDateParam("2030-06-01")
+		booking.EndDate, _ = parseDateParam("2030-06-05")
+		return booking
+	}
+	str := func(s string) *string { return &s }
+	num := func(n int) *int { return &n }
+
+	tests := []struct {
+		name    string
+		req     models.BookingRequest
+		replace bool
+		want    string
+	}{
+		{"patch guests", models.BookingRequest{NumberOfGuests: num(2)}, false, ""},
+		{"patch end before start", models.BookingRequest{EndDate: str("2030-05-01")}, false, "End date must be after start date"},
+		{"patch bad date", models.BookingRequest{StartDate: str("June 1st")}, false, "start_date must be a date (YYYY-MM-DD) or RFC 3339 time"},
+		{"patch unknown status", models.BookingRequest{Status: str("Done")}, false, "status must be Pending, Accepted, Cancelled or Refused"},
+		{"refusal reason on an accepted booking", models.BookingRequest{RefusalReason: str("No")}, false, "refusal_reason is only allowed on Refused bookings"},
+		{"refuse with a reason", models.BookingRequest{Status: str("Refused"), RefusalReason: str("No")}, false, ""},
+		{"put without status", models.BookingRequest{NumberOfGuests: num(1), StartDate: str("2030-06-01"), EndDate: str("2030-06-05")}, true, "status is required"},
+		{"put without a room", models.BookingRequest{UserID: &userID, NumberOfGuests: num(1), StartDate: str("2030-06-01"), EndDate: str("2030-06-05"), Status: str("Accepted")}, true, "user_id and room_id are required unless the booking is Refused"},
+		{"put a refusal without a room", models.BookingRequest{NumberOfGuests: num(1), StartDate: str("2030-06-01"), EndDate: str("2030-06-05"), Status: str("Refused")}, true, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			booking := current()
+			reasons := applyBookingRequest(&booking, tt.req, tt.replace)
+			if tt.want == "" {
+				if len(reasons) > 0 {
+					t.Errorf("applyBookingRequest rejected the booking: %v", reasons)
+				}
+				return
+			}
+			if len(reasons) != 1 || reasons[0] != tt.want {
+				t.Errorf("applyBookingRequest = %v, want [%s]", reasons, tt.want)
+			}
+		})
+	}
+}
+
+func TestBookingWrites(t *testing.T) {
+	db := openTestDB(t)
+	userID, roomID, _, _ := createTestRoom(t, db)
+	h := NewBookingHandler(db)
+
+	create := func(body string) *httptest.ResponseRecorder {
+		return serveWrite(h.CreateBooking, http.MethodPost, "/bookings", nil, body, "")
+	}
+	bookingBody := func(guests int, start, end string) string {
+		return fmt.Sprintf(`{"user_id":%d,"room_id":%d,"number_of_guests":%d,"start_date":%q,"end_date":%q}`, userID, roomID, guests, start, end)
+	}
+
+	rec := create(bookingBody(2, "2030-06-01", "2030-06-05"))
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("create = %d %s, want 201", rec.Code, rec.Body)
+	}
+	var booking models.Booking
+	if err := json.Unmarshal(rec.Body.Bytes(), &booking); err != nil || booking.Status != "Accepted" || booking.ExternalID == "" {
+		t.Fatalf("created booking = %+v, %v, want an Accepted booking with an external_id", booking, err)
+	}
+	id := strconv.Itoa(booking.ID)
+	vars := map[string]string{"id": id}
+	target := "/bookings/" + id
+	created := rec.Header().Get("ETag")
+	if rec.Header().Get("Location") != target {
+		t.Errorf("Location = %q, want %q", rec.Header().Get("Location"), target)
+	}
+
+	conflicts := []struct {
+		name string
+		body string
+		want string
+	}{
+		{"overlapping dates", bookingBody(1, "2030-06-04", "2030-06-08"), "Room is not available for the specified dates"},
+		{"too many guests", bookingBody(3, "2030-07-01", "2030-07-05"), "Number of guests (3) exceeds room capacity (2)"},
+		{"unknown room", fmt.Sprintf(`{"user_id":%d,"room_id":-1,"number_of_guests":1,"start_date":"2030-07-01","end_date":"2030-07-05"}`, userID), "Room does not exist"},
+	}
+	for _, tt := range conflicts {
+		rec := create(tt.body)
+		var resp models.ValidationResponse
+		json.Unmarshal(rec.Body.Bytes(), &resp)
+		if rec.Code != http.StatusConflict || len(resp.Reasons) != 1 || resp.Reasons[0] != tt.want {
+			t.Errorf("create with %s = %d %s, want 409 %q", tt.name, rec.Code, rec.Body, tt.want)
+		}
+	}
+	if rec := create(bookingBody(1, "2030-07-05", "2030-07-01")); rec.Code != http.StatusBadRequest {
+		t.Errorf("create ending before it starts = %d, want 400", rec.Code)
+	}
+
+	// Fixing the payment of a booking leaves its room and dates alone
+	rec = serveWrite(h.PatchBooking, http.MethodPatch, target, vars, `{"payment_id":"pay_test"}`, created)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("patch = %d %s, want 200", rec.Code, rec.Body)
+	}
+	updated := rec.Header().Get("ETag")
+	if updated == created {
+		t.Fatal("patch kept the ETag")
+	}
+
+	if rec := serveWrite(h.PatchBooking, http.MethodPatch, target, vars, `{"status":"Cancelled"}`, created); rec.Code != http.StatusPreconditionFailed {
+		t.Errorf("patch of a stale version = %d, want 412", rec.Code)
+	}
+	if rec := serveWrite(h.PatchBooking, http.MethodPatch, target, vars, `{"external_id":"booking_other"}`, updated); rec.Code != http.StatusBadRequest {
+		t.Errorf("patch of external_id = %d, want 400", rec.Code)
+	}
+
+	// A cancelled booking frees its room for the dates
+	rec = serveWrite(h.PatchBooking, http.MethodPatch, target, vars, `{"status":"Cancelled"}`, updated)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("cancel = %d %s, want 200", rec.Code, rec.Body)
+	}
+	cancelled := rec.Header().Get("ETag")
+	if rec := create(bookingBody(1, "2030-06-04", "2030-06-08")); rec.Code != http.StatusCreated {
+		t.Errorf("create over a cancelled booking = %d %s, want 201", rec.Code, rec.Body)
+	}
+	// and cannot take it back
+	if rec := serveWrite(h.PatchBooking, http.MethodPatch, target, vars, `{"status":"Accepted"}`, cancelled); rec.Code != http.StatusConflict {
+		t.Errorf("accepting over another booking = %d %s, want 409", rec.Code, rec.Body)
+	}
+
+	if rec := serveWrite(h.DeleteBooking, http.MethodDelete, target, vars, "", updated); rec.Code != http.StatusPreconditionFailed {
+		t.Errorf("delete of a stale version = %d, want 412", rec.Code)
+	}
+	if rec := serveWrite(h.DeleteBooking, http.MethodDelete, target, vars, "", cancelled); rec.Code != http.StatusNoContent {
+		t.Fatalf("delete = %d %s, want 204", rec.Code, rec.Body)
+	}
+	if rec := serveWrite(h.GetBooking, http.MethodGet, target, vars, "", ""); rec.Code != http.StatusNotFound {
+		t.Errorf("get of a deleted booking = %d, want 404", rec.Code)
+	}
+	if rec := serveWrite(h.PatchBooking, http.MethodPatch, target, vars, `{"status":"Accepted"}`, ""); rec.Code != http.StatusNotFound {
+		t.Errorf("patch of a deleted booking = %d, want 404", rec.Code)
+	}
+}
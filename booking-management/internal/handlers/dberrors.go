@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"booking-management/db"
+	"booking-management/internal/logger"
+
+	"github.com/lib/pq"
+)
+
+// uniqueViolations names the unique constraints clients can run into.
+var uniqueViolations = map[string]string{
+	"users_email_key":          "A user with this email already exists",
+	"users_username_key":       "A user with this username already exists",
+	"rooms_internal_id_key":    "A room with this internal_id already exists",
+	"bookings_external_id_key": "A booking with this external_id already exists",
+}
+
+// constraintError maps a constraint violation reported by PostgreSQL to a
+// client error. ok is false for any other error.
+func constraintError(err error) (status int, message string, ok bool) {
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		return 0, "", false
+	}
+
+	switch pqErr.Code {
+	case "23505": // unique_violation
+		if message, ok := uniqueViolations[pqErr.Constraint]; ok {
+			return http.StatusConflict, message, true
+		}
+		return http.StatusConflict, "Conflicts with an existing record", true
+	case "23503": // foreign_key_violation
+		return http.StatusConflict, "References a record that does not exist", true
+	case "23514": // check_violation
+		return http.StatusBadRequest, "Violates constraint " + pqErr.Constraint, true
+	case "23P01": // exclusion_violation, only db.BookingOverlapConstraint so far
+		return http.StatusConflict, "Room is not available for the specified dates", true
+	}
+	return 0, "", false
+}
+
+// isOverlapViolation reports whether err is db.BookingOverlapConstraint
+// refusing a Pending or Accepted booking whose room is taken for its dates.
+func isOverlapViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23P01" && pqErr.Constraint == db.BookingOverlapConstraint
+}
+
+// writeDBError answers a failed write: constraint violations as client
+// errors, anything else as a 500 with message.
+func writeDBError(ctx context.Context, w http.ResponseWriter, err error, message string) {
+	if status, clientMessage, ok := constraintError(err); ok {
+		logger.Info(ctx, "Write rejected by constraint", "error", err)
+		http.Error(w, clientMessage, status)
+		return
+	}
+	logger.Error(ctx, message, "error", err)
+	http.Error(w, message, http.StatusInternalServerError)
+}
\ No newline at end of file
@@ -0,0 +1,43 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// etag returns the entity tag of a row last updated at updatedAt. Every
+// write touches updated_at, so the tag changes with every version.
+func etag(updatedAt time.Time) string {
+	return `"` + strconv.FormatInt(updatedAt.UnixMicro(), 10) + `"`
+}
+
+// ifMatch reports whether the If-Match header of r allows changing a row
+// whose current entity tag is current. Requests without If-Match always
+// match, so clients opt in to optimistic concurrency.
+func ifMatch(r *http.Request, current string) bool {
+	header := r.Header.Get("If-Match")
+	if header == "" {
+		return true
+	}
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+		if tag == "*" || tag == current {
+			return true
+		}
+	}
+	return false
+}
+
+// writeEntity writes a single row with its entity tag.
+func writeEntity(w http.ResponseWriter, status int, updatedAt time.Time, body any) {
+	w.Header().Set("ETag", etag(updatedAt))
+	writeJSON(w, status, body)
+}
+
+// writePreconditionFailed answers a write whose If-Match no longer matches.
+func writePreconditionFailed(w http.ResponseWriter, updatedAt time.Time) {
+	w.Header().Set("ETag", etag(updatedAt))
+	http.Error(w, "The resource was changed by another request; fetch it again and retry", http.StatusPreconditionFailed)
+}
\ No newline at end of file
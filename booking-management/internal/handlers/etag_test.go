@@ -0,0 +1,52 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+func TestIfMatch(t *testing.T) {
+	current := etag(time.Date(2030, 6, 1, 12, 0, 0, 123456000, time.UTC))
+
+	tests := []struct {
+		header string
+		want   bool
+	}{
+		{"", true},
+		{"*", true},
+		{current, true},
+		{"W/" + current, true},
+		{`"1", ` + current, true},
+		{`"1"`, false},
+		{`"1", "2"`, false},
+		{strings.Trim(current, `"`), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.header, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPut, "/rooms/101", nil)
+			if tt.header != "" {
+				r.Header.Set("If-Match", tt.header)
+			}
+			if got := ifMatch(r, current); got != tt.want {
+				t.Errorf("ifMatch(%q, %q) = %v, want %v", tt.header, current, got, tt.want)
+			}
+		})
+	}
+}
+
+// serveWrite calls handle with a request for a route with vars, sending
+// body and, if set, the If-Match header.
+func serveWrite(handle http.HandlerFunc, method, target string, vars map[string]string, body, match string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(method, target, strings.NewReader(body))
+	if match != "" {
+		r.Header.Set("If-Match", match)
+	}
+	rec := httptest.NewRecorder()
+	handle(rec, mux.SetURLVars(r, vars))
+	return rec
+}
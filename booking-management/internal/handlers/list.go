@@ -0,0 +1,239 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"booking-management/internal/models"
+)
+
+const (
+	defaultPageSize = 50
+	maxPageSize     = 500
+)
+
+// sortColumns maps the sort keys a list endpoint accepts to the value of
+// that column in a row, as it is stored in cursors. Every key is a column
+// name, and every set includes "id", the tie-breaker of every order.
+type sortColumns[T any] map[string]func(T) string
+
+func (s sortColumns[T]) keys() string {
+	keys := make([]string, 0, len(s))
+	for key := range s {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return strings.Join(keys, ", ")
+}
+
+// listCursor is the position after the last row of a page. It is handed to
+// clients base64-encoded and opaque.
+type listCursor struct {
+	Sort  string `json:"s"`
+	Value string `json:"v"`
+	ID    int    `json:"id"`
+}
+
+func encodeCursor(c listCursor) string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCursor(value string) (*listCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, err
+	}
+	var c listCursor
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// parseSort splits a sort parameter such as "-floor" into a column and a
+// direction, after checking the column against sorts.
+func parseSort[T any](value string, sorts sortColumns[T]) (column string, desc bool, err error) {
+	if strings.HasPrefix(value, "-") {
+		desc = true
+		value = value[1:]
+	}
+	if _, ok := sorts[value]; !ok {
+		return "", false, fmt.Errorf("sort must be one of %s, optionally prefixed with -", sorts.keys())
+	}
+	return value, desc, nil
+}
+
+// listQuery builds the parameterized SELECT of a list endpoint. Filters are
+// added with where and arg, so values never end up in the SQL text.
+type listQuery struct {
+	conditions []string
+	args       []any
+}
+
+// arg adds value as a query argument and returns its placeholder.
+func (q *listQuery) arg(value any) string {
+	q.args = append(q.args, value)
+	return "$" + strconv.Itoa(len(q.args))
+}
+
+func (q *listQuery) where(condition string) {
+	q.conditions = append(q.conditions, condition)
+}
+
+// whereClause returns the WHERE clause of the conditions added so far.
+func (q *listQuery) whereClause() string {
+	if len(q.conditions) == 0 {
+		return ""
+	}
+	return ` WHERE ` + strings.Join(q.conditions, " AND ")
+}
+
+// intFilter adds condition, which holds one %s for the placeholder, if the
+// parameter is set. It fails if the parameter is not a number.
+func (q *listQuery) intFilter(params url.Values, param, condition string) error {
+	value := params.Get(param)
+	if value == "" {
+		return nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return fmt.Errorf("%s must be a number", param)
+	}
+	q.where(fmt.Sprintf(condition, q.arg(n)))
+	return nil
+}
+
+// dateFilter is intFilter for dates, see parseDateParam.
+func (q *listQuery) dateFilter(params url.Values, param, condition string) error {
+	value := params.Get(param)
+	if value == "" {
+		return nil
+	}
+	t, err := parseDateParam(value)
+	if err != nil {
+		return fmt.Errorf("%s must be a date (YYYY-MM-DD) or RFC 3339 time", param)
+	}
+	q.where(fmt.Sprintf(condition, q.arg(t)))
+	return nil
+}
+
+// prefixFilter adds a case-insensitive prefix match on column if the
+// parameter is set. LIKE wildcards in the value match literally.
+func (q *listQuery) prefixFilter(params url.Values, param, column string) {
+	value := params.Get(param)
+	if value == "" {
+		return
+	}
+	escaped := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(value)
+	q.where(column + ` ILIKE ` + q.arg(escaped+"%"))
+}
+
+// listPage is the page a list request asks for: its size, order and the
+// cursor it continues from.
+type listPage struct {
+	limit  int
+	sort   string
+	column string
+	desc   bool
+	cursor *listCursor
+}
+
+// parseListPage reads the limit, sort and cursor parameters of r. sort
+// defaults to ascending IDs.
+func parseListPage[T any](r *http.Request, sorts sortColumns[T]) (listPage, error) {
+	params := r.URL.Query()
+
+	limit, err := parseIntParam(params.Get("limit"), defaultPageSize)
+	if err != nil || limit <= 0 || limit > maxPageSize {
+		return listPage{}, fmt.Errorf("limit must be a number from 1 to %d", maxPageSize)
+	}
+
+	page := listPage{limit: limit, sort: params.Get("sort")}
+	if page.sort == "" {
+		page.sort = "id"
+	}
+	page.column, page.desc, err = parseSort(page.sort, sorts)
+	if err != nil {
+		return listPage{}, err
+	}
+
+	if value := params.Get("cursor"); value != "" {
+		page.cursor, err = decodeCursor(value)
+		if err != nil {
+			return listPage{}, errors.New("cursor is invalid")
+		}
+		// A cursor only means something in the order it was made for
+		if page.cursor.Sort != page.sort {
+			return listPage{}, errors.New("cursor was made for another sort; drop it to start over")
+		}
+	}
+	return page, nil
+}
+
+// build returns the query selecting columns from table that yields page:
+// the rows after its cursor, in its order, plus one row that tells whether
+// there is a next page.
+func (q *listQuery) build(columns, table string, page listPage) string {
+	op, direction := ">", "ASC"
+	if page.desc {
+		op, direction = "<", "DESC"
+	}
+
+	if c := page.cursor; c != nil {
+		if page.column == "id" {
+			q.where("id " + op + " " + q.arg(c.ID))
+		} else {
+			q.where("(" + page.column + ", id) " + op + " (" + q.arg(c.Value) + ", " + q.arg(c.ID) + ")")
+		}
+	}
+
+	order := page.column + " " + direction
+	if page.column != "id" {
+		order += ", id " + direction
+	}
+	return `SELECT ` + columns + ` FROM ` + table + q.whereClause() +
+		` ORDER BY ` + order + ` LIMIT ` + q.arg(page.limit+1)
+}
+
+// writePage answers a list request with the rows fetched for page by
+// build. When there is a next page, its cursor is returned as next_cursor
+// and in a Link header relative to the request, so it survives the gateway
+// stripping its route prefix.
+func writePage[T any](w http.ResponseWriter, r *http.Request, rows []T, page listPage, sorts sortColumns[T]) {
+	result := models.Page[T]{Data: rows}
+	if result.Data == nil {
+		result.Data = []T{}
+	}
+
+	if len(rows) > page.limit {
+		result.Data = rows[:page.limit]
+		last := result.Data[page.limit-1]
+		id, _ := strconv.Atoi(sorts["id"](last))
+		result.NextCursor = encodeCursor(listCursor{Sort: page.sort, Value: sorts[page.column](last), ID: id})
+
+		params := r.URL.Query()
+		params.Set("cursor", result.NextCursor)
+		w.Header().Set("Link", `<?`+params.Encode()+`>; rel="next"`)
+	}
+
+	writeJSON(w, http.StatusOK, result)
+}
+
+// formatDate and formatTime render sort values of DATE and TIMESTAMP
+// columns so PostgreSQL reads them back as the same value.
+func formatDate(t time.Time) string {
+	return t.Format("2006-01-02")
+}
+
+func formatTime(t time.Time) string {
+	return t.Format("2006-01-02T15:04:05.999999")
+}
@@ -0,0 +1,179 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+
+	"booking-management/internal/models"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	want := listCursor{Sort: "-floor", Value: "3", ID: 42}
+
+	got, err := decodeCursor(encodeCursor(want))
+	if err != nil || *got != want {
+		t.Fatalf("decodeCursor(encodeCursor(%+v)) = %+v, %v", want, got, err)
+	}
+
+	for _, value := range []string{"not base64!", "bm90IGpzb24"} {
+		if _, err := decodeCursor(value); err == nil {
+			t.Errorf("decodeCursor(%q) accepted a malformed cursor", value)
+		}
+	}
+}
+
+func TestParseListPage(t *testing.T) {
+	floorCursor := encodeCursor(listCursor{Sort: "floor", Value: "2", ID: 7})
+
+	tests := []struct {
+		name    string
+		query   string
+		want    listPage
+		wantErr string
+	}{
+		{
+			name:  "defaults",
+			query: "",
+			want:  listPage{limit: defaultPageSize, sort: "id", column: "id"},
+		},
+		{
+			name:  "descending sort",
+			query: "sort=-floor&limit=10",
+			want:  listPage{limit: 10, sort: "-floor", column: "floor", desc: true},
+		},
+		{
+			name:  "cursor of the same sort",
+			query: "sort=floor&cursor=" + floorCursor,
+			want:  listPage{limit: defaultPageSize, sort: "floor", column: "floor", cursor: &listCursor{Sort: "floor", Value: "2", ID: 7}},
+		},
+		{
+			name:    "cursor replayed with another sort",
+			query:   "sort=-floor&cursor=" + floorCursor,
+			wantErr: "cursor was made for another sort",
+		},
+		{
+			name:    "cursor replayed with the default sort",
+			query:   "cursor=" + floorCursor,
+			wantErr: "cursor was made for another sort",
+		},
+		{name: "malformed cursor", query: "cursor=abc", wantErr: "cursor is invalid"},
+		{name: "unknown sort", query: "sort=price", wantErr: "sort must be one of"},
+		{name: "sort that is not a column", query: "sort=" + url.QueryEscape("floor; DROP TABLE rooms"), wantErr: "sort must be one of"},
+		{name: "zero limit", query: "limit=0", wantErr: "limit must be a number"},
+		{name: "limit above the maximum", query: "limit=501", wantErr: "limit must be a number"},
+		{name: "non-numeric limit", query: "limit=ten", wantErr: "limit must be a number"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			page, err := parseListPage(httptest.NewRequest("GET", "/rooms?"+tt.query, nil), roomSorts)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("parseListPage error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseListPage failed: %v", err)
+			}
+			if !reflect.DeepEqual(page, tt.want) {
+				t.Errorf("parseListPage = %+v, want %+v", page, tt.want)
+			}
+		})
+	}
+}
+
+func TestListQueryBuild(t *testing.T) {
+	tests := []struct {
+		name     string
+		page     listPage
+		wantSQL  string
+		wantArgs []any
+	}{
+		{
+			name:     "first page by id",
+			page:     listPage{limit: 2, sort: "id", column: "id"},
+			wantSQL:  `SELECT id FROM rooms WHERE floor = $1 ORDER BY id ASC LIMIT $2`,
+			wantArgs: []any{3, 3},
+		},
+		{
+			name:     "next page by id",
+			page:     listPage{limit: 2, sort: "id", column: "id", cursor: &listCursor{Sort: "id", ID: 9}},
+			wantSQL:  `SELECT id FROM rooms WHERE floor = $1 AND id > $2 ORDER BY id ASC LIMIT $3`,
+			wantArgs: []any{3, 9, 3},
+		},
+		{
+			name:     "next page by a descending column",
+			page:     listPage{limit: 2, sort: "-capacity", column: "capacity", desc: true, cursor: &listCursor{Sort: "-capacity", Value: "4", ID: 9}},
+			wantSQL:  `SELECT id FROM rooms WHERE floor = $1 AND (capacity, id) < ($2, $3) ORDER BY capacity DESC, id DESC LIMIT $4`,
+			wantArgs: []any{3, "4", 9, 3},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var q listQuery
+			q.where("floor = " + q.arg(3))
+
+			sql := q.build("id", "rooms", tt.page)
+			if sql != tt.wantSQL {
+				t.Errorf("build =\n%s\nwant\n%s", sql, tt.wantSQL)
+			}
+			if !reflect.DeepEqual(q.args, tt.wantArgs) {
+				t.Errorf("args = %v, want %v", q.args, tt.wantArgs)
+			}
+		})
+	}
+}
+
+func TestPrefixFilterEscapesWildcards(t *testing.T) {
+	var q listQuery
+	q.prefixFilter(url.Values{"username": {`a_b%c\`}}, "username", "username")
+
+	if got := q.whereClause(); got != ` WHERE username ILIKE $1` {
+		t.Errorf("whereClause = %q", got)
+	}
+	if want := `a\_b\%c\\%`; q.args[0] != want {
+		t.Errorf("pattern = %q, want %q", q.args[0], want)
+	}
+}
+
+func TestWritePage(t *testing.T) {
+	rooms := []models.Room{{ID: 1, Floor: 1}, {ID: 2, Floor: 2}, {ID: 3, Floor: 2}}
+	page := listPage{limit: 2, sort: "-floor", column: "floor", desc: true}
+
+	req := httptest.NewRequest("GET", "/rooms?sort=-floor&limit=2", nil)
+	rec := httptest.NewRecorder()
+	writePage(rec, req, rooms, page, roomSorts)
+
+	var body models.Page[models.Room]
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("failed to decode page: %v", err)
+	}
+	if len(body.Data) != 2 {
+		t.Fatalf("page has %d rows, want 2", len(body.Data))
+	}
+
+	// The cursor continues after the last row returned, in the same order
+	cursor, err := decodeCursor(body.NextCursor)
+	if err != nil {
+		t.Fatalf("next_cursor is invalid: %v", err)
+	}
+	if want := (listCursor{Sort: "-floor", Value: "2", ID: 2}); *cursor != want {
+		t.Errorf("next cursor = %+v, want %+v", *cursor, want)
+	}
+
+	link := rec.Header().Get("Link")
+	if !strings.HasPrefix(link, "<?") || !strings.Contains(link, "cursor="+body.NextCursor) || !strings.HasSuffix(link, `>; rel="next"`) {
+		t.Errorf("Link = %q", link)
+	}
+
+	// The last page has no cursor and an empty list renders as []
+	rec = httptest.NewRecorder()
+	writePage(rec, req, []models.Room(nil), page, roomSorts)
+	if got := strings.TrimSpace(rec.Body.String()); got != `{"data":[]}` || rec.Header().Get("Link") != "" {
+		t.Errorf("empty page = %s with Link %q", got, rec.Header().Get("Link"))
+	}
+}
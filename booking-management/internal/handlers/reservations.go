@@ -0,0 +1,216 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"booking-management/internal/database"
+	"booking-management/internal/logger"
+	"booking-management/internal/models"
+
+	"github.com/gorilla/mux"
+)
+
+// ReservationHandler lets the booking service hold a room while it charges
+// for a booking, and give the room back if the booking cannot be completed.
+// A reservation is a Pending booking that the worker later accepts.
+type ReservationHandler struct {
+	db *database.DB
+}
+
+func NewReservationHandler(db *database.DB) *ReservationHandler {
+	return &ReservationHandler{db: db}
+}
+
+// Reserve stores a Pending booking if the room can take it. The room row is
+// locked while checking, so two reservations cannot both take the room.
+// Reserving an external ID again returns the existing booking while it holds
+// the room, so the call can be retried; once the booking is Refused or
+// Cancelled the reservation is rejected.
+func (h *ReservationHandler) Reserve(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	logger.Info(ctx, "Processing reservation request")
+
+	var req models.ReservationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logger.Error(ctx, "Failed to decode reservation request", "error", err)
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if req.ExternalID == "" || req.UserID == "" || req.RoomID == "" {
+		logger.Error(ctx, "Missing required fields in reservation request")
+		http.Error(w, "Missing required fields: external_id, user_id and room_id are required", http.StatusBadRequest)
+		return
+	}
+
+	tx, err := h.db.BeginTx(ctx, nil)
+	if err != nil {
+		logger.Error(ctx, "Failed to begin transaction", "error", err)
+		http.Error(w, "Failed to reserve room", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	existing, err := getBookingByExternalID(ctx, tx, req.ExternalID)
+	if err == nil && takesRoom(*existing) {
+		logger.Info(ctx, "Reservation already exists", "externalId", req.ExternalID, "status", existing.Status)
+		writeJSON(w, http.StatusOK, existing)
+		return
+	}
+	if err == nil {
+		// A released or refused booking no longer holds the room, so a retry
+		// must not be told it does
+		logger.Info(ctx, "Reservation rejected, booking already decided", "externalId", req.ExternalID, "status", existing.Status)
+		writeJSON(w, http.StatusConflict, models.ValidationResponse{IsValid: false, Reasons: []string{"Booking is already " + existing.Status}})
+		return
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		logger.Error(ctx, "Failed to fetch booking from database", "externalId", req.ExternalID, "error", err)
+		http.Error(w, "Failed to reserve room", http.StatusInternalServerError)
+		return
+	}
+
+	var reasons []string
+
+	var userID int
+	userQuery := `
+		SELECT id FROM users
+		WHERE email = $1
+		   OR username = $1
+		   OR (CASE WHEN $1 ~ '^[0-9]+$' THEN id = CAST($1 AS INTEGER) ELSE false END)
+	`
+	err = tx.QueryRowContext(ctx, userQuery, req.UserID).Scan(&userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		reasons = append(reasons, "User does not exist")
+	} else if err != nil {
+		logger.Error(ctx, "Failed to fetch user", "error", err, "user_id", req.UserID)
+		http.Error(w, "Failed to reserve room", http.StatusInternalServerError)
+		return
+	}
+
+	var roomID, capacity int
+	roomQuery := `SELECT id, capacity FROM rooms WHERE internal_id = $1 FOR UPDATE`
+	err = tx.QueryRowContext(ctx, roomQuery, req.RoomID).Scan(&roomID, &capacity)
+	roomExists := err == nil
+	if errors.Is(err, sql.ErrNoRows) {
+		reasons = append(reasons, "Room does not exist")
+	} else if err != nil {
+		logger.Error(ctx, "Failed to fetch room", "error", err, "room_id", req.RoomID)
+		http.Error(w, "Failed to reserve room", http.StatusInternalServerError)
+		return
+	}
+
+	if !req.EndDate.After(req.StartDate) {
+		reasons = append(reasons, "End date must be after start date")
+	}
+	if req.NumberOfGuests <= 0 {
+		reasons = append(reasons, "Number of guests must be greater than 0")
+	}
+
+	if roomExists {
+		availability, err := roomAvailability(ctx, tx, roomID, capacity, req.NumberOfGuests, req.StartDate, req.EndDate, 0)
+		if err != nil {
+			logger.Error(ctx, "Failed to check room availability", "error", err)
+			http.Error(w, "Failed to reserve room", http.StatusInternalServerError)
+			return
+		}
+		reasons = append(reasons, availability...)
+	}
+
+	if len(reasons) > 0 {
+		logger.Info(ctx, "Reservation rejected", "externalId", req.ExternalID, "reasons", reasons)
+		writeJSON(w, http.StatusConflict, models.ValidationResponse{IsValid: false, Reasons: reasons})
+		return
+	}
+
+	var paymentID sql.NullString
+	if req.PaymentID != "" {
+		paymentID = sql.NullString{String: req.PaymentID, Valid: true}
+	}
+
+	insert := `
+		INSERT INTO bookings (external_id, user_id, room_id, number_of_guests, start_date, end_date, payment_id, status)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, 'Pending')
+		RETURNING ` + bookingColumns
+
+	var booking models.Booking
+	row := tx.QueryRowContext(ctx, insert, req.ExternalID, userID, roomID, req.NumberOfGuests, req.StartDate, req.EndDate, paymentID)
+	err = scanBooking(row, &booking)
+	if isOverlapViolation(err) {
+		// Taken by a booking written without locking the room
+		logger.Info(ctx, "Reservation rejected by overlap constraint", "externalId", req.ExternalID)
+		writeJSON(w, http.StatusConflict, models.ValidationResponse{IsValid: false, Reasons: []string{"Room is not available for the specified dates"}})
+		return
+	}
+	if err != nil {
+		logger.Error(ctx, "Failed to insert reservation", "externalId", req.ExternalID, "error", err)
+		http.Error(w, "Failed to reserve room", http.StatusInternalServerError)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		logger.Error(ctx, "Failed to commit reservation", "externalId", req.ExternalID, "error", err)
+		http.Error(w, "Failed to reserve room", http.StatusInternalServerError)
+		return
+	}
+
+	logger.Info(ctx, "Room reserved", "externalId", req.ExternalID, "room_id", req.RoomID, "id", booking.ID)
+	writeJSON(w, http.StatusCreated, booking)
+}
+
+// Release gives the room of a reservation back. A Pending booking becomes
+// Refused with the given reason; one the worker already accepted becomes
+// Cancelled. Releasing a Refused or Cancelled booking changes nothing.
+func (h *ReservationHandler) Release(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	externalID := mux.Vars(r)["externalId"]
+	logger.Info(ctx, "Processing reservation release", "externalId", externalID)
+
+	var req models.ReleaseRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logger.Error(ctx, "Failed to decode release request", "error", err)
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if req.Reason == "" {
+		req.Reason = "Reservation released"
+	}
+
+	query := `
+		UPDATE bookings
+		SET status = CASE WHEN status = 'Pending' THEN 'Refused' ELSE 'Cancelled' END,
+		    refusal_reason = CASE WHEN status = 'Pending' THEN $2 ELSE refusal_reason END,
+		    updated_at = NOW()
+		WHERE external_id = $1 AND status IN ('Pending', 'Accepted')
+	`
+
+	if _, err := h.db.ExecContext(ctx, query, externalID, req.Reason); err != nil {
+		logger.Error(ctx, "Failed to release reservation", "externalId", externalID, "error", err)
+		http.Error(w, "Failed to release reservation", http.StatusInternalServerError)
+		return
+	}
+
+	booking, err := getBookingByExternalID(ctx, h.db, externalID)
+	if errors.Is(err, sql.ErrNoRows) {
+		logger.Info(ctx, "Reservation not found", "externalId", externalID)
+		http.Error(w, "Reservation not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		logger.Error(ctx, "Failed to fetch booking from database", "externalId", externalID, "error", err)
+		http.Error(w, "Failed to release reservation", http.StatusInternalServerError)
+		return
+	}
+
+	logger.Info(ctx, "Reservation released", "externalId", externalID, "status", booking.Status)
+	writeJSON(w, http.StatusOK, booking)
+}
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
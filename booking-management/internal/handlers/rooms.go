@@ -1,14 +1,48 @@
 package handlers
 
 import (
+	"context"
+	"database/sql"
 	"encoding/json"
+	"errors"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	"booking-management/internal/database"
 	"booking-management/internal/logger"
 	"booking-management/internal/models"
+
+	"github.com/gorilla/mux"
 )
 
+// roomColumns lists the columns scanRoom reads, in order.
+const roomColumns = `id, internal_id, name, floor, bathrooms, beds, capacity, created_at, updated_at`
+
+var roomSorts = sortColumns[models.Room]{
+	"id":        func(room models.Room) string { return strconv.Itoa(room.ID) },
+	"floor":     func(room models.Room) string { return strconv.Itoa(room.Floor) },
+	"beds":      func(room models.Room) string { return strconv.Itoa(room.Beds) },
+	"bathrooms": func(room models.Room) string { return strconv.Itoa(room.Bathrooms) },
+	"capacity":  func(room models.Room) string { return strconv.Itoa(room.Capacity) },
+	"name":      func(room models.Room) string { return room.Name },
+}
+
+func scanRoom(row rowScanner, room *models.Room) error {
+	return row.Scan(
+		&room.ID,
+		&room.InternalID,
+		&room.Name,
+		&room.Floor,
+		&room.Bathrooms,
+		&room.Beds,
+		&room.Capacity,
+		&room.CreatedAt,
+		&room.UpdatedAt,
+	)
+}
+
 type RoomHandler struct {
 	db *database.DB
 }
@@ -17,17 +51,35 @@ func NewRoomHandler(db *database.DB) *RoomHandler {
 	return &RoomHandler{db: db}
 }
 
+// GetRooms lists rooms a page at a time, see parseListPage. floor filters on
+// the floor and min_capacity on a minimum capacity.
 func (h *RoomHandler) GetRooms(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
-	logger.Info(ctx, "Fetching rooms")
+	params := r.URL.Query()
 
-	query := `
-		SELECT id, internal_id, name, floor, bathrooms, beds, capacity, created_at, updated_at
-		FROM rooms
-		ORDER BY id ASC
-	`
+	page, err := parseListPage(r, roomSorts)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var q listQuery
+	for _, filter := range []struct {
+		param string
+		cond  string
+	}{
+		{"floor", "floor = %s"},
+		{"min_capacity", "capacity >= %s"},
+	} {
+		if err := q.intFilter(params, filter.param, filter.cond); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
 
-	rows, err := h.db.Query(query)
+	logger.Info(ctx, "Fetching rooms", "limit", page.limit, "sort", page.sort)
+
+	rows, err := h.db.QueryContext(ctx, q.build(roomColumns, "rooms", page), q.args...)
 	if err != nil {
 		logger.Error(ctx, "Failed to fetch rooms from database", "error", err)
 		http.Error(w, "Failed to fetch rooms", http.StatusInternalServerError)
@@ -38,18 +90,7 @@ func (h *RoomHandler) GetRooms(w http.ResponseWriter, r *http.Request) {
 	var rooms []models.Room
 	for rows.Next() {
 		var room models.Room
-		err := rows.Scan(
-			&room.ID,
-			&room.InternalID,
-			&room.Name,
-			&room.Floor,
-			&room.Bathrooms,
-			&room.Beds,
-			&room.Capacity,
-			&room.CreatedAt,
-			&room.UpdatedAt,
-		)
-		if err != nil {
+		if err := scanRoom(rows, &room); err != nil {
 			logger.Error(ctx, "Failed to scan room", "error", err)
 			http.Error(w, "Failed to scan room", http.StatusInternalServerError)
 			return
@@ -64,6 +105,110 @@ func (h *RoomHandler) GetRooms(w http.ResponseWriter, r *http.Request) {
 	}
 
 	logger.Info(ctx, "Successfully fetched rooms", "count", len(rooms))
+	writePage(w, r, rooms, page, roomSorts)
+}
+
+// GetAvailableRooms lists the rooms that fit guests and that no Pending or
+// Accepted booking holds between start and end, in one query. floor
+// filters on the floor, beds and bathrooms on a minimum, and sort orders by
+// a column of roomSorts, descending with a leading "-".
+func (h *RoomHandler) GetAvailableRooms(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	params := r.URL.Query()
+
+	start, err := parseDateParam(params.Get("start"))
+	if err != nil {
+		http.Error(w, "start is required and must be a date (YYYY-MM-DD) or RFC 3339 time", http.StatusBadRequest)
+		return
+	}
+	end, err := parseDateParam(params.Get("end"))
+	if err != nil {
+		http.Error(w, "end is required and must be a date (YYYY-MM-DD) or RFC 3339 time", http.StatusBadRequest)
+		return
+	}
+	if !end.After(start) {
+		http.Error(w, "end must be after start", http.StatusBadRequest)
+		return
+	}
+
+	guests, err := parseIntParam(params.Get("guests"), 1)
+	if err != nil || guests <= 0 {
+		http.Error(w, "guests must be a number greater than 0", http.StatusBadRequest)
+		return
+	}
+
+	var q listQuery
+	startArg, endArg := q.arg(start), q.arg(end)
+	q.where("r.capacity >= " + q.arg(guests))
+	for _, filter := range []struct {
+		param string
+		cond  string
+	}{
+		{"floor", "r.floor = %s"},
+		{"beds", "r.beds >= %s"},
+		{"bathrooms", "r.bathrooms >= %s"},
+	} {
+		if err := q.intFilter(params, filter.param, filter.cond); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	sort := params.Get("sort")
+	if sort == "" {
+		sort = "floor"
+	}
+	sortColumn, desc, err := parseSort(sort, roomSorts)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	direction := "ASC"
+	if desc {
+		direction = "DESC"
+	}
+
+	logger.Info(ctx, "Searching available rooms", "start", start, "end", end, "guests", guests, "sort", params.Get("sort"))
+
+	query := `
+		SELECT ` + roomColumns + `
+		FROM rooms r` + q.whereClause() + `
+		AND NOT EXISTS (
+			SELECT 1 FROM bookings b
+			WHERE b.room_id = r.id
+			AND b.status IN ('Pending', 'Accepted')
+			AND b.start_date < ` + endArg + `
+			AND b.end_date > ` + startArg + `
+		)
+		ORDER BY r.` + sortColumn + ` ` + direction + `, r.id ASC
+	`
+
+	rows, err := h.db.QueryContext(ctx, query, q.args...)
+	if err != nil {
+		logger.Error(ctx, "Failed to search available rooms", "error", err)
+		http.Error(w, "Failed to search available rooms", http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+
+	rooms := []models.Room{}
+	for rows.Next() {
+		var room models.Room
+		if err := scanRoom(rows, &room); err != nil {
+			logger.Error(ctx, "Failed to scan room", "error", err)
+			http.Error(w, "Failed to scan room", http.StatusInternalServerError)
+			return
+		}
+		rooms = append(rooms, room)
+	}
+
+	if err = rows.Err(); err != nil {
+		logger.Error(ctx, "Error iterating rooms", "error", err)
+		http.Error(w, "Error iterating rooms", http.StatusInternalServerError)
+		return
+	}
+
+	logger.Info(ctx, "Successfully searched available rooms", "count", len(rooms))
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
@@ -73,4 +218,283 @@ func (h *RoomHandler) GetRooms(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
 		return
 	}
+}
+
+// parseDateParam accepts a date such as 2025-10-15 or an RFC 3339 time.
+func parseDateParam(value string) (time.Time, error) {
+	if t, err := time.Parse("2006-01-02", value); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
+
+// parseIntParam returns defaultValue for an empty value.
+func parseIntParam(value string, defaultValue int) (int, error) {
+	if value == "" {
+		return defaultValue, nil
+	}
+	return strconv.Atoi(value)
+}
+
+// getRoom returns sql.ErrNoRows when no room has the internal ID. lock
+// keeps the row locked until the transaction of q ends.
+func getRoom(ctx context.Context, q rowQuerier, internalID string, lock bool) (*models.Room, error) {
+	query := `SELECT ` + roomColumns + ` FROM rooms WHERE internal_id = $1`
+	if lock {
+		query += ` FOR UPDATE`
+	}
+
+	var room models.Room
+	if err := scanRoom(q.QueryRowContext(ctx, query, internalID), &room); err != nil {
+		return nil, err
+	}
+	return &room, nil
+}
+
+// applyRoomRequest copies the fields set in req onto room and returns why
+// the result is invalid, if it is. replace requires every field.
+func applyRoomRequest(room *models.Room, req models.RoomRequest, replace bool) []string {
+	if replace {
+		var missing []string
+		if req.Name == nil {
+			missing = append(missing, "name is required")
+		}
+		if req.Floor == nil {
+			missing = append(missing, "floor is required")
+		}
+		if req.Bathrooms == nil {
+			missing = append(missing, "bathrooms is required")
+		}
+		if req.Beds == nil {
+			missing = append(missing, "beds is required")
+		}
+		if req.Capacity == nil {
+			missing = append(missing, "capacity is required")
+		}
+		if len(missing) > 0 {
+			return missing
+		}
+	}
+
+	if req.Name != nil {
+		room.Name = strings.TrimSpace(*req.Name)
+	}
+	if req.Floor != nil {
+		room.Floor = *req.Floor
+	}
+	if req.Bathrooms != nil {
+		room.Bathrooms = *req.Bathrooms
+	}
+	if req.Beds != nil {
+		room.Beds = *req.Beds
+	}
+	if req.Capacity != nil {
+		room.Capacity = *req.Capacity
+	}
+
+	var reasons []string
+	if room.Name == "" || len(room.Name) > 255 {
+		reasons = append(reasons, "name must be 1 to 255 characters")
+	}
+	if room.Floor < 0 {
+		reasons = append(reasons, "floor must not be negative")
+	}
+	if room.Bathrooms < 0 {
+		reasons = append(reasons, "bathrooms must not be negative")
+	}
+	if room.Beds <= 0 {
+		reasons = append(reasons, "beds must be greater than 0")
+	}
+	if room.Capacity <= 0 {
+		reasons = append(reasons, "capacity must be greater than 0")
+	}
+	return reasons
+}
+
+func (h *RoomHandler) GetRoom(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	internalID := mux.Vars(r)["internalId"]
+
+	room, err := getRoom(ctx, h.db, internalID, false)
+	if errors.Is(err, sql.ErrNoRows) {
+		http.Error(w, "Room not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		logger.Error(ctx, "Failed to fetch room", "internal_id", internalID, "error", err)
+		http.Error(w, "Failed to fetch room", http.StatusInternalServerError)
+		return
+	}
+
+	writeEntity(w, http.StatusOK, room.UpdatedAt, room)
+}
+
+func (h *RoomHandler) CreateRoom(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	logger.Info(ctx, "Creating room")
+
+	var req models.RoomRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logger.Error(ctx, "Failed to decode room request", "error", err)
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	var room models.Room
+	var reasons []string
+	if req.InternalID != nil {
+		room.InternalID = strings.TrimSpace(*req.InternalID)
+	}
+	// "available" would be shadowed by GET /rooms/available
+	if room.InternalID == "" || len(room.InternalID) > 50 || room.InternalID == "available" || strings.Contains(room.InternalID, "/") {
+		reasons = append(reasons, "internal_id must be 1 to 50 characters, without /, and not \"available\"")
+	}
+	reasons = append(reasons, applyRoomRequest(&room, req, true)...)
+	if len(reasons) > 0 {
+		writeJSON(w, http.StatusBadRequest, models.ValidationResponse{IsValid: false, Reasons: reasons})
+		return
+	}
+
+	query := `
+		INSERT INTO rooms (internal_id, name, floor, bathrooms, beds, capacity)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING ` + roomColumns
+
+	row := h.db.QueryRowContext(ctx, query, room.InternalID, room.Name, room.Floor, room.Bathrooms, room.Beds, room.Capacity)
+	if err := scanRoom(row, &room); err != nil {
+		writeDBError(ctx, w, err, "Failed to create room")
+		return
+	}
+
+	logger.Info(ctx, "Room created", "internal_id", room.InternalID, "id", room.ID)
+	w.Header().Set("Location", "/rooms/"+room.InternalID)
+	writeEntity(w, http.StatusCreated, room.UpdatedAt, room)
+}
+
+// ReplaceRoom handles PUT, which sets every field of the room.
+func (h *RoomHandler) ReplaceRoom(w http.ResponseWriter, r *http.Request) {
+	h.updateRoom(w, r, true)
+}
+
+// PatchRoom handles PATCH, which sets only the fields in the body.
+func (h *RoomHandler) PatchRoom(w http.ResponseWriter, r *http.Request) {
+	h.updateRoom(w, r, false)
+}
+
+func (h *RoomHandler) updateRoom(w http.ResponseWriter, r *http.Request, replace bool) {
+	ctx := r.Context()
+	internalID := mux.Vars(r)["internalId"]
+	logger.Info(ctx, "Updating room", "internal_id", internalID, "replace", replace)
+
+	var req models.RoomRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logger.Error(ctx, "Failed to decode room request", "error", err)
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	tx, err := h.db.BeginTx(ctx, nil)
+	if err != nil {
+		logger.Error(ctx, "Failed to begin transaction", "error", err)
+		http.Error(w, "Failed to update room", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	room, err := getRoom(ctx, tx, internalID, true)
+	if errors.Is(err, sql.ErrNoRows) {
+		http.Error(w, "Room not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		logger.Error(ctx, "Failed to fetch room", "internal_id", internalID, "error", err)
+		http.Error(w, "Failed to update room", http.StatusInternalServerError)
+		return
+	}
+	if !ifMatch(r, etag(room.UpdatedAt)) {
+		writePreconditionFailed(w, room.UpdatedAt)
+		return
+	}
+
+	if req.InternalID != nil && *req.InternalID != room.InternalID {
+		http.Error(w, "internal_id cannot be changed", http.StatusBadRequest)
+		return
+	}
+	if reasons := applyRoomRequest(room, req, replace); len(reasons) > 0 {
+		writeJSON(w, http.StatusBadRequest, models.ValidationResponse{IsValid: false, Reasons: reasons})
+		return
+	}
+
+	query := `
+		UPDATE rooms
+		SET name = $2, floor = $3, bathrooms = $4, beds = $5, capacity = $6, updated_at = NOW()
+		WHERE id = $1
+		RETURNING ` + roomColumns
+
+	row := tx.QueryRowContext(ctx, query, room.ID, room.Name, room.Floor, room.Bathrooms, room.Beds, room.Capacity)
+	if err := scanRoom(row, room); err != nil {
+		writeDBError(ctx, w, err, "Failed to update room")
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		writeDBError(ctx, w, err, "Failed to update room")
+		return
+	}
+
+	logger.Info(ctx, "Room updated", "internal_id", internalID)
+	writeEntity(w, http.StatusOK, room.UpdatedAt, room)
+}
+
+// DeleteRoom deletes a room that has never been booked. Rooms with
+// bookings are kept, so the booking history stays complete.
+func (h *RoomHandler) DeleteRoom(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	internalID := mux.Vars(r)["internalId"]
+	logger.Info(ctx, "Deleting room", "internal_id", internalID)
+
+	tx, err := h.db.BeginTx(ctx, nil)
+	if err != nil {
+		logger.Error(ctx, "Failed to begin transaction", "error", err)
+		http.Error(w, "Failed to delete room", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	room, err := getRoom(ctx, tx, internalID, true)
+	if errors.Is(err, sql.ErrNoRows) {
+		http.Error(w, "Room not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		logger.Error(ctx, "Failed to fetch room", "internal_id", internalID, "error", err)
+		http.Error(w, "Failed to delete room", http.StatusInternalServerError)
+		return
+	}
+	if !ifMatch(r, etag(room.UpdatedAt)) {
+		writePreconditionFailed(w, room.UpdatedAt)
+		return
+	}
+
+	var booked bool
+	if err := tx.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM bookings WHERE room_id = $1)`, room.ID).Scan(&booked); err != nil {
+		logger.Error(ctx, "Failed to check room bookings", "internal_id", internalID, "error", err)
+		http.Error(w, "Failed to delete room", http.StatusInternalServerError)
+		return
+	}
+	if booked {
+		http.Error(w, "Room has bookings and cannot be deleted", http.StatusConflict)
+		return
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM rooms WHERE id = $1`, room.ID); err != nil {
+		writeDBError(ctx, w, err, "Failed to delete room")
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		writeDBError(ctx, w, err, "Failed to delete room")
+		return
+	}
+
+	logger.Info(ctx, "Room deleted", "internal_id", internalID)
+	w.WriteHeader(http.StatusNoContent)
 }
\ No newline at end of file
@@ -0,0 +1,226 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"booking-management/internal/models"
+)
+
+func TestGetAvailableRoomsValidation(t *testing.T) {
+	// Invalid searches are rejected before the database is queried
+	h := NewRoomHandler(nil)
+
+	tests := []struct {
+		name  string
+		query string
+	}{
+		{"missing start", "end=2030-06-05"},
+		{"missing end", "start=2030-06-01"},
+		{"bad date", "start=06/01/2030&end=2030-06-05"},
+		{"end before start", "start=2030-06-05&end=2030-06-01"},
+		{"empty stay", "start=2030-06-01&end=2030-06-01"},
+		{"no guests", "start=2030-06-01&end=2030-06-05&guests=0"},
+		{"bad guests", "start=2030-06-01&end=2030-06-05&guests=two"},
+		{"bad filter", "start=2030-06-01&end=2030-06-05&beds=many"},
+		{"unknown sort", "start=2030-06-01&end=2030-06-05&sort=price"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			h.GetAvailableRooms(rec, httptest.NewRequest(http.MethodGet, "/rooms/available?"+tt.query, nil))
+			if rec.Code != http.StatusBadRequest {
+				t.Errorf("GetAvailableRooms(%q) = %d %s, want 400", tt.query, rec.Code, rec.Body)
+			}
+		})
+	}
+}
+
+func TestGetAvailableRooms(t *testing.T) {
+	db := openTestDB(t)
+	userID, roomID, _, roomInternalID := createTestRoom(t, db)
+	h := NewRoomHandler(db)
+
+	for _, booking := range []struct {
+		status     string
+		start, end string
+	}{
+		{"Accepted", "2030-06-10", "2030-06-15"},
+		{"Pending", "2030-07-10", "2030-07-15"},
+		{"Refused", "2030-08-10", "2030-08-15"},
+		{"Cancelled", "2030-09-10", "2030-09-15"},
+	} {
+		_, err := db.ExecContext(context.Background(), `
+			INSERT INTO bookings (external_id, user_id, room_id, number_of_guests, start_date, end_date, status)
+			VALUES ($1, $2, $3, 1, $4, $5, $6)
+		`, fmt.Sprintf("booking_test_%d_%s", roomID, booking.status), userID, roomID, booking.start, booking.end, booking.status)
+		if err != nil {
+			t.Fatalf("failed to create %s booking: %v", booking.status, err)
+		}
+	}
+
+	tests := []struct {
+		name  string
+		query string
+		want  bool
+	}{
+		{"free dates", "start=2030-06-01&end=2030-06-05&guests=2", true},
+		{"overlaps an accepted booking", "start=2030-06-12&end=2030-06-20", false},
+		{"covers an accepted booking", "start=2030-06-01&end=2030-06-30", false},
+		{"starts as a booking ends", "start=2030-06-15&end=2030-06-20", true},
+		{"ends as a booking starts", "start=2030-06-05&end=2030-06-10", true},
+		{"overlaps a pending booking", "start=2030-07-12&end=2030-07-13", false},
+		{"overlaps a refused booking", "start=2030-08-12&end=2030-08-13", true},
+		{"overlaps a cancelled booking", "start=2030-09-12&end=2030-09-13", true},
+		{"too many guests", "start=2030-06-01&end=2030-06-05&guests=3", false},
+		{"on the floor", "start=2030-06-01&end=2030-06-05&floor=1", true},
+		{"on another floor", "start=2030-06-01&end=2030-06-05&floor=2", false},
+		{"too few beds", "start=2030-06-01&end=2030-06-05&beds=2", false},
+		{"too few bathrooms", "start=2030-06-01&end=2030-06-05&bathrooms=2", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rooms := searchAvailableRooms(t, h, tt.query)
+			found := false
+			for _, room := range rooms {
+				found = found || room.InternalID == roomInternalID
+			}
+			if found != tt.want {
+				t.Errorf("GetAvailableRooms(%q) lists the room: %v, want %v", tt.query, found, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetAvailableRoomsSort(t *testing.T) {
+	db := openTestDB(t)
+	createTestRoom(t, db)
+	h := NewRoomHandler(db)
+
+	for _, sort := range []string{"", "-floor", "capacity", "-beds"} {
+		rooms := searchAvailableRooms(t, h, "start=2030-06-01&end=2030-06-05&sort="+sort)
+		column, desc := sort, false
+		if column == "" {
+			column = "floor"
+		} else if column[0] == '-' {
+			column, desc = column[1:], true
+		}
+		value := func(room models.Room) int {
+			return map[string]int{"floor": room.Floor, "capacity": room.Capacity, "beds": room.Beds}[column]
+		}
+		for i := 1; i < len(rooms); i++ {
+			prev, cur := value(rooms[i-1]), value(rooms[i])
+			if (!desc && prev > cur) || (desc && prev < cur) {
+				t.Errorf("sort %q lists %d before %d", sort, prev, cur)
+			}
+		}
+	}
+}
+
+func searchAvailableRooms(t *testing.T, h *RoomHandler, query string) []models.Room {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	h.GetAvailableRooms(rec, httptest.NewRequest(http.MethodGet, "/rooms/available?"+query, nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GetAvailableRooms(%q) = %d %s, want 200", query, rec.Code, rec.Body)
+	}
+	var rooms []models.Room
+	if err := json.Unmarshal(rec.Body.Bytes(), &rooms); err != nil {
+		t.Fatalf("failed to decode rooms: %v", err)
+	}
+	return rooms
+}
+
+func TestRoomWrites(t *testing.T) {
+	db := openTestDB(t)
+	userID, _, _, _ := createTestRoom(t, db)
+	h := NewRoomHandler(db)
+	ctx := context.Background()
+
+	internalID := fmt.Sprintf("room_test_crud_%d", userID)
+	vars := map[string]string{"internalId": internalID}
+	target := "/rooms/" + internalID
+	t.Cleanup(func() {
+		db.ExecContext(ctx, `DELETE FROM bookings WHERE room_id IN (SELECT id FROM rooms WHERE internal_id = $1)`, internalID)
+		db.ExecContext(ctx, `DELETE FROM rooms WHERE internal_id = $1`, internalID)
+	})
+
+	body := fmt.Sprintf(`{"internal_id":%q,"name":"Test Room","floor":1,"bathrooms":1,"beds":1,"capacity":2}`, internalID)
+	rec := serveWrite(h.CreateRoom, http.MethodPost, "/rooms", nil, body, "")
+	if rec.Code != http.StatusCreated || rec.Header().Get("Location") != target {
+		t.Fatalf("create = %d %s, Location %q, want 201 at %s", rec.Code, rec.Body, rec.Header().Get("Location"), target)
+	}
+	created := rec.Header().Get("ETag")
+
+	if rec := serveWrite(h.CreateRoom, http.MethodPost, "/rooms", nil, body, ""); rec.Code != http.StatusConflict {
+		t.Errorf("create with a taken internal_id = %d, want 409", rec.Code)
+	}
+	if rec := serveWrite(h.CreateRoom, http.MethodPost, "/rooms", nil, `{"internal_id":"available","name":"Test Room","floor":1,"bathrooms":1,"beds":1,"capacity":2}`, ""); rec.Code != http.StatusBadRequest {
+		t.Errorf("create of room \"available\" = %d, want 400", rec.Code)
+	}
+
+	rec = serveWrite(h.GetRoom, http.MethodGet, target, vars, "", "")
+	if rec.Code != http.StatusOK || rec.Header().Get("ETag") != created {
+		t.Fatalf("get = %d, ETag %q, want 200 with %q", rec.Code, rec.Header().Get("ETag"), created)
+	}
+
+	rec = serveWrite(h.PatchRoom, http.MethodPatch, target, vars, `{"capacity":3}`, created)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("patch = %d %s, want 200", rec.Code, rec.Body)
+	}
+	var room models.Room
+	if err := json.Unmarshal(rec.Body.Bytes(), &room); err != nil || room.Capacity != 3 || room.Name != "Test Room" {
+		t.Errorf("patched room = %+v, %v, want capacity 3 and the name kept", room, err)
+	}
+	updated := rec.Header().Get("ETag")
+	if updated == created {
+		t.Fatal("patch kept the ETag")
+	}
+
+	// A write based on the version before the patch is refused
+	rec = serveWrite(h.PatchRoom, http.MethodPatch, target, vars, `{"capacity":4}`, created)
+	if rec.Code != http.StatusPreconditionFailed || rec.Header().Get("ETag") != updated {
+		t.Errorf("patch of a stale version = %d, ETag %q, want 412 with %q", rec.Code, rec.Header().Get("ETag"), updated)
+	}
+	if rec := serveWrite(h.ReplaceRoom, http.MethodPut, target, vars, `{"name":"Test Room"}`, updated); rec.Code != http.StatusBadRequest {
+		t.Errorf("put without every field = %d, want 400", rec.Code)
+	}
+	if rec := serveWrite(h.PatchRoom, http.MethodPatch, target, vars, `{"internal_id":"room_other"}`, updated); rec.Code != http.StatusBadRequest {
+		t.Errorf("patch of internal_id = %d, want 400", rec.Code)
+	}
+
+	// Booked rooms are kept
+	var roomID int
+	if err := db.QueryRowContext(ctx, `SELECT id FROM rooms WHERE internal_id = $1`, internalID).Scan(&roomID); err != nil {
+		t.Fatalf("failed to look up room: %v", err)
+	}
+	if _, err := db.ExecContext(ctx, `
+		INSERT INTO bookings (external_id, user_id, room_id, number_of_guests, start_date, end_date, status)
+		VALUES ($1, $2, $3, 1, '2030-06-01', '2030-06-05', 'Cancelled')
+	`, internalID, userID, roomID); err != nil {
+		t.Fatalf("failed to create booking: %v", err)
+	}
+	if rec := serveWrite(h.DeleteRoom, http.MethodDelete, target, vars, "", updated); rec.Code != http.StatusConflict {
+		t.Errorf("delete of a booked room = %d, want 409", rec.Code)
+	}
+	if _, err := db.ExecContext(ctx, `DELETE FROM bookings WHERE room_id = $1`, roomID); err != nil {
+		t.Fatalf("failed to delete booking: %v", err)
+	}
+
+	if rec := serveWrite(h.DeleteRoom, http.MethodDelete, target, vars, "", created); rec.Code != http.StatusPreconditionFailed {
+		t.Errorf("delete of a stale version = %d, want 412", rec.Code)
+	}
+	if rec := serveWrite(h.DeleteRoom, http.MethodDelete, target, vars, "", updated); rec.Code != http.StatusNoContent {
+		t.Fatalf("delete = %d %s, want 204", rec.Code, rec.Body)
+	}
+	if rec := serveWrite(h.GetRoom, http.MethodGet, target, vars, "", ""); rec.Code != http.StatusNotFound {
+		t.Errorf("get of a deleted room = %d, want 404", rec.Code)
+	}
+	if rec := serveWrite(h.PatchRoom, http.MethodPatch, target, vars, `{"capacity":3}`, ""); rec.Code != http.StatusNotFound {
+		t.Errorf("patch of a deleted room = %d, want 404", rec.Code)
+	}
+}
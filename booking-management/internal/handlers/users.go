@@ -1,14 +1,46 @@
 package handlers
 
 import (
+	"context"
+	"database/sql"
 	"encoding/json"
+	"errors"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	"booking-management/internal/database"
 	"booking-management/internal/logger"
 	"booking-management/internal/models"
+
+	"github.com/gorilla/mux"
 )
 
+// userColumns lists the columns scanUser reads, in order.
+const userColumns = `id, email, username, date_of_birth, name, surname, created_at, updated_at`
+
+var userSorts = sortColumns[models.User]{
+	"id":         func(user models.User) string { return strconv.Itoa(user.ID) },
+	"email":      func(user models.User) string { return user.Email },
+	"username":   func(user models.User) string { return user.Username },
+	"surname":    func(user models.User) string { return user.Surname },
+	"created_at": func(user models.User) string { return formatTime(user.CreatedAt) },
+}
+
+func scanUser(row rowScanner, user *models.User) error {
+	return row.Scan(
+		&user.ID,
+		&user.Email,
+		&user.Username,
+		&user.DateOfBirth,
+		&user.Name,
+		&user.Surname,
+		&user.CreatedAt,
+		&user.UpdatedAt,
+	)
+}
+
 type UserHandler struct {
 	db *database.DB
 }
@@ -17,17 +49,25 @@ func NewUserHandler(db *database.DB) *UserHandler {
 	return &UserHandler{db: db}
 }
 
+// GetUsers lists users a page at a time, see parseListPage. email and
+// username filter on a case-insensitive prefix.
 func (h *UserHandler) GetUsers(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
-	logger.Info(ctx, "Fetching users")
+	params := r.URL.Query()
 
-	query := `
-		SELECT id, email, username, date_of_birth, name, surname, created_at, updated_at
-		FROM users
-		ORDER BY id ASC
-	`
+	page, err := parseListPage(r, userSorts)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var q listQuery
+	q.prefixFilter(params, "email", "email")
+	q.prefixFilter(params, "username", "username")
+
+	logger.Info(ctx, "Fetching users", "limit", page.limit, "sort", page.sort)
 
-	rows, err := h.db.Query(query)
+	rows, err := h.db.QueryContext(ctx, q.build(userColumns, "users", page), q.args...)
 	if err != nil {
 		logger.Error(ctx, "Failed to fetch users from database", "error", err)
 		http.Error(w, "Failed to fetch users", http.StatusInternalServerError)
@@ -38,17 +78,7 @@ func (h *UserHandler) GetUsers(w http.ResponseWriter, r *http.Request) {
 	var users []models.User
 	for rows.Next() {
 		var user models.User
-		err := rows.Scan(
-			&user.ID,
-			&user.Email,
-			&user.Username,
-			&user.DateOfBirth,
-			&user.Name,
-			&user.Surname,
-			&user.CreatedAt,
-			&user.UpdatedAt,
-		)
-		if err != nil {
+		if err := scanUser(rows, &user); err != nil {
 			logger.Error(ctx, "Failed to scan user", "error", err)
 			http.Error(w, "Failed to scan user", http.StatusInternalServerError)
 			return
@@ -63,13 +93,260 @@ func (h *UserHandler) GetUsers(w http.ResponseWriter, r *http.Request) {
 	}
 
 	logger.Info(ctx, "Successfully fetched users", "count", len(users))
+	writePage(w, r, users, page, userSorts)
+}
+
+// getUser returns sql.ErrNoRows when no user has the ID. lock keeps the row
+// locked until the transaction of q ends.
+func getUser(ctx context.Context, q rowQuerier, id int, lock bool) (*models.User, error) {
+	query := `SELECT ` + userColumns + ` FROM users WHERE id = $1`
+	if lock {
+		query += ` FOR UPDATE`
+	}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
+	var user models.User
+	if err := scanUser(q.QueryRowContext(ctx, query, id), &user); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// applyUserRequest copies the fields set in req onto user and returns why
+// the result is invalid, if it is. replace requires every field.
+func applyUserRequest(user *models.User, req models.UserRequest, replace bool) []string {
+	if replace {
+		var missing []string
+		if req.Email == nil {
+			missing = append(missing, "email is required")
+		}
+		if req.Username == nil {
+			missing = append(missing, "username is required")
+		}
+		if req.DateOfBirth == nil {
+			missing = append(missing, "date_of_birth is required")
+		}
+		if req.Name == nil {
+			missing = append(missing, "name is required")
+		}
+		if req.Surname == nil {
+			missing = append(missing, "surname is required")
+		}
+		if len(missing) > 0 {
+			return missing
+		}
+	}
+
+	var reasons []string
+	if req.Email != nil {
+		user.Email = strings.TrimSpace(*req.Email)
+	}
+	if req.Username != nil {
+		user.Username = strings.TrimSpace(*req.Username)
+	}
+	if req.DateOfBirth != nil {
+		dateOfBirth, err := time.Parse("2006-01-02", *req.DateOfBirth)
+		if err != nil {
+			reasons = append(reasons, "date_of_birth must be a date (YYYY-MM-DD)")
+		} else {
+			user.DateOfBirth = dateOfBirth
+		}
+	}
+	if req.Name != nil {
+		user.Name = strings.TrimSpace(*req.Name)
+	}
+	if req.Surname != nil {
+		user.Surname = strings.TrimSpace(*req.Surname)
+	}
+
+	if len(user.Email) > 255 || !strings.Contains(user.Email, "@") {
+		reasons = append(reasons, "email must be an email address of up to 255 characters")
+	}
+	if user.Username == "" || len(user.Username) > 100 || strings.ContainsAny(user.Username, " \t\n") {
+		reasons = append(reasons, "username must be 1 to 100 characters without spaces")
+	}
+	if !user.DateOfBirth.IsZero() && !user.DateOfBirth.Before(time.Now()) {
+		reasons = append(reasons, "date_of_birth must be in the past")
+	}
+	if user.Name == "" || len(user.Name) > 100 {
+		reasons = append(reasons, "name must be 1 to 100 characters")
+	}
+	if user.Surname == "" || len(user.Surname) > 100 {
+		reasons = append(reasons, "surname must be 1 to 100 characters")
+	}
+	return reasons
+}
+
+func (h *UserHandler) GetUser(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id, _ := strconv.Atoi(mux.Vars(r)["id"])
+
+	user, err := getUser(ctx, h.db, id, false)
+	if errors.Is(err, sql.ErrNoRows) {
+		http.Error(w, "User not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		logger.Error(ctx, "Failed to fetch user", "id", id, "error", err)
+		http.Error(w, "Failed to fetch user", http.StatusInternalServerError)
+		return
+	}
+
+	writeEntity(w, http.StatusOK, user.UpdatedAt, user)
+}
+
+func (h *UserHandler) CreateUser(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	logger.Info(ctx, "Creating user")
+
+	var req models.UserRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logger.Error(ctx, "Failed to decode user request", "error", err)
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	var user models.User
+	if reasons := applyUserRequest(&user, req, true); len(reasons) > 0 {
+		writeJSON(w, http.StatusBadRequest, models.ValidationResponse{IsValid: false, Reasons: reasons})
+		return
+	}
+
+	query := `
+		INSERT INTO users (email, username, date_of_birth, name, surname)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING ` + userColumns
 
-	if err := json.NewEncoder(w).Encode(users); err != nil {
-		logger.Error(ctx, "Failed to encode response", "error", err)
-		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+	row := h.db.QueryRowContext(ctx, query, user.Email, user.Username, user.DateOfBirth, user.Name, user.Surname)
+	if err := scanUser(row, &user); err != nil {
+		writeDBError(ctx, w, err, "Failed to create user")
 		return
 	}
+
+	logger.Info(ctx, "User created", "id", user.ID)
+	w.Header().Set("Location", "/users/"+strconv.Itoa(user.ID))
+	writeEntity(w, http.StatusCreated, user.UpdatedAt, user)
+}
+
+// ReplaceUser handles PUT, which sets every field of the user.
+func (h *UserHandler) ReplaceUser(w http.ResponseWriter, r *http.Request) {
+	h.updateUser(w, r, true)
+}
+
+// PatchUser handles PATCH, which sets only the fields in the body.
+func (h *UserHandler) PatchUser(w http.ResponseWriter, r *http.Request) {
+	h.updateUser(w, r, false)
+}
+
+func (h *UserHandler) updateUser(w http.ResponseWriter, r *http.Request, replace bool) {
+	ctx := r.Context()
+	id, _ := strconv.Atoi(mux.Vars(r)["id"])
+	logger.Info(ctx, "Updating user", "id", id, "replace", replace)
+
+	var req models.UserRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logger.Error(ctx, "Failed to decode user request", "error", err)
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	tx, err := h.db.BeginTx(ctx, nil)
+	if err != nil {
+		logger.Error(ctx, "Failed to begin transaction", "error", err)
+		http.Error(w, "Failed to update user", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	user, err := getUser(ctx, tx, id, true)
+	if errors.Is(err, sql.ErrNoRows) {
+		http.Error(w, "User not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		logger.Error(ctx, "Failed to fetch user", "id", id, "error", err)
+		http.Error(w, "Failed to update user", http.StatusInternalServerError)
+		return
+	}
+	if !ifMatch(r, etag(user.UpdatedAt)) {
+		writePreconditionFailed(w, user.UpdatedAt)
+		return
+	}
+
+	if reasons := applyUserRequest(user, req, replace); len(reasons) > 0 {
+		writeJSON(w, http.StatusBadRequest, models.ValidationResponse{IsValid: false, Reasons: reasons})
+		return
+	}
+
+	query := `
+		UPDATE users
+		SET email = $2, username = $3, date_of_birth = $4, name = $5, surname = $6, updated_at = NOW()
+		WHERE id = $1
+		RETURNING ` + userColumns
+
+	row := tx.QueryRowContext(ctx, query, user.ID, user.Email, user.Username, user.DateOfBirth, user.Name, user.Surname)
+	if err := scanUser(row, user); err != nil {
+		writeDBError(ctx, w, err, "Failed to update user")
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		writeDBError(ctx, w, err, "Failed to update user")
+		return
+	}
+
+	logger.Info(ctx, "User updated", "id", id)
+	writeEntity(w, http.StatusOK, user.UpdatedAt, user)
+}
+
+// DeleteUser deletes a user without bookings. Users with bookings are kept,
+// so the booking history stays complete.
+func (h *UserHandler) DeleteUser(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id, _ := strconv.Atoi(mux.Vars(r)["id"])
+	logger.Info(ctx, "Deleting user", "id", id)
+
+	tx, err := h.db.BeginTx(ctx, nil)
+	if err != nil {
+		logger.Error(ctx, "Failed to begin transaction", "error", err)
+		http.Error(w, "Failed to delete user", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	user, err := getUser(ctx, tx, id, true)
+	if errors.Is(err, sql.ErrNoRows) {
+		http.Error(w, "User not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		logger.Error(ctx, "Failed to fetch user", "id", id, "error", err)
+		http.Error(w, "Failed to delete user", http.StatusInternalServerError)
+		return
+	}
+	if !ifMatch(r, etag(user.UpdatedAt)) {
+		writePreconditionFailed(w, user.UpdatedAt)
+		return
+	}
+
+	var booked bool
+	if err := tx.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM bookings WHERE user_id = $1)`, user.ID).Scan(&booked); err != nil {
+		logger.Error(ctx, "Failed to check user bookings", "id", id, "error", err)
+		http.Error(w, "Failed to delete user", http.StatusInternalServerError)
+		return
+	}
+	if booked {
+		http.Error(w, "User has bookings and cannot be deleted", http.StatusConflict)
+		return
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM users WHERE id = $1`, user.ID); err != nil {
+		writeDBError(ctx, w, err, "Failed to delete user")
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		writeDBError(ctx, w, err, "Failed to delete user")
+		return
+	}
+
+	logger.Info(ctx, "User deleted", "id", id)
+	w.WriteHeader(http.StatusNoContent)
 }
\ No newline at end of file
@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -33,7 +34,7 @@ func (h *ValidationHandler) ValidateBooking(w http.ResponseWriter, r *http.Reque
 	var reasons []string
 
 	// Validate room exists and get room details
-	room, err := h.getRoomByInternalID(req.RoomID)
+	room, err := h.getRoomByInternalID(ctx, req.RoomID)
 	if err != nil {
 		logger.Error(ctx, "Failed to fetch room", "error", err, "room_id", req.RoomID)
 		reasons = append(reasons, "Room does not exist")
@@ -53,7 +54,7 @@ func (h *ValidationHandler) ValidateBooking(w http.ResponseWriter, r *http.Reque
 
 	// Validate room availability
 	if room != nil {
-		isAvailable, err := h.isRoomAvailableByInternalID(req.RoomID, req.StartDate, req.EndDate)
+		isAvailable, err := h.isRoomAvailableByInternalID(ctx, req.RoomID, req.StartDate, req.EndDate)
 		if err != nil {
 			logger.Error(ctx, "Failed to check room availability", "error", err)
 			reasons = append(reasons, "Unable to verify room availability")
@@ -86,11 +87,11 @@ func (h *ValidationHandler) ValidateBooking(w http.ResponseWriter, r *http.Reque
 	}
 }
 
-func (h *ValidationHandler) getRoomByInternalID(internalID string) (*models.Room, error) {
+func (h *ValidationHandler) getRoomByInternalID(ctx context.Context, internalID string) (*models.Room, error) {
 	query := `SELECT id, internal_id, name, floor, bathrooms, beds, capacity, created_at, updated_at FROM rooms WHERE internal_id = $1`
 
 	var room models.Room
-	err := h.db.QueryRow(query, internalID).Scan(
+	err := h.db.QueryRowContext(ctx, query, internalID).Scan(
 		&room.ID,
 		&room.InternalID,
 		&room.Name,
@@ -109,13 +110,13 @@ func (h *ValidationHandler) getRoomByInternalID(internalID string) (*models.Room
 	return &room, nil
 }
 
-func (h *ValidationHandler) isRoomAvailableByInternalID(internalID string, startDate, endDate time.Time) (bool, error) {
+func (h *ValidationHandler) isRoomAvailableByInternalID(ctx context.Context, internalID string, startDate, endDate time.Time) (bool, error) {
 	query := `
 		SELECT COUNT(*)
 		FROM bookings b
 		JOIN rooms r ON b.room_id = r.id
 		WHERE r.internal_id = $1
-		AND b.status = 'Accepted'
+		AND b.status IN ('Pending', 'Accepted')
 		AND (
 			(b.start_date <= $2 AND b.end_date > $2) OR
 			(b.start_date < $3 AND b.end_date >= $3) OR
@@ -124,7 +125,7 @@ func (h *ValidationHandler) isRoomAvailableByInternalID(internalID string, start
 	`
 
 	var count int
-	err := h.db.QueryRow(query, internalID, startDate, endDate).Scan(&count)
+	err := h.db.QueryRowContext(ctx, query, internalID, startDate, endDate).Scan(&count)
 	if err != nil {
 		return false, err
 	}
@@ -0,0 +1,384 @@
+// Package migrate applies the versioned migrations in db.Migrations and
+// records them in the schema_migrations table. The migrations cover every
+// table in the shared database, including those private to the booking
+// service and the worker, which only check the schema version on startup.
+package migrate
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strconv"
+
+	"booking-management/db"
+	"booking-management/internal/logger"
+
+	"github.com/lib/pq"
+)
+
+// lockKey identifies the advisory lock held while migrating, so only one
+// process changes the schema at a time.
+const lockKey = 7_120_411_024
+
+var fileName = regexp.MustCompile(`^(\d+)_(\w+?)(\.optional)?\.(up|down)\.sql$`)
+
+// Migration is one schema change with the SQL to apply and revert it.
+// Optional migrations are only applied when asked for. Checksum identifies
+// the up script, so a migration edited after it was applied is detected.
+type Migration struct {
+	Version  int
+	Name     string
+	Optional bool
+	Checksum string
+	up       string
+	down     string
+}
+
+// Status tells whether a migration has been applied.
+type Status struct {
+	Migration
+	Applied bool
+}
+
+// Load reads the embedded migrations, ordered by version. Every version
+// needs both an up and a down file.
+func Load() ([]Migration, error) {
+	return load(db.Migrations)
+}
+
+func load(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, "migrations")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migrations: %w", err)
+	}
+
+	byVersion := map[int]*Migration{}
+	for _, entry := range entries {
+		match := fileName.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, fmt.Errorf("migration %s is not named <version>_<name>[.optional].(up|down).sql", entry.Name())
+		}
+		content, err := fs.ReadFile(fsys, "migrations/"+entry.Name())
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration %s: %w", entry.Name(), err)
+		}
+
+		version, _ := strconv.Atoi(match[1])
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: match[2], Optional: match[3] != ""}
+			byVersion[version] = m
+		} else if m.Name != match[2] || m.Optional != (match[3] != "") {
+			return nil, fmt.Errorf("migration %d has files with different names", version)
+		}
+		if match[4] == "up" {
+			m.up = string(content)
+		} else {
+			m.down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.up == "" || m.down == "" {
+			return nil, fmt.Errorf("migration %d_%s needs both an up and a down file", m.Version, m.Name)
+		}
+		sum := sha256.Sum256([]byte(m.up))
+		m.Checksum = hex.EncodeToString(sum[:])
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// Latest returns the version of the newest required migration, the oldest
+// schema version this build of the service works with.
+func Latest(migrations []Migration) int {
+	latest := 0
+	for _, m := range migrations {
+		if !m.Optional && m.Version > latest {
+			latest = m.Version
+		}
+	}
+	return latest
+}
+
+// Migrator applies migrations to a database.
+type Migrator struct {
+	db         *sql.DB
+	migrations []Migration
+}
+
+func New(db *sql.DB, migrations []Migration) *Migrator {
+	return &Migrator{db: db, migrations: migrations}
+}
+
+// Up applies every pending required migration, and the optional ones too
+// if optional is set.
+func (m *Migrator) Up(ctx context.Context, optional bool) error {
+	if len(m.migrations) == 0 {
+		return nil
+	}
+	return m.To(ctx, m.migrations[len(m.migrations)-1].Version, optional)
+}
+
+// Down reverts the applied migration with the highest version.
+func (m *Migrator) Down(ctx context.Context) error {
+	return m.withLock(ctx, func(conn *sql.Conn) error {
+		applied, err := m.applied(ctx, conn)
+		if err != nil {
+			return err
+		}
+		for i := len(m.migrations) - 1; i >= 0; i-- {
+			if applied[m.migrations[i].Version] {
+				return m.run(ctx, conn, m.migrations[i], false)
+			}
+		}
+		logger.Info(ctx, "No migration to revert")
+		return nil
+	})
+}
+
+// To reverts the applied migrations above version, then applies the
+// pending required migrations up to it, and the optional ones too if
+// optional is set.
+func (m *Migrator) To(ctx context.Context, version int, optional bool) error {
+	return m.withLock(ctx, func(conn *sql.Conn) error {
+		applied, err := m.applied(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		for i := len(m.migrations) - 1; i >= 0; i-- {
+			migration := m.migrations[i]
+			if migration.Version > version && applied[migration.Version] {
+				if err := m.run(ctx, conn, migration, false); err != nil {
+					return err
+				}
+			}
+		}
+		for _, migration := range m.migrations {
+			if migration.Version <= version && !applied[migration.Version] && (optional || !migration.Optional) {
+				if err := m.run(ctx, conn, migration, true); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+}
+
+// Status lists every migration and whether it has been applied. It only
+// reads the database: it takes no lock and creates no table, so it can run
+// against a database that was never migrated.
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	checksums, err := readApplied(ctx, m.db)
+	if err != nil {
+		return nil, err
+	}
+	if err := verify(m.migrations, checksums); err != nil {
+		return nil, err
+	}
+
+	statuses := make([]Status, 0, len(m.migrations))
+	for _, migration := range m.migrations {
+		_, applied := checksums[migration.Version]
+		statuses = append(statuses, Status{Migration: migration, Applied: applied})
+	}
+	return statuses, nil
+}
+
+// withLock runs fn on a connection holding the migration advisory lock,
+// after making sure schema_migrations exists. Concurrent callers wait for
+// the lock.
+func (m *Migrator) withLock(ctx context.Context, fn func(conn *sql.Conn) error) error {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get connection: %w", err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, `SELECT pg_advisory_lock($1)`, lockKey); err != nil {
+		return fmt.Errorf("failed to acquire migration lock: %w", err)
+	}
+	defer conn.ExecContext(context.WithoutCancel(ctx), `SELECT pg_advisory_unlock($1)`, lockKey)
+
+	query := `
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version INTEGER PRIMARY KEY,
+			name VARCHAR(255) NOT NULL,
+			optional BOOLEAN NOT NULL DEFAULT FALSE,
+			checksum VARCHAR(64),
+			applied_at TIMESTAMP NOT NULL DEFAULT NOW()
+		);
+		ALTER TABLE schema_migrations ADD COLUMN IF NOT EXISTS checksum VARCHAR(64);
+	`
+	if _, err := conn.ExecContext(ctx, query); err != nil {
+		return fmt.Errorf("failed to create schema_migrations table: %w", err)
+	}
+
+	return fn(conn)
+}
+
+// queryer is a *sql.DB or a *sql.Conn.
+type queryer interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}
+
+// readApplied returns the checksums of the applied migrations by version,
+// empty for those recorded before checksums were kept. A database without
+// schema_migrations has none applied.
+func readApplied(ctx context.Context, q queryer) (map[int]string, error) {
+	// to_jsonb reads the checksum even from tables created before the
+	// column was added, which only a migrating command adds
+	rows, err := q.QueryContext(ctx, `SELECT version, COALESCE(to_jsonb(m) ->> 'checksum', '') FROM schema_migrations m`)
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "42P01" { // undefined_table
+		return map[int]string{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read applied migrations: %w", err)
+	}
+	defer rows.Close()
+
+	checksums := map[int]string{}
+	for rows.Next() {
+		var version int
+		var checksum string
+		if err := rows.Scan(&version, &checksum); err != nil {
+			return nil, fmt.Errorf("failed to scan applied migration: %w", err)
+		}
+		checksums[version] = checksum
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read applied migrations: %w", err)
+	}
+	return checksums, nil
+}
+
+// applied returns the applied versions, after checking them with verify.
+// Migrations recorded before checksums were kept get their checksum now.
+func (m *Migrator) applied(ctx context.Context, conn *sql.Conn) (map[int]bool, error) {
+	checksums, err := readApplied(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+	if err := verify(m.migrations, checksums); err != nil {
+		return nil, err
+	}
+
+	applied := make(map[int]bool, len(checksums))
+	for _, migration := range m.migrations {
+		checksum, ok := checksums[migration.Version]
+		if !ok {
+			continue
+		}
+		applied[migration.Version] = true
+		if checksum == "" {
+			_, err := conn.ExecContext(ctx, `UPDATE schema_migrations SET checksum = $2 WHERE version = $1`, migration.Version, migration.Checksum)
+			if err != nil {
+				return nil, fmt.Errorf("failed to record checksum of migration %d_%s: %w", migration.Version, migration.Name, err)
+			}
+		}
+	}
+	return applied, nil
+}
+
+// verify checks the applied migrations, by version with their recorded
+// checksums, against migrations. It fails if the database has a migration
+// this build does not know, since it cannot be reverted, or if an applied
+// migration was edited since, since the database does not match it. An
+// empty checksum was recorded before checksums were kept and always matches.
+func verify(migrations []Migration, applied map[int]string) error {
+	known := make(map[int]Migration, len(migrations))
+	for _, migration := range migrations {
+		known[migration.Version] = migration
+	}
+
+	versions := make([]int, 0, len(applied))
+	for version := range applied {
+		versions = append(versions, version)
+	}
+	sort.Ints(versions)
+
+	for _, version := range versions {
+		migration, ok := known[version]
+		if !ok {
+			return fmt.Errorf("database has migration %d, which this build does not know; use a newer build", version)
+		}
+		if checksum := applied[version]; checksum != "" && checksum != migration.Checksum {
+			return fmt.Errorf("migration %d_%s was changed after it was applied; add a new migration instead of editing it", version, migration.Name)
+		}
+	}
+	return nil
+}
+
+// run applies or reverts migration in one transaction together with its
+// schema_migrations row, so a failed migration leaves nothing behind.
+func (m *Migrator) run(ctx context.Context, conn *sql.Conn, migration Migration, up bool) error {
+	direction, script := "up", migration.up
+	if !up {
+		direction, script = "down", migration.down
+	}
+	logger.Info(ctx, "Running migration", "version", migration.Version, "name", migration.Name, "direction", direction)
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, script); err != nil {
+		return fmt.Errorf("migration %d_%s %s failed: %w", migration.Version, migration.Name, direction, err)
+	}
+
+	if up {
+		_, err = tx.ExecContext(ctx, `INSERT INTO schema_migrations (version, name, optional, checksum) VALUES ($1, $2, $3, $4)`,
+			migration.Version, migration.Name, migration.Optional, migration.Checksum)
+	} else {
+		_, err = tx.ExecContext(ctx, `DELETE FROM schema_migrations WHERE version = $1`, migration.Version)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to record migration %d_%s: %w", migration.Version, migration.Name, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit migration %d_%s: %w", migration.Version, migration.Name, err)
+	}
+	return nil
+}
+
+// Version returns the schema version of the database: the newest applied
+// required migration, or 0 if none is.
+func Version(ctx context.Context, db *sql.DB) (int, error) {
+	var version int
+	err := db.QueryRowContext(ctx, `SELECT COALESCE(MAX(version), 0) FROM schema_migrations WHERE NOT optional`).Scan(&version)
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "42P01" { // undefined_table
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to read schema version: %w", err)
+	}
+	return version, nil
+}
+
+// Check fails unless the database is at schema version want or newer.
+// Migrations only add to the schema, so a build keeps working while a newer
+// one has already migrated the database, e.g. during a rolling deploy.
+func Check(ctx context.Context, db *sql.DB, want int) error {
+	version, err := Version(ctx, db)
+	if err != nil {
+		return err
+	}
+	if version < want {
+		return fmt.Errorf("database schema is at version %d but this build requires version %d or newer", version, want)
+	}
+	return nil
+}
\ No newline at end of file
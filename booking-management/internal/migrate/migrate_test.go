@@ -0,0 +1,262 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestLoadEmbedded(t *testing.T) {
+	migrations, err := Load()
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+
+	for i, m := range migrations {
+		if i > 0 && m.Version <= migrations[i-1].Version {
+			t.Errorf("migration %d is not ordered after %d", m.Version, migrations[i-1].Version)
+		}
+		if len(m.Checksum) != 64 {
+			t.Errorf("migration %d has checksum %q", m.Version, m.Checksum)
+		}
+		if strings.TrimSpace(m.up) == "" || strings.TrimSpace(m.down) == "" {
+			t.Errorf("migration %d has an empty script", m.Version)
+		}
+	}
+	// The sample data is optional, so it never decides the schema version the
+	// services check for
+	for _, m := range migrations {
+		if m.Optional != (m.Version == 4) {
+			t.Errorf("migration %d_%s has optional %v", m.Version, m.Name, m.Optional)
+		}
+	}
+	if latest := Latest(migrations); latest != migrations[len(migrations)-1].Version {
+		t.Errorf("Latest = %d, want %d", latest, migrations[len(migrations)-1].Version)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	tests := []struct {
+		name    string
+		files   map[string]string
+		wantErr string
+	}{
+		{
+			name: "up and down",
+			files: map[string]string{
+				"0001_init.up.sql":            "CREATE TABLE a (id INT);",
+				"0001_init.down.sql":          "DROP TABLE a;",
+				"0002_seed.optional.up.sql":   "INSERT INTO a VALUES (1);",
+				"0002_seed.optional.down.sql": "DELETE FROM a;",
+			},
+		},
+		{
+			name:    "badly named file",
+			files:   map[string]string{"init.sql": "CREATE TABLE a (id INT);"},
+			wantErr: "is not named",
+		},
+		{
+			name:    "missing down file",
+			files:   map[string]string{"0001_init.up.sql": "CREATE TABLE a (id INT);"},
+			wantErr: "needs both an up and a down file",
+		},
+		{
+			name: "files with different names",
+			files: map[string]string{
+				"0001_init.up.sql":    "CREATE TABLE a (id INT);",
+				"0001_other.down.sql": "DROP TABLE a;",
+			},
+			wantErr: "different names",
+		},
+		{
+			name: "optional on one side only",
+			files: map[string]string{
+				"0001_init.optional.up.sql": "CREATE TABLE a (id INT);",
+				"0001_init.down.sql":        "DROP TABLE a;",
+			},
+			wantErr: "different names",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			migrations, err := load(migrationFS(tt.files))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("load error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("load failed: %v", err)
+			}
+			if len(migrations) != 2 || !migrations[1].Optional || Latest(migrations) != 1 {
+				t.Errorf("load = %+v, want a required migration 1 and an optional migration 2", migrations)
+			}
+		})
+	}
+}
+
+func TestChecksumCoversTheUpScript(t *testing.T) {
+	files := map[string]string{
+		"0001_init.up.sql":   "CREATE TABLE a (id INT);",
+		"0001_init.down.sql": "DROP TABLE a;",
+	}
+	original := mustLoad(t, files)[0].Checksum
+
+	files["0001_init.down.sql"] = "DROP TABLE IF EXISTS a;"
+	if got := mustLoad(t, files)[0].Checksum; got != original {
+		t.Error("editing the down script changed the checksum")
+	}
+
+	files["0001_init.up.sql"] = "CREATE TABLE a (id BIGINT);"
+	if got := mustLoad(t, files)[0].Checksum; got == original {
+		t.Error("editing the up script kept the checksum")
+	}
+}
+
+func TestVerify(t *testing.T) {
+	migrations := mustLoad(t, map[string]string{
+		"0001_init.up.sql":    "CREATE TABLE a (id INT);",
+		"0001_init.down.sql":  "DROP TABLE a;",
+		"0002_index.up.sql":   "CREATE INDEX a_id ON a (id);",
+		"0002_index.down.sql": "DROP INDEX a_id;",
+	})
+	first, second := migrations[0].Checksum, migrations[1].Checksum
+
+	tests := []struct {
+		name    string
+		applied map[int]string
+		wantErr string
+	}{
+		{name: "nothing applied", applied: map[int]string{}},
+		{name: "all applied unchanged", applied: map[int]string{1: first, 2: second}},
+		{name: "some applied", applied: map[int]string{1: first}},
+		{name: "applied before checksums were kept", applied: map[int]string{1: "", 2: second}},
+		{
+			name:    "applied migration was edited",
+			applied: map[int]string{1: first, 2: first},
+			wantErr: "migration 2_index was changed after it was applied",
+		},
+		{
+			name:    "unknown migration",
+			applied: map[int]string{1: first, 3: second},
+			wantErr: "database has migration 3, which this build does not know",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := verify(migrations, tt.applied)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("verify failed: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("verify error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func migrationFS(files map[string]string) fstest.MapFS {
+	fsys := fstest.MapFS{}
+	for name, content := range files {
+		fsys["migrations/"+name] = &fstest.MapFile{Data: []byte(content)}
+	}
+	return fsys
+}
+
+func mustLoad(t *testing.T, files map[string]string) []Migration {
+	t.Helper()
+	migrations, err := load(migrationFS(files))
+	if err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+	return migrations
+}
+
+// openTestDB connects to TEST_DATABASE_URL, a PostgreSQL database migrated
+// with the migrate command, and skips the test without one. The connection
+// pool holds a single connection, so session settings stick.
+func openTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+
+	url := os.Getenv("TEST_DATABASE_URL")
+	if url == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+
+	conn, err := sql.Open("postgres", url)
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	conn.SetMaxOpenConns(1)
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func TestStatusIsReadOnly(t *testing.T) {
+	conn := openTestDB(t)
+	ctx := context.Background()
+
+	// An empty schema first on the search path stands in for a database
+	// that was never migrated
+	schema := fmt.Sprintf("migrate_test_%d", time.Now().UnixNano())
+	if _, err := conn.ExecContext(ctx, `CREATE SCHEMA `+schema); err != nil {
+		t.Fatalf("failed to create schema: %v", err)
+	}
+	t.Cleanup(func() { conn.ExecContext(context.Background(), `DROP SCHEMA `+schema+` CASCADE`) })
+	if _, err := conn.ExecContext(ctx, `SET search_path TO `+schema); err != nil {
+		t.Fatalf("failed to set search path: %v", err)
+	}
+
+	migrations, err := Load()
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	statuses, err := New(conn, migrations).Status(ctx)
+	if err != nil {
+		t.Fatalf("Status failed: %v", err)
+	}
+	if len(statuses) != len(migrations) {
+		t.Fatalf("Status listed %d migrations, want %d", len(statuses), len(migrations))
+	}
+	for _, status := range statuses {
+		if status.Applied {
+			t.Errorf("migration %d is applied in an empty schema", status.Version)
+		}
+	}
+
+	var table sql.NullString
+	if err := conn.QueryRowContext(ctx, `SELECT to_regclass($1)::text`, schema+".schema_migrations").Scan(&table); err != nil {
+		t.Fatalf("failed to look up schema_migrations: %v", err)
+	}
+	if table.Valid {
+		t.Error("Status created schema_migrations")
+	}
+}
+
+func TestCheckAcceptsNewerSchemas(t *testing.T) {
+	conn := openTestDB(t)
+	ctx := context.Background()
+
+	version, err := Version(ctx, conn)
+	if err != nil {
+		t.Fatalf("Version failed: %v", err)
+	}
+	if err := Check(ctx, conn, version); err != nil {
+		t.Errorf("Check at the current version failed: %v", err)
+	}
+	if err := Check(ctx, conn, version-1); err != nil {
+		t.Errorf("Check of an older build failed: %v", err)
+	}
+	if err := Check(ctx, conn, version+1); err == nil {
+		t.Error("Check of a newer build accepted an older schema")
+	}
+}
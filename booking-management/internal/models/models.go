@@ -27,17 +27,25 @@ type Room struct {
 
 type Booking struct {
 	ID             int       `json:"id" db:"id"`
-	UserID         int       `json:"user_id" db:"user_id"`
-	RoomID         int       `json:"room_id" db:"room_id"`
+	ExternalID     string    `json:"external_id" db:"external_id"`
+	UserID         *int      `json:"user_id" db:"user_id"`
+	RoomID         *int      `json:"room_id" db:"room_id"`
 	NumberOfGuests int       `json:"number_of_guests" db:"number_of_guests"`
 	StartDate      time.Time `json:"start_date" db:"start_date"`
 	EndDate        time.Time `json:"end_date" db:"end_date"`
 	PaymentID      *string   `json:"payment_id" db:"payment_id"`
 	Status         string    `json:"status" db:"status"`
+	RefusalReason  *string   `json:"refusal_reason" db:"refusal_reason"`
 	CreatedAt      time.Time `json:"created_at" db:"created_at"`
 	UpdatedAt      time.Time `json:"updated_at" db:"updated_at"`
 }
 
+// Page is one page of a list endpoint. NextCursor is empty on the last page.
+type Page[T any] struct {
+	Data       []T    `json:"data"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
 type ValidationRequest struct {
 	RoomID         string    `json:"room_id"`
 	NumberOfGuests int       `json:"number_of_guests"`
@@ -48,4 +56,58 @@ type ValidationRequest struct {
 type ValidationResponse struct {
 	IsValid bool     `json:"isValid"`
 	Reasons []string `json:"reasons"`
+}
+
+// ReservationRequest holds a room for a booking before it is paid for. The
+// user is an email, username or numeric ID and the room its internal ID.
+type ReservationRequest struct {
+	ExternalID     string    `json:"external_id"`
+	UserID         string    `json:"user_id"`
+	RoomID         string    `json:"room_id"`
+	NumberOfGuests int       `json:"number_of_guests"`
+	StartDate      time.Time `json:"start_date"`
+	EndDate        time.Time `json:"end_date"`
+	PaymentID      string    `json:"payment_id"`
+}
+
+type ReleaseRequest struct {
+	Reason string `json:"reason"`
+}
+
+// RoomRequest creates or changes a room. POST and PUT need every field but
+// internal_id, which PUT takes from the path; PATCH only the fields to
+// change.
+type RoomRequest struct {
+	InternalID *string `json:"internal_id"`
+	Name       *string `json:"name"`
+	Floor      *int    `json:"floor"`
+	Bathrooms  *int    `json:"bathrooms"`
+	Beds       *int    `json:"beds"`
+	Capacity   *int    `json:"capacity"`
+}
+
+// UserRequest creates or changes a user. date_of_birth is a date such as
+// 1990-05-15.
+type UserRequest struct {
+	Email       *string `json:"email"`
+	Username    *string `json:"username"`
+	DateOfBirth *string `json:"date_of_birth"`
+	Name        *string `json:"name"`
+	Surname     *string `json:"surname"`
+}
+
+// BookingRequest creates or changes a booking. user_id and room_id are the
+// numeric IDs; dates are dates such as 2025-10-15 or RFC 3339 times. A new
+// booking without external_id gets one generated, and without status is
+// Accepted.
+type BookingRequest struct {
+	ExternalID     *string `json:"external_id"`
+	UserID         *int    `json:"user_id"`
+	RoomID         *int    `json:"room_id"`
+	NumberOfGuests *int    `json:"number_of_guests"`
+	StartDate      *string `json:"start_date"`
+	EndDate        *string `json:"end_date"`
+	PaymentID      *string `json:"payment_id"`
+	Status         *string `json:"status"`
+	RefusalReason  *string `json:"refusal_reason"`
 }
\ No newline at end of file
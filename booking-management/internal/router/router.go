@@ -3,7 +3,9 @@ package router
 import (
 	"booking-management/internal/database"
 	"booking-management/internal/handlers"
+	"booking-management/internal/metrics"
 	"booking-management/internal/middleware"
+	"booking-management/internal/tracing"
 
 	"github.com/gorilla/mux"
 )
@@ -11,6 +13,8 @@ import (
 func NewRouter(db *database.DB) *mux.Router {
 	router := mux.NewRouter()
 
+	router.Use(tracing.Middleware)
+	router.Use(metrics.Middleware)
 	router.Use(middleware.BaggageMiddleware)
 
 	healthHandler := handlers.NewHealthHandler()
@@ -18,12 +22,34 @@ func NewRouter(db *database.DB) *mux.Router {
 	roomHandler := handlers.NewRoomHandler(db)
 	bookingHandler := handlers.NewBookingHandler(db)
 	validationHandler := handlers.NewValidationHandler(db)
+	reservationHandler := handlers.NewReservationHandler(db)
 
 	router.HandleFunc("/healthz", healthHandler.Healthz).Methods("GET")
+	router.Handle("/metrics", metrics.Handler()).Methods("GET")
 	router.HandleFunc("/users", userHandler.GetUsers).Methods("GET")
+	router.HandleFunc("/users", userHandler.CreateUser).Methods("POST")
+	router.HandleFunc("/users/{id:[0-9]+}", userHandler.GetUser).Methods("GET")
+	router.HandleFunc("/users/{id:[0-9]+}", userHandler.ReplaceUser).Methods("PUT")
+	router.HandleFunc("/users/{id:[0-9]+}", userHandler.PatchUser).Methods("PATCH")
+	router.HandleFunc("/users/{id:[0-9]+}", userHandler.DeleteUser).Methods("DELETE")
 	router.HandleFunc("/rooms", roomHandler.GetRooms).Methods("GET")
+	router.HandleFunc("/rooms", roomHandler.CreateRoom).Methods("POST")
+	// Registered before /rooms/{internalId} so it is not taken for a room
+	router.HandleFunc("/rooms/available", roomHandler.GetAvailableRooms).Methods("GET")
+	router.HandleFunc("/rooms/{internalId}", roomHandler.GetRoom).Methods("GET")
+	router.HandleFunc("/rooms/{internalId}", roomHandler.ReplaceRoom).Methods("PUT")
+	router.HandleFunc("/rooms/{internalId}", roomHandler.PatchRoom).Methods("PATCH")
+	router.HandleFunc("/rooms/{internalId}", roomHandler.DeleteRoom).Methods("DELETE")
 	router.HandleFunc("/bookings", bookingHandler.GetBookings).Methods("GET")
+	router.HandleFunc("/bookings", bookingHandler.CreateBooking).Methods("POST")
+	router.HandleFunc("/bookings/{id:[0-9]+}", bookingHandler.GetBooking).Methods("GET")
+	router.HandleFunc("/bookings/{id:[0-9]+}", bookingHandler.ReplaceBooking).Methods("PUT")
+	router.HandleFunc("/bookings/{id:[0-9]+}", bookingHandler.PatchBooking).Methods("PATCH")
+	router.HandleFunc("/bookings/{id:[0-9]+}", bookingHandler.DeleteBooking).Methods("DELETE")
+	router.HandleFunc("/bookings/external/{externalId}", bookingHandler.GetBookingByExternalID).Methods("GET")
 	router.HandleFunc("/validate", validationHandler.ValidateBooking).Methods("POST")
+	router.HandleFunc("/reservations", reservationHandler.Reserve).Methods("POST")
+	router.HandleFunc("/reservations/{externalId}/release", reservationHandler.Release).Methods("POST")
 
 	return router
 }
\ No newline at end of file
@@ -5,19 +5,38 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"os"
 
 	"booking-management/internal/config"
 	"booking-management/internal/database"
 	"booking-management/internal/logger"
+	"booking-management/internal/migrate"
 	"booking-management/internal/router"
+	"booking-management/internal/tracing"
 )
 
 func main() {
 	ctx := context.Background()
 	cfg := config.Load()
 
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		runMigrate(cfg, os.Args[2:])
+		return
+	}
+
 	logger.Info(ctx, "Starting BookingManagement service", "port", cfg.Port)
 
+	shutdownTracing, err := tracing.Init(ctx, tracing.Options{
+		ServiceName:  "booking-management",
+		OTLPEndpoint: cfg.OTLPEndpoint,
+		File:         cfg.TracesFile,
+	})
+	if err != nil {
+		logger.Error(ctx, "Failed to initialize tracing", "error", err)
+		log.Fatalf("Failed to initialize tracing: %v", err)
+	}
+	defer shutdownTracing(context.Background())
+
 	db, err := database.NewConnection(cfg)
 	if err != nil {
 		logger.Error(ctx, "Failed to connect to database", "error", err)
@@ -27,6 +46,16 @@ func main() {
 
 	logger.Info(ctx, "Database connection established")
 
+	// Refuse to serve against a schema this build was not written for
+	migrations, err := migrate.Load()
+	if err != nil {
+		log.Fatalf("Failed to load migrations: %v", err)
+	}
+	if err := migrate.Check(ctx, db.DB, migrate.Latest(migrations)); err != nil {
+		logger.Error(ctx, "Unexpected database schema version", "error", err)
+		log.Fatalf("Unexpected database schema version: %v; run the migrate command", err)
+	}
+
 	r := router.NewRouter(db)
 
 	server := &http.Server{
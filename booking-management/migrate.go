@@ -0,0 +1,102 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+
+	"booking-management/internal/config"
+	"booking-management/internal/database"
+	"booking-management/internal/logger"
+	"booking-management/internal/migrate"
+)
+
+const migrateUsage = `usage: booking-management migrate <command>
+
+commands:
+  up [-seed]            apply every pending migration; -seed also applies the sample data
+  down                  revert the applied migration with the highest version
+  to [-seed] <version>  migrate up or down to version
+  status                list the migrations and whether they are applied`
+
+// runMigrate implements the migrate subcommand, which changes the database
+// schema to the version this build expects, or to another one.
+func runMigrate(cfg *config.Config, args []string) {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, migrateUsage)
+		os.Exit(2)
+	}
+	command := args[0]
+
+	flags := flag.NewFlagSet("migrate "+command, flag.ExitOnError)
+	seed := flags.Bool("seed", false, "also apply optional migrations, such as the sample data")
+	flags.Parse(args[1:])
+
+	var version int
+	switch command {
+	case "up", "down", "status":
+	case "to":
+		var err error
+		if version, err = strconv.Atoi(flags.Arg(0)); err != nil {
+			fmt.Fprintln(os.Stderr, migrateUsage)
+			os.Exit(2)
+		}
+	default:
+		fmt.Fprintln(os.Stderr, migrateUsage)
+		os.Exit(2)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	migrations, err := migrate.Load()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	db, err := database.NewConnection(cfg)
+	if err != nil {
+		logger.Error(ctx, "Failed to connect to database", "error", err)
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+	defer db.Close()
+
+	migrator := migrate.New(db.DB, migrations)
+
+	switch command {
+	case "up":
+		err = migrator.Up(ctx, *seed)
+	case "down":
+		err = migrator.Down(ctx)
+	case "to":
+		err = migrator.To(ctx, version, *seed)
+	case "status":
+		var statuses []migrate.Status
+		statuses, err = migrator.Status(ctx)
+		for _, status := range statuses {
+			state := "pending"
+			if status.Applied {
+				state = "applied"
+			}
+			if status.Optional {
+				state += " (optional)"
+			}
+			fmt.Printf("%04d  %-32s %s\n", status.Version, status.Name, state)
+		}
+	}
+	if err != nil {
+		logger.Error(ctx, "Migration failed", "command", command, "error", err)
+		log.Fatal(err)
+	}
+
+	current, err := migrate.Version(ctx, db.DB)
+	if err != nil {
+		log.Fatal(err)
+	}
+	logger.Info(ctx, "Database schema version", "version", current, "expected", migrate.Latest(migrations))
+}
\ No newline at end of file
@@ -7,11 +7,14 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"time"
 
 	"booking/internal/logger"
+	"booking/internal/metrics"
 	"booking/internal/middleware"
 	"booking/internal/models"
+	"booking/internal/tracing"
 )
 
 type BookingManagementClient struct {
@@ -23,7 +26,8 @@ func NewBookingManagementClient(baseURL string) *BookingManagementClient {
 	return &BookingManagementClient{
 		baseURL: baseURL,
 		httpClient: &http.Client{
-			Timeout: 30 * time.Second,
+			Timeout:   30 * time.Second,
+			Transport: metrics.NewTransport("booking-management", tracing.NewTransport(http.DefaultTransport)),
 		},
 	}
 }
@@ -76,4 +80,139 @@ func (bmc *BookingManagementClient) ValidateBooking(ctx context.Context, req mod
 
 	logger.Info(ctx, "Booking validation completed", "is_valid", validationResp.IsValid, "reasons_count", len(validationResp.Reasons))
 	return &validationResp, nil
+}
+
+// GetBooking returns the booking stored under the ID the booking service
+// generated for it, or nil when the worker has not stored it yet.
+func (bmc *BookingManagementClient) GetBooking(ctx context.Context, bookingID string) (*models.BookingRecord, error) {
+	httpReq, err := http.NewRequestWithContext(ctx, "GET", fmt.Sprintf("%s/bookings/external/%s", bmc.baseURL, url.PathEscape(bookingID)), nil)
+	if err != nil {
+		logger.Error(ctx, "Failed to create HTTP request", "error", err)
+		return nil, fmt.Errorf("failed to create HTTP request: %w", err)
+	}
+
+	// Propagate baggage header
+	if baggage := middleware.GetBaggageFromContext(ctx); baggage != "" {
+		httpReq.Header.Set("Baggage", baggage)
+	}
+
+	resp, err := bmc.httpClient.Do(httpReq)
+	if err != nil {
+		logger.Error(ctx, "Failed to make HTTP request to booking-management service", "error", err)
+		return nil, fmt.Errorf("failed to make HTTP request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		logger.Error(ctx, "Failed to read response body", "error", err)
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		logger.Error(ctx, "Booking-management service returned error", "statusCode", resp.StatusCode, "body", string(body))
+		return nil, fmt.Errorf("booking-management service returned status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var booking models.BookingRecord
+	if err := json.Unmarshal(body, &booking); err != nil {
+		logger.Error(ctx, "Failed to unmarshal booking", "error", err)
+		return nil, fmt.Errorf("failed to unmarshal booking: %w", err)
+	}
+
+	return &booking, nil
+}
+
+// Reserve holds the room of req for a booking that is about to be paid for.
+// It returns the reasons the room cannot be reserved, if any. Reserving the
+// same booking again succeeds without holding a second room.
+func (bmc *BookingManagementClient) Reserve(ctx context.Context, req models.ReservationRequest) ([]string, error) {
+	logger.Info(ctx, "Reserving room with booking-management service", "bookingId", req.ExternalID, "room_id", req.RoomID)
+
+	payloadBytes, err := json.Marshal(req)
+	if err != nil {
+		logger.Error(ctx, "Failed to marshal reservation request", "error", err)
+		return nil, fmt.Errorf("failed to marshal reservation request: %w", err)
+	}
+
+	resp, body, err := bmc.post(ctx, "/reservations", payloadBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	switch resp.StatusCode {
+	case http.StatusCreated, http.StatusOK:
+		logger.Info(ctx, "Room reserved", "bookingId", req.ExternalID)
+		return nil, nil
+	case http.StatusConflict:
+		var rejection models.BookingValidationResponse
+		if err := json.Unmarshal(body, &rejection); err != nil {
+			logger.Error(ctx, "Failed to unmarshal reservation rejection", "error", err)
+			return nil, fmt.Errorf("failed to unmarshal reservation rejection: %w", err)
+		}
+		logger.Info(ctx, "Room reservation rejected", "bookingId", req.ExternalID, "reasons", rejection.Reasons)
+		return rejection.Reasons, nil
+	default:
+		logger.Error(ctx, "Booking-management service returned error", "statusCode", resp.StatusCode, "body", string(body))
+		return nil, fmt.Errorf("booking-management service returned status %d: %s", resp.StatusCode, string(body))
+	}
+}
+
+// Release gives back the room reserved for bookingID. Releasing a booking
+// that was never reserved is not an error.
+func (bmc *BookingManagementClient) Release(ctx context.Context, bookingID, reason string) error {
+	logger.Info(ctx, "Releasing reserved room", "bookingId", bookingID, "reason", reason)
+
+	payloadBytes, err := json.Marshal(models.ReleaseRequest{Reason: reason})
+	if err != nil {
+		logger.Error(ctx, "Failed to marshal release request", "error", err)
+		return fmt.Errorf("failed to marshal release request: %w", err)
+	}
+
+	resp, body, err := bmc.post(ctx, fmt.Sprintf("/reservations/%s/release", url.PathEscape(bookingID)), payloadBytes)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNotFound {
+		logger.Error(ctx, "Booking-management service returned error", "statusCode", resp.StatusCode, "body", string(body))
+		return fmt.Errorf("booking-management service returned status %d: %s", resp.StatusCode, string(body))
+	}
+
+	logger.Info(ctx, "Reserved room released", "bookingId", bookingID)
+	return nil
+}
+
+// post sends a JSON body to path and returns the response with its body read.
+func (bmc *BookingManagementClient) post(ctx context.Context, path string, payload []byte) (*http.Response, []byte, error) {
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", bmc.baseURL+path, bytes.NewBuffer(payload))
+	if err != nil {
+		logger.Error(ctx, "Failed to create HTTP request", "error", err)
+		return nil, nil, fmt.Errorf("failed to create HTTP request: %w", err)
+	}
+
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	// Propagate baggage header
+	if baggage := middleware.GetBaggageFromContext(ctx); baggage != "" {
+		httpReq.Header.Set("Baggage", baggage)
+	}
+
+	resp, err := bmc.httpClient.Do(httpReq)
+	if err != nil {
+		logger.Error(ctx, "Failed to make HTTP request to booking-management service", "error", err)
+		return nil, nil, fmt.Errorf("failed to make HTTP request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		logger.Error(ctx, "Failed to read response body", "error", err)
+		return nil, nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	return resp, body, nil
 }
\ No newline at end of file
@@ -4,16 +4,25 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"time"
 
 	"booking/internal/logger"
+	"booking/internal/metrics"
 	"booking/internal/middleware"
 	"booking/internal/models"
+	"booking/internal/tracing"
 )
 
+// ErrPaymentRejected is returned when the payment service refuses a payment
+// or refund, e.g. a declined card. Nothing was charged or refunded, and
+// retrying the same request does not help.
+var ErrPaymentRejected = errors.New("payment rejected")
+
 type PaymentClient struct {
 	baseURL    string
 	httpClient *http.Client
@@ -23,7 +32,8 @@ func NewPaymentClient(baseURL string) *PaymentClient {
 	return &PaymentClient{
 		baseURL: baseURL,
 		httpClient: &http.Client{
-			Timeout: 30 * time.Second,
+			Timeout:   30 * time.Second,
+			Transport: metrics.NewTransport("payments", tracing.NewTransport(http.DefaultTransport)),
 		},
 	}
 }
@@ -65,7 +75,7 @@ func (pc *PaymentClient) ProcessPayment(ctx context.Context, req models.PaymentR
 
 	if resp.StatusCode != http.StatusOK {
 		logger.Error(ctx, "Payment service returned error", "statusCode", resp.StatusCode, "body", string(body))
-		return nil, fmt.Errorf("payment service returned status %d: %s", resp.StatusCode, string(body))
+		return nil, statusError(resp.StatusCode, body)
 	}
 
 	var paymentResp models.PaymentResponse
@@ -76,4 +86,108 @@ func (pc *PaymentClient) ProcessPayment(ctx context.Context, req models.PaymentR
 
 	logger.Info(ctx, "Payment processed successfully", "paymentId", req.PaymentID, "success", paymentResp.Success)
 	return &paymentResp, nil
+}
+
+// Refund gives back a processed payment.
+func (pc *PaymentClient) Refund(ctx context.Context, req models.RefundRequest) (*models.RefundResponse, error) {
+	logger.Info(ctx, "Refunding payment", "paymentId", req.PaymentID, "reason", req.Reason)
+
+	payloadBytes, err := json.Marshal(req)
+	if err != nil {
+		logger.Error(ctx, "Failed to marshal refund request", "error", err)
+		return nil, fmt.Errorf("failed to marshal refund request: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", fmt.Sprintf("%s/refund", pc.baseURL), bytes.NewBuffer(payloadBytes))
+	if err != nil {
+		logger.Error(ctx, "Failed to create HTTP request", "error", err)
+		return nil, fmt.Errorf("failed to create HTTP request: %w", err)
+	}
+
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	// Propagate baggage header
+	if baggage := middleware.GetBaggageFromContext(ctx); baggage != "" {
+		httpReq.Header.Set("Baggage", baggage)
+	}
+
+	resp, err := pc.httpClient.Do(httpReq)
+	if err != nil {
+		logger.Error(ctx, "Failed to make HTTP request to payment service", "error", err)
+		return nil, fmt.Errorf("failed to make HTTP request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		logger.Error(ctx, "Failed to read response body", "error", err)
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		logger.Error(ctx, "Payment service returned error", "statusCode", resp.StatusCode, "body", string(body))
+		return nil, statusError(resp.StatusCode, body)
+	}
+
+	var refundResp models.RefundResponse
+	if err := json.Unmarshal(body, &refundResp); err != nil {
+		logger.Error(ctx, "Failed to unmarshal refund response", "error", err)
+		return nil, fmt.Errorf("failed to unmarshal refund response: %w", err)
+	}
+
+	logger.Info(ctx, "Payment refunded", "paymentId", req.PaymentID, "refundId", refundResp.RefundID)
+	return &refundResp, nil
+}
+
+// GetPayment asks the payment service what happened to a payment, e.g. after
+// the response of ProcessPayment was lost. It returns nil if the payment
+// service never saw the payment, so nothing was charged.
+func (pc *PaymentClient) GetPayment(ctx context.Context, paymentID string) (*models.PaymentStatus, error) {
+	httpReq, err := http.NewRequestWithContext(ctx, "GET", fmt.Sprintf("%s/payments/%s", pc.baseURL, url.PathEscape(paymentID)), nil)
+	if err != nil {
+		logger.Error(ctx, "Failed to create HTTP request", "error", err)
+		return nil, fmt.Errorf("failed to create HTTP request: %w", err)
+	}
+
+	// Propagate baggage header
+	if baggage := middleware.GetBaggageFromContext(ctx); baggage != "" {
+		httpReq.Header.Set("Baggage", baggage)
+	}
+
+	resp, err := pc.httpClient.Do(httpReq)
+	if err != nil {
+		logger.Error(ctx, "Failed to make HTTP request to payment service", "error", err)
+		return nil, fmt.Errorf("failed to make HTTP request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		logger.Error(ctx, "Failed to read response body", "error", err)
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		logger.Error(ctx, "Payment service returned error", "statusCode", resp.StatusCode, "body", string(body))
+		return nil, statusError(resp.StatusCode, body)
+	}
+
+	var status models.PaymentStatus
+	if err := json.Unmarshal(body, &status); err != nil {
+		logger.Error(ctx, "Failed to unmarshal payment status", "error", err)
+		return nil, fmt.Errorf("failed to unmarshal payment status: %w", err)
+	}
+	return &status, nil
+}
+
+// statusError wraps ErrPaymentRejected for client errors, which the payment
+// service returns for invalid, declined and rejected requests.
+func statusError(statusCode int, body []byte) error {
+	if statusCode >= 400 && statusCode < 500 {
+		return fmt.Errorf("%w: payment service returned status %d: %s", ErrPaymentRejected, statusCode, string(body))
+	}
+	return fmt.Errorf("payment service returned status %d: %s", statusCode, string(body))
 }
\ No newline at end of file
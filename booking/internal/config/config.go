@@ -3,6 +3,7 @@ package config
 import (
 	"os"
 	"strings"
+	"time"
 )
 
 type Config struct {
@@ -10,6 +11,25 @@ type Config struct {
 	KafkaBrokers  []string
 	PaymentServiceURL string
 	BookingManagementServiceURL string
+	OTLPEndpoint  string
+	TracesFile    string
+
+	DBHost string
+	DBPort string
+	DBUser string
+	DBPass string
+	DBName string
+
+	SagaRecoveryInterval time.Duration
+	SagaStaleAfter       time.Duration
+
+	OutboxPollInterval   time.Duration
+	OutboxRetryBaseDelay time.Duration
+	OutboxRetryMaxDelay  time.Duration
+	OutboxRetention      time.Duration
+	OutboxStuckAfter     time.Duration
+
+	IdempotencyKeyTTL time.Duration
 }
 
 func Load() *Config {
@@ -26,6 +46,25 @@ func Load() *Config {
 		KafkaBrokers: brokers,
 		PaymentServiceURL: getEnv("PAYMENT_SERVICE_URL", "http://payments:3000"),
 		BookingManagementServiceURL: getEnv("BOOKING_MANAGEMENT_SERVICE_URL", "http://booking-management:8080"),
+		OTLPEndpoint: getEnv("OTEL_EXPORTER_OTLP_ENDPOINT", ""),
+		TracesFile:   getEnv("OTEL_TRACES_FILE", ""),
+
+		DBHost: getEnv("DB_HOST", "localhost"),
+		DBPort: getEnv("DB_PORT", "5432"),
+		DBUser: getEnv("DB_USER", "postgres"),
+		DBPass: getEnv("DB_PASS", "postgres"),
+		DBName: getEnv("DB_NAME", "booking_management"),
+
+		SagaRecoveryInterval: getDurationEnv("SAGA_RECOVERY_INTERVAL", 30*time.Second),
+		SagaStaleAfter:       getDurationEnv("SAGA_STALE_AFTER", 2*time.Minute),
+
+		OutboxPollInterval:   getDurationEnv("OUTBOX_POLL_INTERVAL", time.Second),
+		OutboxRetryBaseDelay: getDurationEnv("OUTBOX_RETRY_BASE_DELAY", time.Second),
+		OutboxRetryMaxDelay:  getDurationEnv("OUTBOX_RETRY_MAX_DELAY", 5*time.Minute),
+		OutboxRetention:      getDurationEnv("OUTBOX_RETENTION", 7*24*time.Hour),
+		OutboxStuckAfter:     getDurationEnv("OUTBOX_STUCK_AFTER", time.Minute),
+
+		IdempotencyKeyTTL: getDurationEnv("IDEMPOTENCY_KEY_TTL", 24*time.Hour),
 	}
 }
 
@@ -34,4 +73,13 @@ func getEnv(key, defaultValue string) string {
 		return value
 	}
 	return defaultValue
+}
+
+func getDurationEnv(key string, defaultValue time.Duration) time.Duration {
+	if value := os.Getenv(key); value != "" {
+		if duration, err := time.ParseDuration(value); err == nil {
+			return duration
+		}
+	}
+	return defaultValue
 }
\ No newline at end of file
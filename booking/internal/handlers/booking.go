@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"math/rand"
 	"net/http"
@@ -10,20 +11,28 @@ import (
 	"booking/internal/client"
 	"booking/internal/kafka"
 	"booking/internal/logger"
+	"booking/internal/middleware"
 	"booking/internal/models"
+	"booking/internal/repository"
+	"booking/internal/saga"
+	"booking/internal/store"
+
+	"github.com/gorilla/mux"
 )
 
 type BookingHandler struct {
-	paymentClient           *client.PaymentClient
-	kafkaClient             *kafka.Client
+	sagas                   *saga.Orchestrator
+	outbox                  *repository.OutboxRepository
 	bookingManagementClient *client.BookingManagementClient
+	cancellations           *store.CancellationStore
 }
 
-func NewBookingHandler(paymentClient *client.PaymentClient, kafkaClient *kafka.Client, bookingManagementClient *client.BookingManagementClient) *BookingHandler {
+func NewBookingHandler(sagas *saga.Orchestrator, outbox *repository.OutboxRepository, bookingManagementClient *client.BookingManagementClient, cancellations *store.CancellationStore) *BookingHandler {
 	return &BookingHandler{
-		paymentClient:           paymentClient,
-		kafkaClient:             kafkaClient,
+		sagas:                   sagas,
+		outbox:                  outbox,
 		bookingManagementClient: bookingManagementClient,
+		cancellations:           cancellations,
 	}
 }
 
@@ -45,6 +54,14 @@ func (bh *BookingHandler) Book(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Guests authenticated by the gateway may only book, and be charged, for
+	// themselves
+	if identity := middleware.GetIdentityFromContext(ctx); identity != nil && !identity.IsStaff() && identity.Subject != bookingReq.UserID {
+		logger.Error(ctx, "Caller attempted to book for another user", "subject", identity.Subject, "userId", bookingReq.UserID)
+		http.Error(w, "Cannot book for another user", http.StatusForbidden)
+		return
+	}
+
 	if bookingReq.Guests <= 0 {
 		logger.Error(ctx, "Invalid number of guests", "guests", bookingReq.Guests)
 		http.Error(w, "Invalid number of guests", http.StatusBadRequest)
@@ -57,109 +74,132 @@ func (bh *BookingHandler) Book(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Validate booking with booking-management service
-	validationReq := models.BookingValidationRequest{
-		RoomID:         bookingReq.RoomID,
-		NumberOfGuests: bookingReq.Guests,
-		StartDate:      bookingReq.StartDate,
-		EndDate:        bookingReq.EndDate,
-	}
+	// Generate booking ID
+	bookingID := fmt.Sprintf("booking_%d_%s", time.Now().Unix(), generateRandomString(6))
 
-	validationResp, err := bh.bookingManagementClient.ValidateBooking(ctx, validationReq)
-	if err != nil {
-		logger.Error(ctx, "Failed to validate booking", "error", err)
-		response := models.BookingResponse{
-			Success: false,
-			Message: "Booking validation failed",
-		}
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(response)
-		return
+	bookingEvent := models.BookingEvent{
+		UserID:    bookingReq.UserID,
+		RoomID:    bookingReq.RoomID,
+		Guests:    bookingReq.Guests,
+		StartDate: bookingReq.StartDate,
+		EndDate:   bookingReq.EndDate,
+		BookingID: bookingID,
+		PaymentID: bookingReq.PaymentID,
 	}
 
-	if !validationResp.IsValid {
-		logger.Error(ctx, "Booking validation failed", "reasons", validationResp.Reasons)
-		response := models.BookingResponse{
-			Success: false,
-			Message: fmt.Sprintf("Booking validation failed: %v", validationResp.Reasons),
+	// Reserve the room, charge the payment and publish the booking event;
+	// a failed step undoes the ones before it
+	if err := bh.sagas.Run(ctx, bookingEvent, bookingReq.CreditCardNumber); err != nil {
+		logger.Error(ctx, "Booking saga failed", "bookingId", bookingID, "error", err)
+		status, response := sagaFailureResponse(err)
+		if status != http.StatusBadRequest {
+			response.BookingID = bookingID
 		}
 		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusBadRequest)
+		w.WriteHeader(status)
 		json.NewEncoder(w).Encode(response)
 		return
 	}
 
-	logger.Info(ctx, "Booking validation passed", "room_id", bookingReq.RoomID)
+	logger.Info(ctx, "Booking requested", "bookingId", bookingID, "userId", bookingReq.UserID)
 
-	// Process payment
-	paymentReq := models.PaymentRequest{
-		PaymentID:       bookingReq.PaymentID,
-		CreditCardNumber: bookingReq.CreditCardNumber,
+	// The worker accepts or refuses the booking asynchronously; its status is
+	// available from Status
+	response := models.BookingResponse{
+		Success:   true,
+		Message:   "Booking request accepted",
+		BookingID: bookingID,
+		Status:    models.BookingPending,
 	}
 
-	paymentResp, err := bh.paymentClient.ProcessPayment(ctx, paymentReq)
-	if err != nil {
-		logger.Error(ctx, "Failed to process payment", "error", err)
-		response := models.BookingResponse{
-			Success: false,
-			Message: "Payment processing failed",
-		}
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusPaymentRequired)
-		json.NewEncoder(w).Encode(response)
-		return
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(response)
+}
+
+// sagaFailureResponse maps the step a booking saga stopped at to the
+// response returned to the client.
+func sagaFailureResponse(err error) (int, models.BookingResponse) {
+	response := models.BookingResponse{Success: false}
+
+	var stepErr *saga.StepError
+	if !errors.As(err, &stepErr) {
+		response.Message = "Booking failed"
+		return http.StatusInternalServerError, response
 	}
 
-	if !paymentResp.Success {
-		logger.Error(ctx, "Payment was not successful", "message", paymentResp.Message)
-		response := models.BookingResponse{
-			Success: false,
-			Message: fmt.Sprintf("Payment failed: %s", paymentResp.Message),
+	switch stepErr.Step {
+	case models.SagaStepValidate:
+		if stepErr.Err != nil {
+			response.Message = "Booking validation failed"
+			return http.StatusInternalServerError, response
 		}
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusPaymentRequired)
-		json.NewEncoder(w).Encode(response)
-		return
+		response.Message = fmt.Sprintf("Booking validation failed: %v", stepErr.Reasons)
+		return http.StatusBadRequest, response
+	case models.SagaStepReserve:
+		if stepErr.Err != nil {
+			response.Message = "Room reservation failed"
+			return http.StatusInternalServerError, response
+		}
+		response.Message = fmt.Sprintf("Room reservation failed: %v", stepErr.Reasons)
+		return http.StatusConflict, response
+	case models.SagaStepCharge:
+		if stepErr.Err != nil {
+			response.Message = "Payment processing failed"
+		} else {
+			response.Message = fmt.Sprintf("Payment failed: %s", stepErr.Reasons[0])
+		}
+		return http.StatusPaymentRequired, response
+	default:
+		response.Message = "Booking event publishing failed"
+		return http.StatusInternalServerError, response
 	}
+}
 
-	// Generate booking ID
-	bookingID := fmt.Sprintf("booking_%d_%s", time.Now().Unix(), generateRandomString(6))
+// Status reports the current status of a booking. A booking the worker has
+// not stored yet is Pending. Guests only see their own bookings; any other
+// booking is reported as not found, so its ID cannot be probed.
+func (bh *BookingHandler) Status(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	bookingID := mux.Vars(r)["bookingId"]
 
-	// Create booking event for Kafka
-	bookingEvent := models.BookingEvent{
-		UserID:    bookingReq.UserID,
-		RoomID:    bookingReq.RoomID,
-		Guests:    bookingReq.Guests,
-		StartDate: bookingReq.StartDate,
-		EndDate:   bookingReq.EndDate,
-		BookingID: bookingID,
-		PaymentID: bookingReq.PaymentID,
+	if identity := middleware.GetIdentityFromContext(ctx); identity != nil && !identity.IsStaff() {
+		saga, err := bh.sagas.Get(ctx, bookingID)
+		if err != nil {
+			logger.Error(ctx, "Failed to fetch booking owner", "bookingId", bookingID, "error", err)
+			http.Error(w, "Failed to fetch booking status", http.StatusInternalServerError)
+			return
+		}
+		if saga == nil || saga.Event.UserID != identity.Subject {
+			logger.Error(ctx, "Caller attempted to read another user's booking status", "subject", identity.Subject, "bookingId", bookingID)
+			http.Error(w, "Booking not found", http.StatusNotFound)
+			return
+		}
 	}
 
-	// Publish to Kafka
-	if err := bh.kafkaClient.SendMessage(ctx, "booking-events", bookingID, bookingEvent); err != nil {
-		logger.Error(ctx, "Failed to publish booking event to Kafka", "error", err)
-		response := models.BookingResponse{
-			Success: false,
-			Message: "Booking event publishing failed",
-		}
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(response)
+	booking, err := bh.bookingManagementClient.GetBooking(ctx, bookingID)
+	if err != nil {
+		logger.Error(ctx, "Failed to fetch booking status", "bookingId", bookingID, "error", err)
+		http.Error(w, "Failed to fetch booking status", http.StatusBadGateway)
 		return
 	}
 
-	logger.Info(ctx, "Booking completed successfully", "bookingId", bookingID, "userId", bookingReq.UserID)
-
-	response := models.BookingResponse{
-		Success:   true,
-		Message:   "Booking completed successfully",
+	response := models.BookingStatusResponse{
 		BookingID: bookingID,
+		Status:    models.BookingPending,
 	}
+	if booking != nil {
+		response.Status = booking.Status
+		response.UpdatedAt = &booking.UpdatedAt
+		if booking.RefusalReason != nil {
+			response.RefusalReason = *booking.RefusalReason
+		}
+	}
+
+	logger.Info(ctx, "Fetched booking status", "bookingId", bookingID, "status", response.Status)
 
 	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusCreated)
+	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(response)
 }
 
@@ -181,6 +221,16 @@ func (bh *BookingHandler) Cancel(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Guests authenticated by the gateway may only cancel their own bookings
+	if identity := middleware.GetIdentityFromContext(ctx); identity != nil && !identity.IsStaff() && identity.Subject != cancellationReq.UserID {
+		logger.Error(ctx, "Caller attempted to cancel another user's booking", "subject", identity.Subject, "userId", cancellationReq.UserID)
+		http.Error(w, "Cannot cancel a booking for another user", http.StatusForbidden)
+		return
+	}
+
+	// Forget the result of an earlier cancellation so this one reads as pending
+	bh.cancellations.Delete(cancellationReq.BookingID)
+
 	// Create cancellation event for Kafka
 	cancellationEvent := models.CancellationEvent{
 		BookingID: cancellationReq.BookingID,
@@ -188,9 +238,13 @@ func (bh *BookingHandler) Cancel(w http.ResponseWriter, r *http.Request) {
 		Timestamp: time.Now(),
 	}
 
-	// Publish to Kafka
-	if err := bh.kafkaClient.SendMessage(ctx, "booking-cancellations", cancellationReq.BookingID, cancellationEvent); err != nil {
-		logger.Error(ctx, "Failed to publish cancellation event to Kafka", "error", err)
+	// Store in the outbox; the relay publishes it to Kafka
+	message, err := kafka.NewMessage(ctx, "booking-cancellations", cancellationReq.BookingID, cancellationEvent)
+	if err == nil {
+		err = bh.outbox.Enqueue(ctx, message)
+	}
+	if err != nil {
+		logger.Error(ctx, "Failed to store cancellation event", "error", err)
 		response := models.CancellationResponse{
 			Success: false,
 			Message: "Cancellation event publishing failed",
@@ -201,18 +255,46 @@ func (bh *BookingHandler) Cancel(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	logger.Info(ctx, "Booking cancellation completed successfully", "bookingId", cancellationReq.BookingID, "userId", cancellationReq.UserID)
+	logger.Info(ctx, "Booking cancellation requested", "bookingId", cancellationReq.BookingID, "userId", cancellationReq.UserID)
 
+	// The worker cancels the booking asynchronously; its result is available
+	// from GetCancellation
 	response := models.CancellationResponse{
-		Success: true,
-		Message: "Booking cancellation completed successfully",
+		Success:   true,
+		Message:   "Booking cancellation requested",
+		BookingID: cancellationReq.BookingID,
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
+	w.WriteHeader(http.StatusAccepted)
 	json.NewEncoder(w).Encode(response)
 }
 
+// GetCancellation returns the result of the latest cancellation of a
+// booking, or 404 while the worker has not processed it yet.
+func (bh *BookingHandler) GetCancellation(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	bookingID := mux.Vars(r)["bookingId"]
+
+	result, ok := bh.cancellations.Get(bookingID)
+	if !ok {
+		logger.Info(ctx, "No cancellation result for booking", "bookingId", bookingID)
+		http.Error(w, "No cancellation result for this booking", http.StatusNotFound)
+		return
+	}
+
+	// Guests may only see the cancellations they requested
+	if identity := middleware.GetIdentityFromContext(ctx); identity != nil && !identity.IsStaff() && identity.Subject != result.UserID {
+		logger.Error(ctx, "Caller attempted to read another user's cancellation", "subject", identity.Subject, "bookingId", bookingID)
+		http.Error(w, "Cannot read a cancellation for another user", http.StatusForbidden)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(result)
+}
+
 func generateRandomString(length int) string {
 	const charset = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
 	b := make([]byte, length)
@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"booking/internal/client"
+	"booking/internal/middleware"
+	"booking/internal/saga"
+)
+
+func TestBookRequiresOwnership(t *testing.T) {
+	// booking-management rejects every booking, so an allowed request stops
+	// at validation, before anything needs the database or the payments
+	var validations atomic.Int32
+	bookingManagement := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		validations.Add(1)
+		w.Write([]byte(`{"isValid":false,"reasons":["Room is not available"]}`))
+	}))
+	defer bookingManagement.Close()
+
+	sagas := saga.NewOrchestrator(nil, client.NewBookingManagementClient(bookingManagement.URL), nil)
+	handler := middleware.IdentityMiddleware(http.HandlerFunc(NewBookingHandler(sagas, nil, nil, nil).Book))
+
+	start := time.Now().Add(24 * time.Hour).Format(time.RFC3339)
+	end := time.Now().Add(72 * time.Hour).Format(time.RFC3339)
+	body := `{"paymentId":"pay_1","creditCardNumber":"4242424242424242","roomId":"room_101","userId":"alice","guests":2,"startDate":"` + start + `","endDate":"` + end + `"}`
+
+	tests := []struct {
+		name          string
+		subject, role string
+		want          int
+	}{
+		{"guest booking for another user", "mallory", "guest", http.StatusForbidden},
+		{"guest booking for themselves", "alice", "guest", http.StatusBadRequest},
+		{"staff booking for a guest", "bob", "staff", http.StatusBadRequest},
+		{"admin booking for a guest", "carol", "admin", http.StatusBadRequest},
+		{"caller not authenticated by the gateway", "", "", http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			validations.Store(0)
+			req := httptest.NewRequest("POST", "/book", strings.NewReader(body))
+			if tt.subject != "" {
+				req.Header.Set(middleware.SubjectHeader, tt.subject)
+				req.Header.Set(middleware.RoleHeader, tt.role)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.want {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.want, rec.Body.String())
+			}
+			reached := validations.Load() != 0
+			if reached == (tt.want == http.StatusForbidden) {
+				t.Errorf("booking-management called = %v", reached)
+			}
+		})
+	}
+}
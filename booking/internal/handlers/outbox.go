@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"booking/internal/logger"
+	"booking/internal/middleware"
+	"booking/internal/models"
+	"booking/internal/repository"
+)
+
+const (
+	defaultStuckLimit = 100
+	maxStuckLimit     = 1000
+)
+
+type OutboxHandler struct {
+	outbox     *repository.OutboxRepository
+	stuckAfter time.Duration
+}
+
+func NewOutboxHandler(outbox *repository.OutboxRepository, stuckAfter time.Duration) *OutboxHandler {
+	return &OutboxHandler{
+		outbox:     outbox,
+		stuckAfter: stuckAfter,
+	}
+}
+
+// Stuck lists the outbox messages that are still unsent stuckAfter after
+// they were stored, with their attempts and last error. limit defaults to
+// 100.
+func (oh *OutboxHandler) Stuck(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Only admins authenticated by the gateway may look at the outbox
+	if identity := middleware.GetIdentityFromContext(ctx); identity != nil && identity.Role != "admin" {
+		logger.Error(ctx, "Caller attempted to list the outbox", "subject", identity.Subject, "role", identity.Role)
+		http.Error(w, "Only admins can list the outbox", http.StatusForbidden)
+		return
+	}
+
+	limit := defaultStuckLimit
+	if value := r.URL.Query().Get("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed <= 0 || parsed > maxStuckLimit {
+			http.Error(w, "limit must be between 1 and 1000", http.StatusBadRequest)
+			return
+		}
+		limit = parsed
+	}
+
+	messages, err := oh.outbox.ListStuck(ctx, oh.stuckAfter, limit)
+	if err != nil {
+		logger.Error(ctx, "Failed to list stuck outbox messages", "error", err)
+		http.Error(w, "Failed to list stuck outbox messages", http.StatusInternalServerError)
+		return
+	}
+
+	response := models.StuckOutboxResponse{
+		StuckAfter: oh.stuckAfter.String(),
+		Count:      len(messages),
+		Messages:   messages,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
\ No newline at end of file
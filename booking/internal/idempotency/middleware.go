@@ -0,0 +1,180 @@
+package idempotency
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"booking/internal/logger"
+	"booking/internal/middleware"
+	"booking/internal/store"
+)
+
+// Headers of idempotent requests and replayed responses.
+const (
+	KeyHeader      = "Idempotency-Key"
+	ReplayedHeader = "Idempotent-Replayed"
+)
+
+const (
+	maxKeyLength = 255
+	maxBodyBytes = 1 << 20
+	// pollInterval is how often a duplicate checks whether the request
+	// holding its key has finished.
+	pollInterval = 100 * time.Millisecond
+	// maxWait bounds how long a duplicate waits for that request.
+	maxWait = 30 * time.Second
+)
+
+// Middleware makes requests carrying an Idempotency-Key safe to retry. The
+// first request with a key runs and its response is kept for ttl; repeats
+// with the same body get that response replayed, and repeats with another
+// body get 422. A repeat arriving while the first request still runs waits
+// for it. Keys are scoped to the caller and route. Server errors are not
+// kept, so a request that failed that way runs again when retried.
+func Middleware(keys store.IdempotencyStore, ttl time.Duration) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := r.Context()
+			key := r.Header.Get(KeyHeader)
+			if key == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if len(key) > maxKeyLength {
+				http.Error(w, "Idempotency-Key must be at most 255 characters", http.StatusBadRequest)
+				return
+			}
+
+			body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBodyBytes))
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				logger.Info(ctx, "Idempotent request body too large", "limit", tooLarge.Limit)
+				http.Error(w, "Request body too large", http.StatusRequestEntityTooLarge)
+				return
+			}
+			if err != nil {
+				logger.Error(ctx, "Failed to read idempotent request body", "error", err)
+				http.Error(w, "Invalid request body", http.StatusBadRequest)
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+
+			sum := sha256.Sum256(body)
+			fingerprint := hex.EncodeToString(sum[:])
+			scopedKey := scope(r, key)
+
+			deadline := time.Now().Add(maxWait)
+			for {
+				entry, err := keys.Reserve(ctx, scopedKey, fingerprint, ttl)
+				if errors.Is(err, store.ErrIdempotencyKeysFull) {
+					logger.Warn(ctx, "No room for another Idempotency-Key", "key", key)
+					http.Error(w, "Too many requests with an Idempotency-Key in progress", http.StatusServiceUnavailable)
+					return
+				}
+				if err != nil {
+					logger.Error(ctx, "Failed to reserve Idempotency-Key", "error", err)
+					http.Error(w, "Failed to check Idempotency-Key", http.StatusInternalServerError)
+					return
+				}
+				if entry == nil {
+					break
+				}
+
+				if entry.Fingerprint != fingerprint {
+					logger.Info(ctx, "Idempotency-Key reused with a different body", "key", key)
+					http.Error(w, "Idempotency-Key was already used with a different request body", http.StatusUnprocessableEntity)
+					return
+				}
+				if entry.Response != nil {
+					logger.Info(ctx, "Replaying response for Idempotency-Key", "key", key, "status", entry.Response.Status)
+					replay(w, entry.Response)
+					return
+				}
+
+				if time.Now().After(deadline) {
+					logger.Info(ctx, "Request with the same Idempotency-Key still in progress", "key", key)
+					http.Error(w, "A request with this Idempotency-Key is still in progress", http.StatusConflict)
+					return
+				}
+				select {
+				case <-time.After(pollInterval):
+				case <-ctx.Done():
+					return
+				}
+			}
+
+			// The handler may keep running after the client has gone, and its
+			// response must still be kept for the retry
+			storeCtx := context.WithoutCancel(ctx)
+			rec := &recorder{ResponseWriter: w, status: http.StatusOK}
+			completed := false
+			defer func() {
+				if !completed {
+					if err := keys.Release(storeCtx, scopedKey); err != nil {
+						logger.Error(ctx, "Failed to release Idempotency-Key", "error", err)
+					}
+				}
+			}()
+
+			next.ServeHTTP(rec, r)
+
+			if rec.status >= http.StatusInternalServerError {
+				return
+			}
+			response := store.IdempotentResponse{
+				Status: rec.status,
+				Header: http.Header{"Content-Type": rec.Header().Values("Content-Type")},
+				Body:   rec.body.Bytes(),
+			}
+			if err := keys.Complete(storeCtx, scopedKey, response, ttl); err != nil {
+				logger.Error(ctx, "Failed to store response for Idempotency-Key", "error", err)
+				return
+			}
+			completed = true
+		})
+	}
+}
+
+// scope prefixes key with the caller and route, so different callers and
+// endpoints never share a key.
+func scope(r *http.Request, key string) string {
+	subject := ""
+	if identity := middleware.GetIdentityFromContext(r.Context()); identity != nil {
+		subject = identity.Subject
+	}
+	return subject + " " + r.Method + " " + r.URL.Path + " " + key
+}
+
+func replay(w http.ResponseWriter, response *store.IdempotentResponse) {
+	for name, values := range response.Header {
+		for _, value := range values {
+			w.Header().Add(name, value)
+		}
+	}
+	w.Header().Set(ReplayedHeader, "true")
+	w.WriteHeader(response.Status)
+	w.Write(response.Body)
+}
+
+// recorder passes the response through while keeping a copy of it.
+type recorder struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (r *recorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *recorder) Write(b []byte) (int, error) {
+	r.body.Write(b)
+	return r.ResponseWriter.Write(b)
+}
\ No newline at end of file
@@ -0,0 +1,170 @@
+package idempotency
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"booking/internal/store"
+)
+
+// countingHandler answers every request with status and counts the calls.
+type countingHandler struct {
+	calls  atomic.Int32
+	status int
+	// started, when set, is sent to as each call begins, and unblock holds
+	// the call until it is closed
+	started chan struct{}
+	unblock chan struct{}
+}
+
+func (h *countingHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	call := h.calls.Add(1)
+	if h.started != nil {
+		h.started <- struct{}{}
+	}
+	if h.unblock != nil {
+		<-h.unblock
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(h.status)
+	fmt.Fprintf(w, `{"call":%d}`, call)
+}
+
+func serve(handler http.Handler, key, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/bookings", strings.NewReader(body))
+	if key != "" {
+		req.Header.Set(KeyHeader, key)
+	}
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestMiddlewareReplaysResponses(t *testing.T) {
+	next := &countingHandler{status: http.StatusCreated}
+	handler := Middleware(store.NewMemoryIdempotencyStore(0), time.Minute)(next)
+
+	first := serve(handler, "key-1", `{"room":1}`)
+	if first.Code != http.StatusCreated || first.Header().Get(ReplayedHeader) != "" {
+		t.Fatalf("first request = %d, replayed %q, want 201 not replayed", first.Code, first.Header().Get(ReplayedHeader))
+	}
+
+	repeat := serve(handler, "key-1", `{"room":1}`)
+	if repeat.Code != http.StatusCreated || repeat.Header().Get(ReplayedHeader) != "true" {
+		t.Errorf("repeated request = %d, replayed %q, want a replayed 201", repeat.Code, repeat.Header().Get(ReplayedHeader))
+	}
+	if repeat.Body.String() != first.Body.String() || repeat.Header().Get("Content-Type") != "application/json" {
+		t.Errorf("replayed %q %s, want %s as JSON", repeat.Header().Get("Content-Type"), repeat.Body, first.Body)
+	}
+
+	if rec := serve(handler, "key-2", `{"room":1}`); rec.Code != http.StatusCreated || rec.Header().Get(ReplayedHeader) != "" {
+		t.Errorf("request with another key = %d, replayed %q, want it run", rec.Code, rec.Header().Get(ReplayedHeader))
+	}
+	if rec := serve(handler, "", `{"room":1}`); rec.Code != http.StatusCreated {
+		t.Errorf("request without a key = %d, want 201", rec.Code)
+	}
+	if calls := next.calls.Load(); calls != 3 {
+		t.Errorf("handler ran %d times, want 3", calls)
+	}
+}
+
+func TestMiddlewareRejectsAnotherBody(t *testing.T) {
+	next := &countingHandler{status: http.StatusCreated}
+	handler := Middleware(store.NewMemoryIdempotencyStore(0), time.Minute)(next)
+
+	serve(handler, "key-1", `{"room":1}`)
+	if rec := serve(handler, "key-1", `{"room":2}`); rec.Code != http.StatusUnprocessableEntity {
+		t.Errorf("key reused with another body = %d, want 422", rec.Code)
+	}
+	if calls := next.calls.Load(); calls != 1 {
+		t.Errorf("handler ran %d times, want 1", calls)
+	}
+}
+
+func TestMiddlewareRunsServerErrorsAgain(t *testing.T) {
+	next := &countingHandler{status: http.StatusInternalServerError}
+	handler := Middleware(store.NewMemoryIdempotencyStore(0), time.Minute)(next)
+
+	serve(handler, "key-1", `{"room":1}`)
+	next.status = http.StatusCreated
+	if rec := serve(handler, "key-1", `{"room":1}`); rec.Code != http.StatusCreated || rec.Header().Get(ReplayedHeader) != "" {
+		t.Errorf("retry after a server error = %d, replayed %q, want it run", rec.Code, rec.Header().Get(ReplayedHeader))
+	}
+	if calls := next.calls.Load(); calls != 2 {
+		t.Errorf("handler ran %d times, want 2", calls)
+	}
+}
+
+func TestMiddlewareRunsConcurrentDuplicatesOnce(t *testing.T) {
+	next := &countingHandler{status: http.StatusCreated, started: make(chan struct{}, 2), unblock: make(chan struct{})}
+	handler := Middleware(store.NewMemoryIdempotencyStore(0), time.Minute)(next)
+
+	var wg sync.WaitGroup
+	responses := make([]*httptest.ResponseRecorder, 2)
+	for i := range responses {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			responses[i] = serve(handler, "key-1", `{"room":1}`)
+		}()
+		if i == 0 {
+			// The duplicate arrives while the first request holds the key
+			<-next.started
+		}
+	}
+	time.Sleep(2 * pollInterval)
+	close(next.unblock)
+	wg.Wait()
+
+	if calls := next.calls.Load(); calls != 1 {
+		t.Fatalf("handler ran %d times, want 1", calls)
+	}
+	for i, rec := range responses {
+		if rec.Code != http.StatusCreated {
+			t.Errorf("request %d = %d, want 201", i, rec.Code)
+		}
+	}
+	if responses[1].Header().Get(ReplayedHeader) != "true" {
+		t.Error("duplicate did not get the replayed response")
+	}
+}
+
+func TestMiddlewareRejectsLargeRequests(t *testing.T) {
+	next := &countingHandler{status: http.StatusCreated}
+	handler := Middleware(store.NewMemoryIdempotencyStore(0), time.Minute)(next)
+
+	if rec := serve(handler, "key-1", strings.Repeat("x", maxBodyBytes+1)); rec.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("large body = %d, want 413", rec.Code)
+	}
+	if rec := serve(handler, strings.Repeat("k", maxKeyLength+1), `{}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("long key = %d, want 400", rec.Code)
+	}
+	if calls := next.calls.Load(); calls != 0 {
+		t.Errorf("handler ran %d times, want 0", calls)
+	}
+}
+
+func TestMiddlewareWithAllKeysInProgress(t *testing.T) {
+	next := &countingHandler{status: http.StatusCreated, started: make(chan struct{}, 1), unblock: make(chan struct{})}
+	handler := Middleware(store.NewMemoryIdempotencyStore(1), time.Minute)(next)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		serve(handler, "key-1", `{"room":1}`)
+	}()
+	<-next.started
+
+	// The only key is held by a running request, so there is no room
+	if rec := serve(handler, "key-2", `{"room":1}`); rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("request with every key in progress = %d, want 503", rec.Code)
+	}
+	close(next.unblock)
+	<-done
+}
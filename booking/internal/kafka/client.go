@@ -4,12 +4,24 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"strconv"
+	"time"
 
 	"github.com/IBM/sarama"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
 
 	"booking/internal/logger"
+	"booking/internal/metrics"
+	"booking/internal/middleware"
+	"booking/internal/models"
 )
 
+var tracer = otel.Tracer("booking/internal/kafka")
+
 // Client represents a Kafka client
 type Client struct {
 	producer sarama.SyncProducer
@@ -32,30 +44,79 @@ func NewClient(brokers []string) (*Client, error) {
 	}, nil
 }
 
-// SendMessage sends a message to the specified Kafka topic
-func (c *Client) SendMessage(ctx context.Context, topic string, key string, message interface{}) error {
-	logger.Info(ctx, "Sending message to Kafka", "topic", topic, "key", key)
+// NewClientFromProducer creates a Kafka client sending through producer,
+// e.g. a mock producer in tests.
+func NewClientFromProducer(producer sarama.SyncProducer) *Client {
+	return &Client{producer: producer}
+}
 
-	// Serialize the message to JSON
+// NewMessage serializes message for the outbox, keeping the baggage and
+// trace context of ctx so the relay publishes it as part of the same trace.
+func NewMessage(ctx context.Context, topic string, key string, message interface{}) (models.OutboxMessage, error) {
 	messageBytes, err := json.Marshal(message)
 	if err != nil {
 		logger.Error(ctx, "Failed to marshal message", "error", err, "topic", topic)
-		return fmt.Errorf("failed to marshal message: %w", err)
+		return models.OutboxMessage{}, fmt.Errorf("failed to marshal message: %w", err)
+	}
+
+	headers := map[string]string{}
+	if baggage := middleware.GetBaggageFromContext(ctx); baggage != "" {
+		headers[BaggageHeader] = baggage
 	}
+	otel.GetTextMapPropagator().Inject(ctx, propagation.MapCarrier(headers))
+
+	return models.OutboxMessage{
+		Topic:   topic,
+		Key:     key,
+		Payload: messageBytes,
+		Headers: headers,
+	}, nil
+}
+
+// Publish sends an outbox message to its Kafka topic, continuing the trace
+// of the request that stored it
+func (c *Client) Publish(ctx context.Context, message models.OutboxMessage) error {
+	if baggage := message.Headers[BaggageHeader]; baggage != "" {
+		ctx = middleware.WithBaggage(ctx, baggage)
+	}
+	ctx = otel.GetTextMapPropagator().Extract(ctx, propagation.MapCarrier(message.Headers))
+
+	topic, key := message.Topic, message.Key
+	logger.Info(ctx, "Sending message to Kafka", "topic", topic, "key", key, "outboxId", message.ID)
 
 	// Create Kafka message
 	msg := &sarama.ProducerMessage{
 		Topic: topic,
 		Key:   sarama.StringEncoder(key),
-		Value: sarama.ByteEncoder(messageBytes),
+		Value: sarama.ByteEncoder(message.Payload),
 	}
 
+	// Start a producer span and carry it, with the baggage, in the message headers
+	ctx, span := tracer.Start(ctx, topic+" publish",
+		trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithAttributes(
+			semconv.MessagingSystemKafka,
+			semconv.MessagingDestinationName(topic),
+			semconv.MessagingOperationTypePublish,
+		),
+	)
+	defer span.End()
+	InjectHeaders(ctx, msg)
+
 	// Send message
+	start := time.Now()
 	partition, offset, err := c.producer.SendMessage(msg)
+	metrics.ObserveKafkaSend(topic, time.Since(start), err)
 	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
 		logger.Error(ctx, "Failed to send message to Kafka", "error", err, "topic", topic, "key", key)
 		return fmt.Errorf("failed to send message: %w", err)
 	}
+	span.SetAttributes(
+		semconv.MessagingDestinationPartitionID(strconv.Itoa(int(partition))),
+		semconv.MessagingKafkaMessageOffset(int(offset)),
+	)
 
 	logger.Info(ctx, "Message sent to Kafka successfully",
 		"topic", topic,
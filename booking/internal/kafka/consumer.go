@@ -0,0 +1,122 @@
+package kafka
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/IBM/sarama"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"booking/internal/logger"
+	"booking/internal/models"
+)
+
+// CancellationResultsTopic is where the worker publishes the outcome of
+// every cancellation event.
+const CancellationResultsTopic = "booking-cancellation-results"
+
+// CancellationResultConsumer reads every partition of the cancellation
+// results topic. It does not join a consumer group: each replica of the
+// service needs every result to answer status requests. Results published
+// while the service is down are not read.
+type CancellationResultConsumer struct {
+	consumer sarama.Consumer
+	handler  func(context.Context, models.CancellationResult)
+}
+
+func NewCancellationResultConsumer(brokers []string, handler func(context.Context, models.CancellationResult)) (*CancellationResultConsumer, error) {
+	consumer, err := sarama.NewConsumer(brokers, sarama.NewConfig())
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Kafka consumer: %w", err)
+	}
+
+	return &CancellationResultConsumer{
+		consumer: consumer,
+		handler:  handler,
+	}, nil
+}
+
+// Start consumes until ctx is done. It waits for the topic to exist, since
+// the worker creates it with its first result.
+func (c *CancellationResultConsumer) Start(ctx context.Context) error {
+	var partitions []int32
+	for {
+		var err error
+		partitions, err = c.consumer.Partitions(CancellationResultsTopic)
+		if err == nil && len(partitions) > 0 {
+			break
+		}
+		logger.Warn(ctx, "Cancellation results topic not available yet, retrying", "topic", CancellationResultsTopic, "error", err)
+		select {
+		case <-time.After(5 * time.Second):
+		case <-ctx.Done():
+			return nil
+		}
+	}
+
+	var wg sync.WaitGroup
+	for _, partition := range partitions {
+		pc, err := c.consumer.ConsumePartition(CancellationResultsTopic, partition, sarama.OffsetNewest)
+		if err != nil {
+			return fmt.Errorf("failed to consume partition %d: %w", partition, err)
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer pc.Close()
+			c.consumePartition(ctx, pc)
+		}()
+	}
+
+	logger.Info(ctx, "Consuming cancellation results", "topic", CancellationResultsTopic, "partitions", len(partitions))
+	wg.Wait()
+	return nil
+}
+
+func (c *CancellationResultConsumer) consumePartition(ctx context.Context, pc sarama.PartitionConsumer) {
+	for {
+		select {
+		case message := <-pc.Messages():
+			c.handleMessage(ctx, message)
+		case err := <-pc.Errors():
+			logger.Error(ctx, "Failed to consume cancellation results", "error", err)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (c *CancellationResultConsumer) handleMessage(ctx context.Context, message *sarama.ConsumerMessage) {
+	ctx = ExtractHeaders(ctx, message.Headers)
+	ctx, span := tracer.Start(ctx, message.Topic+" process",
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(
+			semconv.MessagingSystemKafka,
+			semconv.MessagingDestinationName(message.Topic),
+			semconv.MessagingOperationTypeDeliver,
+			semconv.MessagingDestinationPartitionID(strconv.Itoa(int(message.Partition))),
+			semconv.MessagingKafkaMessageOffset(int(message.Offset)),
+		),
+	)
+	defer span.End()
+
+	var result models.CancellationResult
+	if err := json.Unmarshal(message.Value, &result); err != nil {
+		span.RecordError(err)
+		logger.Error(ctx, "Failed to unmarshal cancellation result", "error", err, "offset", message.Offset)
+		return
+	}
+
+	logger.Info(ctx, "Received cancellation result", "bookingId", result.BookingID, "result", result.Result)
+	c.handler(ctx, result)
+}
+
+func (c *CancellationResultConsumer) Close() error {
+	return c.consumer.Close()
+}
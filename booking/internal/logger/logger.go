@@ -5,6 +5,8 @@ import (
 	"log/slog"
 	"os"
 
+	"go.opentelemetry.io/otel/trace"
+
 	"booking/internal/middleware"
 )
 
@@ -35,10 +37,13 @@ func Warn(ctx context.Context, msg string, args ...any) {
 func logWithBaggage(ctx context.Context, level slog.Level, msg string, args ...any) {
 	baggage := middleware.GetBaggageFromContext(ctx)
 
-	logArgs := make([]any, 0, len(args)+2)
+	logArgs := make([]any, 0, len(args)+6)
 	if baggage != "" {
 		logArgs = append(logArgs, "baggage", baggage)
 	}
+	if spanCtx := trace.SpanContextFromContext(ctx); spanCtx.IsValid() {
+		logArgs = append(logArgs, "trace_id", spanCtx.TraceID().String(), "span_id", spanCtx.SpanID().String())
+	}
 	logArgs = append(logArgs, args...)
 
 	defaultLogger.Log(ctx, level, msg, logArgs...)
@@ -0,0 +1,134 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+var (
+	httpRequestsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "http_server_requests_total",
+		Help: "Inbound HTTP requests by route, method and status.",
+	}, []string{"route", "method", "status"})
+
+	httpRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "http_server_request_duration_seconds",
+		Help:    "Inbound HTTP request latency by route, method and status.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"route", "method", "status"})
+
+	upstreamRequestsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "http_client_requests_total",
+		Help: "Upstream requests by upstream, method and outcome (status code or error).",
+	}, []string{"upstream", "method", "outcome"})
+
+	upstreamRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "http_client_request_duration_seconds",
+		Help:    "Upstream request latency until response headers, by upstream and method.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"upstream", "method"})
+
+	kafkaSendDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "kafka_producer_send_duration_seconds",
+		Help:    "Kafka produce latency by topic, including failed sends.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"topic"})
+
+	kafkaSendFailures = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "kafka_producer_send_failures_total",
+		Help: "Kafka messages that could not be produced, by topic.",
+	}, []string{"topic"})
+)
+
+// Handler serves the Prometheus scrape endpoint.
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
+
+// Middleware records the count and latency of every request matched by the
+// router, labelled with the route template rather than the raw path.
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+
+		next.ServeHTTP(rec, r)
+
+		route := "unmatched"
+		if current := mux.CurrentRoute(r); current != nil {
+			if tpl, err := current.GetPathTemplate(); err == nil {
+				route = tpl
+			}
+		}
+		status := strconv.Itoa(rec.status)
+
+		httpRequestsTotal.WithLabelValues(route, r.Method, status).Inc()
+		httpRequestDuration.WithLabelValues(route, r.Method, status).Observe(time.Since(start).Seconds())
+	})
+}
+
+// NewTransport wraps base so every request made through it is recorded under
+// the given upstream name. A nil base uses http.DefaultTransport.
+func NewTransport(upstream string, base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &transport{upstream: upstream, base: base}
+}
+
+type transport struct {
+	upstream string
+	base     http.RoundTripper
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	resp, err := t.base.RoundTrip(req)
+
+	outcome := "error"
+	if err == nil {
+		outcome = strconv.Itoa(resp.StatusCode)
+	}
+	upstreamRequestsTotal.WithLabelValues(t.upstream, req.Method, outcome).Inc()
+	upstreamRequestDuration.WithLabelValues(t.upstream, req.Method).Observe(time.Since(start).Seconds())
+
+	return resp, err
+}
+
+// ObserveKafkaSend records a single produce call and whether it failed.
+func ObserveKafkaSend(topic string, duration time.Duration, err error) {
+	kafkaSendDuration.WithLabelValues(topic).Observe(duration.Seconds())
+	if err != nil {
+		kafkaSendFailures.WithLabelValues(topic).Inc()
+	}
+}
+
+// statusRecorder captures the response status for Middleware.
+type statusRecorder struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	if !r.wroteHeader {
+		r.status = status
+		r.wroteHeader = true
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *statusRecorder) Write(b []byte) (int, error) {
+	r.wroteHeader = true
+	return r.ResponseWriter.Write(b)
+}
+
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
@@ -0,0 +1,67 @@
+package metrics
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestMiddlewareLabelsRouteTemplate(t *testing.T) {
+	router := mux.NewRouter()
+	router.Use(Middleware)
+	router.HandleFunc("/bookings/{id}", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "Booking not found", http.StatusNotFound)
+	})
+
+	counter := httpRequestsTotal.WithLabelValues("/bookings/{id}", "GET", "404")
+	before := testutil.ToFloat64(counter)
+	for _, id := range []string{"b1", "b2"} {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/bookings/"+id, nil))
+	}
+
+	// Both bookings share one series, keyed on the template
+	if got := testutil.ToFloat64(counter) - before; got != 2 {
+		t.Errorf("requests counted under /bookings/{id} = %v, want 2", got)
+	}
+}
+
+func TestTransportRecordsOutcome(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	client := &http.Client{Transport: NewTransport("metrics-test", nil)}
+
+	resp, err := client.Get(upstream.URL)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	resp.Body.Close()
+	upstream.Close()
+	if _, err := client.Get(upstream.URL); err == nil {
+		t.Fatal("request to a closed server succeeded")
+	}
+
+	if got := testutil.ToFloat64(upstreamRequestsTotal.WithLabelValues("metrics-test", "GET", "503")); got != 1 {
+		t.Errorf("requests answered with 503 = %v, want 1", got)
+	}
+	if got := testutil.ToFloat64(upstreamRequestsTotal.WithLabelValues("metrics-test", "GET", "error")); got != 1 {
+		t.Errorf("failed requests = %v, want 1", got)
+	}
+}
+
+func TestObserveKafkaSend(t *testing.T) {
+	ObserveKafkaSend("metrics-test", time.Millisecond, nil)
+	ObserveKafkaSend("metrics-test", time.Millisecond, errors.New("broker down"))
+
+	if got := testutil.ToFloat64(kafkaSendFailures.WithLabelValues("metrics-test")); got != 1 {
+		t.Errorf("send failures = %v, want 1", got)
+	}
+	if got := testutil.CollectAndCount(kafkaSendDuration, "kafka_producer_send_duration_seconds"); got != 1 {
+		t.Errorf("send duration series = %d, want 1", got)
+	}
+}
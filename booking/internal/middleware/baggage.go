@@ -20,6 +20,12 @@ func BaggageMiddleware(next http.Handler) http.Handler {
 	})
 }
 
+// WithBaggage returns ctx carrying baggage, for work that does not start
+// from an HTTP request such as consumed Kafka messages.
+func WithBaggage(ctx context.Context, baggage string) context.Context {
+	return context.WithValue(ctx, BaggageContextKey, baggage)
+}
+
 func GetBaggageFromContext(ctx context.Context) string {
 	if baggage, ok := ctx.Value(BaggageContextKey).(string); ok {
 		return baggage
@@ -0,0 +1,51 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+)
+
+// Headers set by the gateway for authenticated callers. The gateway strips
+// them from client requests, so they can be trusted behind it.
+const (
+	SubjectHeader = "X-Authenticated-Subject"
+	RoleHeader    = "X-Authenticated-Role"
+)
+
+const IdentityContextKey contextKey = "identity"
+
+// Identity is the authenticated caller forwarded by the gateway.
+type Identity struct {
+	Subject string
+	Role    string
+}
+
+// IsStaff reports whether the caller may act on behalf of other users.
+func (i *Identity) IsStaff() bool {
+	return i.Role == "staff" || i.Role == "admin"
+}
+
+func IdentityMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		subject := r.Header.Get(SubjectHeader)
+
+		if subject != "" {
+			identity := &Identity{
+				Subject: subject,
+				Role:    r.Header.Get(RoleHeader),
+			}
+			ctx := context.WithValue(r.Context(), IdentityContextKey, identity)
+			r = r.WithContext(ctx)
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// GetIdentityFromContext returns the caller, or nil for unauthenticated requests.
+func GetIdentityFromContext(ctx context.Context) *Identity {
+	if identity, ok := ctx.Value(IdentityContextKey).(*Identity); ok {
+		return identity
+	}
+	return nil
+}
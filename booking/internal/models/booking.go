@@ -16,6 +16,31 @@ type BookingResponse struct {
 	Success bool   `json:"success"`
 	Message string `json:"message"`
 	BookingID string `json:"bookingId,omitempty"`
+	Status    string `json:"status,omitempty"`
+}
+
+// Booking statuses. A booking is Pending until the worker accepts or
+// refuses it; Pending and Accepted bookings can be Cancelled.
+const (
+	BookingPending   = "Pending"
+	BookingAccepted  = "Accepted"
+	BookingRefused   = "Refused"
+	BookingCancelled = "Cancelled"
+)
+
+// BookingRecord is a booking as stored by booking-management.
+type BookingRecord struct {
+	ExternalID    string    `json:"external_id"`
+	Status        string    `json:"status"`
+	RefusalReason *string   `json:"refusal_reason"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+type BookingStatusResponse struct {
+	BookingID     string     `json:"bookingId"`
+	Status        string     `json:"status"`
+	RefusalReason string     `json:"refusalReason,omitempty"`
+	UpdatedAt     *time.Time `json:"updatedAt,omitempty"`
 }
 
 type PaymentRequest struct {
@@ -44,8 +69,9 @@ type CancellationRequest struct {
 }
 
 type CancellationResponse struct {
-	Success bool   `json:"success"`
-	Message string `json:"message"`
+	Success   bool   `json:"success"`
+	Message   string `json:"message"`
+	BookingID string `json:"bookingId,omitempty"`
 }
 
 type CancellationEvent struct {
@@ -54,6 +80,22 @@ type CancellationEvent struct {
 	Timestamp time.Time `json:"timestamp"`
 }
 
+// Outcomes of a cancellation, reported by the worker in CancellationResult.
+const (
+	CancellationCancelled        = "cancelled"
+	CancellationAlreadyCancelled = "already_cancelled"
+	CancellationRefused          = "refused"
+	CancellationNotFound         = "not_found"
+	CancellationNotOwned         = "not_owned"
+)
+
+type CancellationResult struct {
+	BookingID string    `json:"bookingId"`
+	UserID    string    `json:"userId"`
+	Result    string    `json:"result"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
 type BookingValidationRequest struct {
 	RoomID         string    `json:"room_id"`
 	NumberOfGuests int       `json:"number_of_guests"`
@@ -64,4 +106,44 @@ type BookingValidationRequest struct {
 type BookingValidationResponse struct {
 	IsValid bool     `json:"isValid"`
 	Reasons []string `json:"reasons"`
+}
+
+type ReservationRequest struct {
+	ExternalID     string    `json:"external_id"`
+	UserID         string    `json:"user_id"`
+	RoomID         string    `json:"room_id"`
+	NumberOfGuests int       `json:"number_of_guests"`
+	StartDate      time.Time `json:"start_date"`
+	EndDate        time.Time `json:"end_date"`
+	PaymentID      string    `json:"payment_id"`
+}
+
+type ReleaseRequest struct {
+	Reason string `json:"reason"`
+}
+
+type RefundRequest struct {
+	PaymentID string `json:"paymentId"`
+	Reason    string `json:"reason"`
+}
+
+// Statuses the payment service reports for a payment.
+const (
+	PaymentProcessing = "processing"
+	PaymentCompleted  = "completed"
+	PaymentDeclined   = "declined"
+	PaymentFailed     = "failed"
+	PaymentRefunded   = "refunded"
+)
+
+// PaymentStatus is what the payment service knows of a payment.
+type PaymentStatus struct {
+	PaymentID string `json:"paymentId"`
+	Status    string `json:"status"`
+}
+
+type RefundResponse struct {
+	Success  bool   `json:"success"`
+	RefundID string `json:"refundId"`
+	Message  string `json:"message"`
 }
\ No newline at end of file
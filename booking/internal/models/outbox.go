@@ -0,0 +1,28 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// OutboxMessage is a Kafka message stored in the outbox until the relay has
+// published it. Headers hold the baggage and trace context of the request
+// that produced it.
+type OutboxMessage struct {
+	ID            int64             `json:"id"`
+	Topic         string            `json:"topic"`
+	Key           string            `json:"key"`
+	Payload       json.RawMessage   `json:"payload"`
+	Headers       map[string]string `json:"headers,omitempty"`
+	Attempts      int               `json:"attempts"`
+	LastError     string            `json:"lastError,omitempty"`
+	CreatedAt     time.Time         `json:"createdAt"`
+	NextAttemptAt time.Time         `json:"nextAttemptAt"`
+}
+
+// StuckOutboxResponse lists outbox messages that have waited too long.
+type StuckOutboxResponse struct {
+	StuckAfter string          `json:"stuckAfter"`
+	Count      int             `json:"count"`
+	Messages   []OutboxMessage `json:"messages"`
+}
\ No newline at end of file
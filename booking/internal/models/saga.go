@@ -0,0 +1,44 @@
+package models
+
+import "time"
+
+// Steps of the booking saga. Forward steps run in order; when the saga fails
+// it switches to the compensation steps, refunding the payment and then
+// releasing the reserved room. A charge whose outcome is unknown is looked
+// up at the payment service first, to refund only what was charged.
+// Validation has no side effects and is not persisted.
+const (
+	SagaStepValidate    = "validate"
+	SagaStepReserve     = "reserve"
+	SagaStepCharge      = "charge"
+	SagaStepPublish     = "publish"
+	SagaStepCheckCharge = "check_charge"
+	SagaStepRefund      = "refund"
+	SagaStepRelease     = "release"
+)
+
+// Statuses of the booking saga.
+const (
+	SagaRunning      = "running"
+	SagaCompleted    = "completed"
+	SagaCompensating = "compensating"
+	SagaCompensated  = "compensated"
+	// SagaFailed means the room was released but the refund was rejected,
+	// so the payment needs a person to refund it.
+	SagaFailed = "failed"
+)
+
+// BookingSaga is the persisted state of one booking request. Step is the
+// step being run; Event is everything later steps need, without the card
+// number. ManualRefund is set when the payment service rejected the refund,
+// so the payment must be refunded by hand once the room is released.
+type BookingSaga struct {
+	BookingID    string
+	Step         string
+	Status       string
+	Event        BookingEvent
+	Error        string
+	ManualRefund bool
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+}
\ No newline at end of file
@@ -0,0 +1,120 @@
+package outbox
+
+import (
+	"context"
+	"math/rand"
+	"time"
+
+	"booking/internal/kafka"
+	"booking/internal/logger"
+	"booking/internal/repository"
+)
+
+const (
+	// batchSize bounds how many messages one relay pass claims.
+	batchSize = 100
+	// claimLease is how long a claimed message is left alone before another
+	// pass may claim it again.
+	claimLease = 30 * time.Second
+	// purgeInterval is how often sent messages past retention are deleted.
+	purgeInterval = time.Hour
+)
+
+// Options configure the relay.
+type Options struct {
+	// PollInterval is how long the relay waits when nothing is due.
+	PollInterval time.Duration
+	// BaseDelay and MaxDelay bound the backoff between failed publishes.
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+	// Retention is how long sent messages are kept.
+	Retention time.Duration
+}
+
+// Relay publishes the messages stored in the outbox to Kafka and marks them
+// sent. A message that cannot be published is retried with full-jitter
+// exponential backoff until it goes through.
+type Relay struct {
+	repo  *repository.OutboxRepository
+	kafka *kafka.Client
+	opts  Options
+}
+
+func NewRelay(repo *repository.OutboxRepository, kafkaClient *kafka.Client, opts Options) *Relay {
+	return &Relay{repo: repo, kafka: kafkaClient, opts: opts}
+}
+
+// Run relays messages until ctx is done.
+func (r *Relay) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.opts.PollInterval)
+	defer ticker.Stop()
+
+	lastPurge := time.Time{}
+	for {
+		// Keep going while full batches are due
+		claimed, err := r.relayBatch(ctx)
+		if err != nil && ctx.Err() == nil {
+			logger.Warn(ctx, "Failed to relay outbox messages", "error", err)
+		}
+
+		if time.Since(lastPurge) >= purgeInterval {
+			lastPurge = time.Now()
+			if purged, err := r.repo.PurgeSent(ctx, r.opts.Retention); err != nil {
+				logger.Warn(ctx, "Failed to purge sent outbox messages", "error", err)
+			} else if purged > 0 {
+				logger.Info(ctx, "Purged sent outbox messages", "count", purged)
+			}
+		}
+
+		if err == nil && claimed == batchSize {
+			continue
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// relayBatch publishes one batch of due messages and returns how many it
+// claimed.
+func (r *Relay) relayBatch(ctx context.Context) (int, error) {
+	messages, err := r.repo.ClaimDue(ctx, batchSize, claimLease)
+	if err != nil {
+		return 0, err
+	}
+
+	// A batch holds at most one message per key, see ClaimDue
+	for _, message := range messages {
+		if err := r.kafka.Publish(ctx, message); err != nil {
+			next := time.Now().Add(r.backoff(message.Attempts))
+			logger.Warn(ctx, "Outbox message not published, will retry", "outboxId", message.ID, "topic", message.Topic, "attempts", message.Attempts+1, "nextAttemptAt", next, "error", err)
+			if err := r.repo.MarkFailed(ctx, message.ID, err, next); err != nil {
+				logger.Error(ctx, "Failed to record outbox publish failure", "outboxId", message.ID, "error", err)
+			}
+			continue
+		}
+
+		if err := r.repo.MarkSent(ctx, message.ID); err != nil {
+			// The lease runs out and the message is sent again; consumers
+			// handle duplicates
+			logger.Error(ctx, "Failed to mark outbox message sent", "outboxId", message.ID, "error", err)
+		}
+	}
+
+	return len(messages), nil
+}
+
+// backoff returns a random delay in [0, min(MaxDelay, BaseDelay*2^attempt)].
+func (r *Relay) backoff(attempt int) time.Duration {
+	delay := r.opts.BaseDelay << attempt
+	if delay <= 0 || delay > r.opts.MaxDelay {
+		delay = r.opts.MaxDelay
+	}
+	if delay <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(delay) + 1))
+}
\ No newline at end of file
@@ -0,0 +1,138 @@
+package outbox
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/IBM/sarama/mocks"
+	_ "github.com/lib/pq"
+
+	"booking/internal/kafka"
+	"booking/internal/models"
+	"booking/internal/repository"
+)
+
+func TestBackoffBounds(t *testing.T) {
+	relay := &Relay{opts: Options{BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second}}
+
+	tests := []struct {
+		attempt int
+		max     time.Duration
+	}{
+		{0, 100 * time.Millisecond},
+		{2, 400 * time.Millisecond},
+		{4, time.Second},
+		{80, time.Second},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprint(tt.attempt), func(t *testing.T) {
+			for range 1000 {
+				if delay := relay.backoff(tt.attempt); delay < 0 || delay > tt.max {
+					t.Fatalf("backoff(%d) = %s, want within [0, %s]", tt.attempt, delay, tt.max)
+				}
+			}
+		})
+	}
+}
+
+// openTestDB connects to TEST_DATABASE_URL, a PostgreSQL database migrated
+// with the booking-management migrate command, and skips the test without
+// one. The connection works on an empty copy of booking_outbox in a schema
+// of its own, so the relay only sees the messages of the test.
+func openTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+	ctx := context.Background()
+
+	url := os.Getenv("TEST_DATABASE_URL")
+	if url == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+
+	db, err := sql.Open("postgres", url)
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+
+	if err := repository.CheckSchemaVersion(ctx, db); err != nil {
+		t.Fatalf("test database is not usable: %v", err)
+	}
+
+	schema := fmt.Sprintf("relay_test_%d", time.Now().UnixNano())
+	for _, statement := range []string{
+		`CREATE SCHEMA ` + schema,
+		`CREATE TABLE ` + schema + `.booking_outbox (LIKE booking_outbox INCLUDING ALL)`,
+		`SET search_path TO ` + schema,
+	} {
+		if _, err := db.ExecContext(ctx, statement); err != nil {
+			t.Fatalf("failed to set up outbox schema: %v", err)
+		}
+	}
+	t.Cleanup(func() { db.ExecContext(context.Background(), `DROP SCHEMA `+schema+` CASCADE`) })
+	return db
+}
+
+func TestRelayBatch(t *testing.T) {
+	db := openTestDB(t)
+	repo := repository.NewOutboxRepository(db)
+	ctx := context.Background()
+
+	for _, key := range []string{"booking_1", "booking_2", "booking_1"} {
+		if err := repo.Enqueue(ctx, models.OutboxMessage{Topic: "booking-events", Key: key, Payload: []byte(`{}`)}); err != nil {
+			t.Fatalf("Enqueue failed: %v", err)
+		}
+	}
+
+	producer := mocks.NewSyncProducer(t, nil)
+	defer producer.Close()
+	relay := NewRelay(repo, kafka.NewClientFromProducer(producer), Options{BaseDelay: time.Hour, MaxDelay: time.Hour})
+
+	// The first booking_1 message goes through, booking_2 fails, and the
+	// second booking_1 message waits for the first
+	producer.ExpectSendMessageWithMessageCheckerFunctionAndSucceed(keyIs("booking_1"))
+	producer.ExpectSendMessageAndFail(sarama.ErrOutOfBrokers)
+	if claimed, err := relay.relayBatch(ctx); err != nil || claimed != 2 {
+		t.Fatalf("relayBatch = %d, %v, want 2 messages", claimed, err)
+	}
+
+	var attempts int
+	var lastError string
+	err := db.QueryRowContext(ctx, `SELECT attempts, last_error FROM booking_outbox WHERE key = 'booking_2'`).Scan(&attempts, &lastError)
+	if err != nil || attempts != 1 || lastError == "" {
+		t.Fatalf("failed message = %d attempts, %q, %v, want a recorded failure", attempts, lastError, err)
+	}
+
+	// Now the second booking_1 message is due, and booking_2 is backing off
+	producer.ExpectSendMessageWithMessageCheckerFunctionAndSucceed(keyIs("booking_1"))
+	if claimed, err := relay.relayBatch(ctx); err != nil || claimed != 1 {
+		t.Fatalf("relayBatch = %d, %v, want 1 message", claimed, err)
+	}
+
+	var unsent int
+	if err := db.QueryRowContext(ctx, `SELECT COUNT(*) FROM booking_outbox WHERE sent_at IS NULL`).Scan(&unsent); err != nil {
+		t.Fatalf("failed to count unsent messages: %v", err)
+	}
+	if unsent != 1 {
+		t.Errorf("%d messages unsent, want only booking_2", unsent)
+	}
+}
+
+// keyIs checks that a published message has key.
+func keyIs(key string) mocks.MessageChecker {
+	return func(message *sarama.ProducerMessage) error {
+		got, err := message.Key.Encode()
+		if err != nil {
+			return err
+		}
+		if string(got) != key {
+			return fmt.Errorf("published key %q, want %q", got, key)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,155 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"booking/internal/models"
+)
+
+// execer is satisfied by both *sql.DB and *sql.Tx, so outbox messages can be
+// stored in the transaction of the change that produced them.
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+// OutboxRepository stores Kafka messages in the booking_outbox table until
+// the relay has published them.
+type OutboxRepository struct {
+	db *sql.DB
+}
+
+func NewOutboxRepository(db *sql.DB) *OutboxRepository {
+	return &OutboxRepository{db: db}
+}
+
+// Enqueue stores message for the relay to publish.
+func (r *OutboxRepository) Enqueue(ctx context.Context, message models.OutboxMessage) error {
+	return insertOutboxMessage(ctx, r.db, message)
+}
+
+func insertOutboxMessage(ctx context.Context, q execer, message models.OutboxMessage) error {
+	headers, err := json.Marshal(message.Headers)
+	if err != nil {
+		return fmt.Errorf("failed to marshal outbox headers: %w", err)
+	}
+
+	query := `
+		INSERT INTO booking_outbox (topic, key, payload, headers)
+		VALUES ($1, $2, $3, $4)
+	`
+
+	if _, err := q.ExecContext(ctx, query, message.Topic, message.Key, []byte(message.Payload), headers); err != nil {
+		return fmt.Errorf("failed to store outbox message: %w", err)
+	}
+	return nil
+}
+
+// ClaimDue returns up to limit unsent messages that are due, oldest first.
+// A message waits while an older unsent message has the same key, so each
+// key is published in order. Claimed messages are not due again for lease,
+// which keeps other replicas off them and retries them if this one dies
+// before marking them.
+func (r *OutboxRepository) ClaimDue(ctx context.Context, limit int, lease time.Duration) ([]models.OutboxMessage, error) {
+	query := `
+		UPDATE booking_outbox
+		SET next_attempt_at = NOW() + make_interval(secs => $2)
+		WHERE id IN (
+			SELECT o.id FROM booking_outbox o
+			WHERE o.sent_at IS NULL
+			AND o.next_attempt_at <= NOW()
+			AND NOT EXISTS (
+				SELECT 1 FROM booking_outbox e
+				WHERE e.key = o.key AND e.sent_at IS NULL AND e.id < o.id
+			)
+			ORDER BY o.id
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING ` + outboxColumns
+
+	rows, err := r.db.QueryContext(ctx, query, limit, lease.Seconds())
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim outbox messages: %w", err)
+	}
+	return scanOutboxMessages(rows)
+}
+
+// MarkSent records that message was published.
+func (r *OutboxRepository) MarkSent(ctx context.Context, id int64) error {
+	query := `UPDATE booking_outbox SET sent_at = NOW(), last_error = NULL WHERE id = $1`
+
+	if _, err := r.db.ExecContext(ctx, query, id); err != nil {
+		return fmt.Errorf("failed to mark outbox message %d sent: %w", id, err)
+	}
+	return nil
+}
+
+// MarkFailed records a failed publish and when to try again.
+func (r *OutboxRepository) MarkFailed(ctx context.Context, id int64, publishErr error, nextAttemptAt time.Time) error {
+	query := `
+		UPDATE booking_outbox
+		SET attempts = attempts + 1, last_error = $2, next_attempt_at = $3
+		WHERE id = $1
+	`
+
+	if _, err := r.db.ExecContext(ctx, query, id, publishErr.Error(), nextAttemptAt); err != nil {
+		return fmt.Errorf("failed to mark outbox message %d failed: %w", id, err)
+	}
+	return nil
+}
+
+// ListStuck returns up to limit messages that are still unsent olderThan
+// after they were stored, oldest first.
+func (r *OutboxRepository) ListStuck(ctx context.Context, olderThan time.Duration, limit int) ([]models.OutboxMessage, error) {
+	query := `
+		SELECT ` + outboxColumns + `
+		FROM booking_outbox
+		WHERE sent_at IS NULL
+		AND created_at < NOW() - make_interval(secs => $1)
+		ORDER BY id
+		LIMIT $2
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, olderThan.Seconds(), limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list stuck outbox messages: %w", err)
+	}
+	return scanOutboxMessages(rows)
+}
+
+// PurgeSent deletes messages sent more than olderThan ago and returns how
+// many it deleted.
+func (r *OutboxRepository) PurgeSent(ctx context.Context, olderThan time.Duration) (int64, error) {
+	query := `DELETE FROM booking_outbox WHERE sent_at < NOW() - make_interval(secs => $1)`
+
+	result, err := r.db.ExecContext(ctx, query, olderThan.Seconds())
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge sent outbox messages: %w", err)
+	}
+	return result.RowsAffected()
+}
+
+const outboxColumns = `id, topic, key, payload, headers, attempts, COALESCE(last_error, ''), created_at, next_attempt_at`
+
+func scanOutboxMessages(rows *sql.Rows) ([]models.OutboxMessage, error) {
+	defer rows.Close()
+
+	messages := []models.OutboxMessage{}
+	for rows.Next() {
+		var message models.OutboxMessage
+		var payload, headers []byte
+		if err := rows.Scan(&message.ID, &message.Topic, &message.Key, &payload, &headers, &message.Attempts, &message.LastError, &message.CreatedAt, &message.NextAttemptAt); err != nil {
+			return nil, fmt.Errorf("failed to scan outbox message: %w", err)
+		}
+		message.Payload = payload
+		if err := json.Unmarshal(headers, &message.Headers); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal headers of outbox message %d: %w", message.ID, err)
+		}
+		messages = append(messages, message)
+	}
+	return messages, rows.Err()
+}
\ No newline at end of file
@@ -0,0 +1,135 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"booking/internal/models"
+
+	_ "github.com/lib/pq"
+)
+
+// openOutboxTestDB connects to TEST_DATABASE_URL, a PostgreSQL database
+// migrated with the booking-management migrate command, and skips the test
+// without one. The connection works on an empty copy of booking_outbox in a
+// schema of its own, so messages other tests leave unsent are not claimed.
+func openOutboxTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+	ctx := context.Background()
+
+	url := os.Getenv("TEST_DATABASE_URL")
+	if url == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+
+	db, err := sql.Open("postgres", url)
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+
+	if err := CheckSchemaVersion(ctx, db); err != nil {
+		t.Fatalf("test database is not usable: %v", err)
+	}
+
+	schema := fmt.Sprintf("outbox_test_%d", time.Now().UnixNano())
+	for _, statement := range []string{
+		`CREATE SCHEMA ` + schema,
+		`CREATE TABLE ` + schema + `.booking_outbox (LIKE booking_outbox INCLUDING ALL)`,
+		`SET search_path TO ` + schema,
+	} {
+		if _, err := db.ExecContext(ctx, statement); err != nil {
+			t.Fatalf("failed to set up outbox schema: %v", err)
+		}
+	}
+	t.Cleanup(func() { db.ExecContext(context.Background(), `DROP SCHEMA `+schema+` CASCADE`) })
+	return db
+}
+
+func enqueue(t *testing.T, repo *OutboxRepository, key string) {
+	t.Helper()
+	err := repo.Enqueue(context.Background(), models.OutboxMessage{Topic: "booking-events", Key: key, Payload: []byte(`{}`)})
+	if err != nil {
+		t.Fatalf("Enqueue failed: %v", err)
+	}
+}
+
+func claimedKeys(messages []models.OutboxMessage) []string {
+	keys := []string{}
+	for _, message := range messages {
+		keys = append(keys, message.Key)
+	}
+	return keys
+}
+
+func TestClaimDueKeepsKeysInOrder(t *testing.T) {
+	db := openOutboxTestDB(t)
+	repo := NewOutboxRepository(db)
+	ctx := context.Background()
+
+	enqueue(t, repo, "booking_1")
+	enqueue(t, repo, "booking_2")
+	enqueue(t, repo, "booking_1")
+
+	// The second booking_1 message waits for the first
+	first, err := repo.ClaimDue(ctx, 10, time.Minute)
+	if err != nil {
+		t.Fatalf("ClaimDue failed: %v", err)
+	}
+	if keys := claimedKeys(first); fmt.Sprint(keys) != "[booking_1 booking_2]" {
+		t.Fatalf("claimed %v, want [booking_1 booking_2]", keys)
+	}
+
+	// Claimed messages are leased, and the waiting one is still blocked
+	if again, err := repo.ClaimDue(ctx, 10, time.Minute); err != nil || len(again) != 0 {
+		t.Fatalf("ClaimDue during the lease = %v, %v, want nothing", claimedKeys(again), err)
+	}
+
+	// A failed publish keeps blocking its key until it is sent
+	if err := repo.MarkFailed(ctx, first[0].ID, fmt.Errorf("broker down"), time.Now().Add(-time.Second)); err != nil {
+		t.Fatalf("MarkFailed failed: %v", err)
+	}
+	retried, err := repo.ClaimDue(ctx, 10, time.Minute)
+	if err != nil {
+		t.Fatalf("ClaimDue failed: %v", err)
+	}
+	if len(retried) != 1 || retried[0].ID != first[0].ID || retried[0].Attempts != 1 || retried[0].LastError != "broker down" {
+		t.Fatalf("claimed %+v, want the failed booking_1 message again", retried)
+	}
+
+	if err := repo.MarkSent(ctx, first[0].ID); err != nil {
+		t.Fatalf("MarkSent failed: %v", err)
+	}
+	next, err := repo.ClaimDue(ctx, 10, time.Minute)
+	if err != nil {
+		t.Fatalf("ClaimDue failed: %v", err)
+	}
+	if len(next) != 1 || next[0].Key != "booking_1" || next[0].ID <= first[0].ID {
+		t.Errorf("claimed %+v, want the second booking_1 message", next)
+	}
+}
+
+func TestListStuck(t *testing.T) {
+	db := openOutboxTestDB(t)
+	repo := NewOutboxRepository(db)
+	ctx := context.Background()
+
+	enqueue(t, repo, "booking_old")
+	enqueue(t, repo, "booking_new")
+	if _, err := db.ExecContext(ctx, `UPDATE booking_outbox SET created_at = NOW() - INTERVAL '1 hour' WHERE key = 'booking_old'`); err != nil {
+		t.Fatalf("failed to backdate message: %v", err)
+	}
+
+	stuck, err := repo.ListStuck(ctx, time.Minute, 10)
+	if err != nil {
+		t.Fatalf("ListStuck failed: %v", err)
+	}
+	if keys := claimedKeys(stuck); fmt.Sprint(keys) != "[booking_old]" {
+		t.Errorf("stuck messages %v, want [booking_old]", keys)
+	}
+}
@@ -0,0 +1,149 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"booking/internal/models"
+)
+
+// SagaRepository stores the state of booking sagas in the booking_sagas
+// table, which the booking service owns. The table is created by the
+// booking-management migrations.
+type SagaRepository struct {
+	db *sql.DB
+}
+
+func NewSagaRepository(db *sql.DB) *SagaRepository {
+	return &SagaRepository{db: db}
+}
+
+func (r *SagaRepository) Create(ctx context.Context, saga *models.BookingSaga) error {
+	event, err := json.Marshal(saga.Event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal saga event: %w", err)
+	}
+
+	query := `
+		INSERT INTO booking_sagas (booking_id, step, status, event)
+		VALUES ($1, $2, $3, $4)
+	`
+
+	if _, err := r.db.ExecContext(ctx, query, saga.BookingID, saga.Step, saga.Status, event); err != nil {
+		return fmt.Errorf("failed to create saga: %w", err)
+	}
+	return nil
+}
+
+// Get returns the saga of a booking, or nil if there is none.
+func (r *SagaRepository) Get(ctx context.Context, bookingID string) (*models.BookingSaga, error) {
+	query := `
+		SELECT booking_id, step, status, event, COALESCE(error, ''), manual_refund, created_at, updated_at
+		FROM booking_sagas
+		WHERE booking_id = $1
+	`
+
+	var saga models.BookingSaga
+	var event []byte
+	err := r.db.QueryRowContext(ctx, query, bookingID).Scan(&saga.BookingID, &saga.Step, &saga.Status, &event, &saga.Error, &saga.ManualRefund, &saga.CreatedAt, &saga.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get saga: %w", err)
+	}
+	if err := json.Unmarshal(event, &saga.Event); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal event of saga %s: %w", saga.BookingID, err)
+	}
+	return &saga, nil
+}
+
+// Update records the step and status of a saga, the error that made it
+// compensate if there is one, and whether its payment must be refunded
+// manually.
+func (r *SagaRepository) Update(ctx context.Context, saga *models.BookingSaga) error {
+	query := `
+		UPDATE booking_sagas
+		SET step = $2, status = $3, error = NULLIF($4, ''), manual_refund = $5, updated_at = NOW()
+		WHERE booking_id = $1
+	`
+
+	if _, err := r.db.ExecContext(ctx, query, saga.BookingID, saga.Step, saga.Status, saga.Error, saga.ManualRefund); err != nil {
+		return fmt.Errorf("failed to update saga: %w", err)
+	}
+	return nil
+}
+
+// Complete marks saga completed and stores message in the outbox in one
+// transaction, so the booking event is published exactly when the saga
+// completes.
+func (r *SagaRepository) Complete(ctx context.Context, saga *models.BookingSaga, message models.OutboxMessage) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := insertOutboxMessage(ctx, tx, message); err != nil {
+		return err
+	}
+
+	query := `
+		UPDATE booking_sagas
+		SET status = $2, error = NULL, updated_at = NOW()
+		WHERE booking_id = $1
+	`
+	if _, err := tx.ExecContext(ctx, query, saga.BookingID, models.SagaCompleted); err != nil {
+		return fmt.Errorf("failed to complete saga: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit saga completion: %w", err)
+	}
+	saga.Status = models.SagaCompleted
+	saga.Error = ""
+	return nil
+}
+
+// ClaimStale returns up to limit unfinished sagas that have not moved for
+// staleAfter, typically because the replica running them died. Claiming a
+// saga touches it, so other replicas leave it alone while it is recovered.
+func (r *SagaRepository) ClaimStale(ctx context.Context, staleAfter time.Duration, limit int) ([]models.BookingSaga, error) {
+	query := `
+		UPDATE booking_sagas
+		SET updated_at = NOW()
+		WHERE booking_id IN (
+			SELECT booking_id FROM booking_sagas
+			WHERE status IN ('running', 'compensating')
+			AND updated_at < NOW() - make_interval(secs => $1)
+			ORDER BY updated_at
+			LIMIT $2
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING booking_id, step, status, event, COALESCE(error, ''), manual_refund, created_at, updated_at
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, staleAfter.Seconds(), limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim stale sagas: %w", err)
+	}
+	defer rows.Close()
+
+	var sagas []models.BookingSaga
+	for rows.Next() {
+		var saga models.BookingSaga
+		var event []byte
+		if err := rows.Scan(&saga.BookingID, &saga.Step, &saga.Status, &event, &saga.Error, &saga.ManualRefund, &saga.CreatedAt, &saga.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan saga: %w", err)
+		}
+		if err := json.Unmarshal(event, &saga.Event); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal event of saga %s: %w", saga.BookingID, err)
+		}
+		sagas = append(sagas, saga)
+	}
+	return sagas, rows.Err()
+}
@@ -1,25 +1,40 @@
 package router
 
 import (
+	"net/http"
+	"time"
+
 	"booking/internal/client"
 	"booking/internal/handlers"
-	"booking/internal/kafka"
+	"booking/internal/metrics"
 	"booking/internal/middleware"
+	"booking/internal/repository"
+	"booking/internal/saga"
+	"booking/internal/store"
+	"booking/internal/tracing"
 
 	"github.com/gorilla/mux"
 )
 
-func NewRouter(paymentClient *client.PaymentClient, kafkaClient *kafka.Client, bookingManagementClient *client.BookingManagementClient) *mux.Router {
+func NewRouter(sagas *saga.Orchestrator, outbox *repository.OutboxRepository, bookingManagementClient *client.BookingManagementClient, cancellations *store.CancellationStore, outboxStuckAfter time.Duration, idempotent mux.MiddlewareFunc) *mux.Router {
 	router := mux.NewRouter()
 
+	router.Use(tracing.Middleware)
+	router.Use(metrics.Middleware)
 	router.Use(middleware.BaggageMiddleware)
+	router.Use(middleware.IdentityMiddleware)
 
 	healthHandler := handlers.NewHealthHandler()
-	bookingHandler := handlers.NewBookingHandler(paymentClient, kafkaClient, bookingManagementClient)
+	bookingHandler := handlers.NewBookingHandler(sagas, outbox, bookingManagementClient, cancellations)
+	outboxHandler := handlers.NewOutboxHandler(outbox, outboxStuckAfter)
 
 	router.HandleFunc("/health", healthHandler.Health).Methods("GET")
-	router.HandleFunc("/book", bookingHandler.Book).Methods("POST")
-	router.HandleFunc("/cancel", bookingHandler.Cancel).Methods("POST")
+	router.Handle("/metrics", metrics.Handler()).Methods("GET")
+	router.Handle("/book", idempotent(http.HandlerFunc(bookingHandler.Book))).Methods("POST")
+	router.HandleFunc("/status/{bookingId}", bookingHandler.Status).Methods("GET")
+	router.Handle("/cancel", idempotent(http.HandlerFunc(bookingHandler.Cancel))).Methods("POST")
+	router.HandleFunc("/cancellations/{bookingId}", bookingHandler.GetCancellation).Methods("GET")
+	router.HandleFunc("/admin/outbox/stuck", outboxHandler.Stuck).Methods("GET")
 
 	return router
 }
\ No newline at end of file
@@ -0,0 +1,310 @@
+package saga
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"booking/internal/client"
+	"booking/internal/kafka"
+	"booking/internal/logger"
+	"booking/internal/models"
+	"booking/internal/repository"
+)
+
+// releaseReason is recorded as the refusal reason of a compensated booking.
+const releaseReason = "Booking could not be completed"
+
+// recoveryBatchSize bounds how many stale sagas one recovery pass claims.
+const recoveryBatchSize = 20
+
+var errInterrupted = errors.New("saga interrupted")
+
+// StepError reports the step at which a booking saga stopped. Reasons is
+// set when the step rejected the booking, e.g. an unavailable room or a
+// declined card, and Err when the step could not be run.
+type StepError struct {
+	Step    string
+	Reasons []string
+	Err     error
+}
+
+func (e *StepError) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("%s step failed: %v", e.Step, e.Err)
+	}
+	return fmt.Sprintf("%s step rejected the booking: %v", e.Step, e.Reasons)
+}
+
+func (e *StepError) Unwrap() error {
+	return e.Err
+}
+
+// Orchestrator runs booking sagas: validate the booking, reserve the room,
+// charge the payment and publish the booking event through the outbox. Once the room is
+// reserved every step is persisted first, so a saga interrupted by a crash
+// is resumed or compensated by Watch.
+type Orchestrator struct {
+	repo              *repository.SagaRepository
+	bookingManagement *client.BookingManagementClient
+	payments          *client.PaymentClient
+}
+
+func NewOrchestrator(repo *repository.SagaRepository, bookingManagement *client.BookingManagementClient, payments *client.PaymentClient) *Orchestrator {
+	return &Orchestrator{
+		repo:              repo,
+		bookingManagement: bookingManagement,
+		payments:          payments,
+	}
+}
+
+// Get returns the saga of a booking, or nil if the booking was not made
+// through the booking service.
+func (o *Orchestrator) Get(ctx context.Context, bookingID string) (*models.BookingSaga, error) {
+	return o.repo.Get(ctx, bookingID)
+}
+
+// Run books event, charging cardNumber. On failure the steps already taken
+// are compensated and a *StepError is returned. The saga keeps running if
+// the caller goes away, so it never stops halfway.
+func (o *Orchestrator) Run(ctx context.Context, event models.BookingEvent, cardNumber string) error {
+	ctx = context.WithoutCancel(ctx)
+
+	validationResp, err := o.bookingManagement.ValidateBooking(ctx, models.BookingValidationRequest{
+		RoomID:         event.RoomID,
+		NumberOfGuests: event.Guests,
+		StartDate:      event.StartDate,
+		EndDate:        event.EndDate,
+	})
+	if err != nil {
+		return &StepError{Step: models.SagaStepValidate, Err: err}
+	}
+	if !validationResp.IsValid {
+		return &StepError{Step: models.SagaStepValidate, Reasons: validationResp.Reasons}
+	}
+
+	saga := &models.BookingSaga{
+		BookingID: event.BookingID,
+		Step:      models.SagaStepReserve,
+		Status:    models.SagaRunning,
+		Event:     event,
+	}
+	if err := o.repo.Create(ctx, saga); err != nil {
+		return &StepError{Step: models.SagaStepReserve, Err: err}
+	}
+
+	reasons, err := o.bookingManagement.Reserve(ctx, models.ReservationRequest{
+		ExternalID:     event.BookingID,
+		UserID:         event.UserID,
+		RoomID:         event.RoomID,
+		NumberOfGuests: event.Guests,
+		StartDate:      event.StartDate,
+		EndDate:        event.EndDate,
+		PaymentID:      event.PaymentID,
+	})
+	if err != nil {
+		// The room may have been reserved before the call failed
+		o.compensate(ctx, saga, models.SagaStepRelease, err)
+		return &StepError{Step: models.SagaStepReserve, Err: err}
+	}
+	if len(reasons) > 0 {
+		saga.Status = models.SagaCompensated
+		saga.Error = fmt.Sprintf("reservation rejected: %v", reasons)
+		o.save(ctx, saga)
+		return &StepError{Step: models.SagaStepReserve, Reasons: reasons}
+	}
+
+	if err := o.advance(ctx, saga, models.SagaStepCharge); err != nil {
+		// Nothing was charged yet, so this is still a reservation failure
+		o.compensate(ctx, saga, models.SagaStepRelease, err)
+		return &StepError{Step: models.SagaStepReserve, Err: err}
+	}
+
+	paymentResp, err := o.payments.ProcessPayment(ctx, models.PaymentRequest{
+		PaymentID:        event.PaymentID,
+		CreditCardNumber: cardNumber,
+	})
+	if err != nil {
+		// A rejected payment charged nothing; any other failure may have
+		// charged the card before the response was lost, so the payment
+		// service is asked
+		from := models.SagaStepCheckCharge
+		if errors.Is(err, client.ErrPaymentRejected) {
+			from = models.SagaStepRelease
+		}
+		o.compensate(ctx, saga, from, err)
+		return &StepError{Step: models.SagaStepCharge, Err: err}
+	}
+	if !paymentResp.Success {
+		o.compensate(ctx, saga, models.SagaStepRelease, fmt.Errorf("payment failed: %s", paymentResp.Message))
+		return &StepError{Step: models.SagaStepCharge, Reasons: []string{paymentResp.Message}}
+	}
+
+	if err := o.advance(ctx, saga, models.SagaStepPublish); err != nil {
+		o.compensate(ctx, saga, models.SagaStepRefund, err)
+		return &StepError{Step: models.SagaStepPublish, Err: err}
+	}
+
+	if err := o.publish(ctx, saga); err != nil {
+		return &StepError{Step: models.SagaStepPublish, Err: err}
+	}
+	return nil
+}
+
+// publish stores the booking event in the outbox and completes the saga in
+// one transaction, or compensates the saga if that fails. The outbox relay
+// sends the event to Kafka.
+func (o *Orchestrator) publish(ctx context.Context, saga *models.BookingSaga) error {
+	message, err := kafka.NewMessage(ctx, "booking-events", saga.BookingID, saga.Event)
+	if err == nil {
+		err = o.repo.Complete(ctx, saga, message)
+	}
+	if err != nil {
+		o.compensate(ctx, saga, models.SagaStepRefund, err)
+		return err
+	}
+
+	logger.Info(ctx, "Booking saga completed", "bookingId", saga.BookingID)
+	return nil
+}
+
+// advance persists that saga is about to run step. A step is never run
+// without its state being stored first.
+func (o *Orchestrator) advance(ctx context.Context, saga *models.BookingSaga, step string) error {
+	saga.Step = step
+	if err := o.repo.Update(ctx, saga); err != nil {
+		logger.Error(ctx, "Failed to persist booking saga step", "bookingId", saga.BookingID, "step", step, "error", err)
+		return err
+	}
+	return nil
+}
+
+// save persists saga after a step finished. A failure is only logged: the
+// saga is then found stale and resumed from the stored step, and every step
+// can be repeated.
+func (o *Orchestrator) save(ctx context.Context, saga *models.BookingSaga) {
+	if err := o.repo.Update(ctx, saga); err != nil {
+		logger.Error(ctx, "Failed to persist booking saga", "bookingId", saga.BookingID, "step", saga.Step, "status", saga.Status, "error", err)
+	}
+}
+
+// compensate switches saga to compensation starting at step, the charge
+// check, the refund or the release, because of cause. If a compensation cannot run now, Watch
+// retries it later.
+func (o *Orchestrator) compensate(ctx context.Context, saga *models.BookingSaga, step string, cause error) {
+	logger.Warn(ctx, "Compensating booking saga", "bookingId", saga.BookingID, "failedStep", saga.Step, "error", cause)
+
+	saga.Status = models.SagaCompensating
+	saga.Step = step
+	saga.Error = cause.Error()
+	o.save(ctx, saga)
+
+	if err := o.runCompensation(ctx, saga); err != nil {
+		logger.Error(ctx, "Booking saga compensation incomplete", "bookingId", saga.BookingID, "step", saga.Step, "error", err)
+	}
+}
+
+func (o *Orchestrator) runCompensation(ctx context.Context, saga *models.BookingSaga) error {
+	if saga.Step == models.SagaStepCheckCharge {
+		payment, err := o.payments.GetPayment(ctx, saga.Event.PaymentID)
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case payment == nil, payment.Status == models.PaymentDeclined, payment.Status == models.PaymentFailed, payment.Status == models.PaymentRefunded:
+			// Nothing was charged, or it was already given back
+			saga.Step = models.SagaStepRelease
+		case payment.Status == models.PaymentProcessing:
+			// Watch looks again once the payment service has decided
+			return fmt.Errorf("payment %s is still being processed", saga.Event.PaymentID)
+		default:
+			saga.Step = models.SagaStepRefund
+		}
+		o.save(ctx, saga)
+	}
+
+	if saga.Step == models.SagaStepRefund {
+		_, err := o.payments.Refund(ctx, models.RefundRequest{
+			PaymentID: saga.Event.PaymentID,
+			Reason:    releaseReason,
+		})
+		if errors.Is(err, client.ErrPaymentRejected) {
+			// Retrying cannot help; still give the room back, and only then
+			// mark the saga failed, so Watch retries a failed release
+			logger.Error(ctx, "Refund rejected, the payment must be refunded manually", "bookingId", saga.BookingID, "paymentId", saga.Event.PaymentID, "error", err)
+			saga.ManualRefund = true
+			saga.Error = err.Error()
+		} else if err != nil {
+			return err
+		}
+
+		saga.Step = models.SagaStepRelease
+		o.save(ctx, saga)
+	}
+
+	if err := o.bookingManagement.Release(ctx, saga.BookingID, releaseReason); err != nil {
+		return err
+	}
+
+	if saga.ManualRefund {
+		saga.Status = models.SagaFailed
+		o.save(ctx, saga)
+		logger.Warn(ctx, "Booking saga failed, room released but payment not refunded", "bookingId", saga.BookingID, "paymentId", saga.Event.PaymentID)
+		return nil
+	}
+	saga.Status = models.SagaCompensated
+	o.save(ctx, saga)
+	logger.Info(ctx, "Booking saga compensated", "bookingId", saga.BookingID)
+	return nil
+}
+
+// Recover resumes or compensates the sagas that stopped moving for
+// staleAfter. A saga interrupted before its payment was confirmed is
+// compensated, since the card number is not stored, refunding the payment
+// only if the payment service charged it; one interrupted while publishing
+// is published.
+func (o *Orchestrator) Recover(ctx context.Context, staleAfter time.Duration) error {
+	sagas, err := o.repo.ClaimStale(ctx, staleAfter, recoveryBatchSize)
+	if err != nil {
+		return err
+	}
+
+	for i := range sagas {
+		saga := &sagas[i]
+		logger.Info(ctx, "Recovering booking saga", "bookingId", saga.BookingID, "step", saga.Step, "status", saga.Status)
+
+		switch {
+		case saga.Status == models.SagaCompensating:
+			if err := o.runCompensation(ctx, saga); err != nil {
+				logger.Error(ctx, "Booking saga compensation incomplete", "bookingId", saga.BookingID, "step", saga.Step, "error", err)
+			}
+		case saga.Step == models.SagaStepReserve:
+			o.compensate(ctx, saga, models.SagaStepRelease, errInterrupted)
+		case saga.Step == models.SagaStepCharge:
+			o.compensate(ctx, saga, models.SagaStepCheckCharge, errInterrupted)
+		case saga.Step == models.SagaStepPublish:
+			o.publish(ctx, saga)
+		}
+	}
+	return nil
+}
+
+// Watch runs Recover now and then every interval until ctx is done.
+func (o *Orchestrator) Watch(ctx context.Context, interval, staleAfter time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := o.Recover(ctx, staleAfter); err != nil && ctx.Err() == nil {
+			logger.Warn(ctx, "Failed to recover booking sagas", "error", err)
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
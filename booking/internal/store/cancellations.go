@@ -0,0 +1,70 @@
+package store
+
+import (
+	"sync"
+
+	"booking/internal/models"
+)
+
+// DefaultCancellationResultsLimit bounds how many results are kept in memory.
+const DefaultCancellationResultsLimit = 10000
+
+// CancellationStore keeps the latest cancellation result of each booking in
+// memory. Once full, the booking that was added first is forgotten.
+type CancellationStore struct {
+	mu      sync.RWMutex
+	limit   int
+	results map[string]models.CancellationResult
+	order   []string
+}
+
+func NewCancellationStore(limit int) *CancellationStore {
+	if limit <= 0 {
+		limit = DefaultCancellationResultsLimit
+	}
+	return &CancellationStore{
+		limit:   limit,
+		results: make(map[string]models.CancellationResult),
+	}
+}
+
+// Put records result as the latest result for its booking.
+func (s *CancellationStore) Put(result models.CancellationResult) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.results[result.BookingID]; !ok {
+		if len(s.order) >= s.limit {
+			delete(s.results, s.order[0])
+			s.order = s.order[1:]
+		}
+		s.order = append(s.order, result.BookingID)
+	}
+	s.results[result.BookingID] = result
+}
+
+func (s *CancellationStore) Get(bookingID string) (models.CancellationResult, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	result, ok := s.results[bookingID]
+	return result, ok
+}
+
+// Delete forgets the result of bookingID, so a new cancellation request is
+// reported as pending until its own result arrives.
+func (s *CancellationStore) Delete(bookingID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.results[bookingID]; !ok {
+		return
+	}
+	delete(s.results, bookingID)
+	for i, id := range s.order {
+		if id == bookingID {
+			s.order = append(s.order[:i], s.order[i+1:]...)
+			break
+		}
+	}
+}
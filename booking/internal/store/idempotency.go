@@ -0,0 +1,150 @@
+package store
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// DefaultIdempotencyKeysLimit bounds how many keys are kept in memory.
+const DefaultIdempotencyKeysLimit = 10000
+
+// IdempotentResponse is a response stored for replay.
+type IdempotentResponse struct {
+	Status int
+	Header http.Header
+	Body   []byte
+}
+
+// IdempotencyEntry is what a store knows about a key. Response is nil while
+// the request that reserved the key is still running.
+type IdempotencyEntry struct {
+	Fingerprint string
+	Response    *IdempotentResponse
+}
+
+// IdempotencyStore keeps the responses of requests made with an
+// Idempotency-Key. Reserve must be atomic, so that only one request runs per
+// key even across replicas sharing the store.
+type IdempotencyStore interface {
+	// Reserve claims key for ttl for a request whose body has fingerprint.
+	// It returns nil if the key was free, or the entry already stored for it.
+	// A store with no room for the key returns ErrIdempotencyKeysFull.
+	Reserve(ctx context.Context, key, fingerprint string, ttl time.Duration) (*IdempotencyEntry, error)
+	// Complete stores the response of the request that reserved key for ttl.
+	Complete(ctx context.Context, key string, response IdempotentResponse, ttl time.Duration) error
+	// Release forgets key, so the request can be made again.
+	Release(ctx context.Context, key string) error
+}
+
+// ErrIdempotencyKeysFull is returned by Reserve when every key kept is held
+// by a request that is still running, so none can make room for a new one.
+var ErrIdempotencyKeysFull = errors.New("too many idempotent requests in progress")
+
+type memoryIdempotencyEntry struct {
+	IdempotencyEntry
+	key       string
+	expiresAt time.Time
+}
+
+// MemoryIdempotencyStore is an IdempotencyStore for a single replica. Once
+// full, expired keys are forgotten first, then the completed key that was
+// reserved first. Keys of requests still running are never forgotten, so
+// their duplicates keep waiting instead of running again.
+type MemoryIdempotencyStore struct {
+	mu      sync.Mutex
+	limit   int
+	entries map[string]*list.Element
+	// order holds the *memoryIdempotencyEntry values, oldest reservation first
+	order *list.List
+}
+
+func NewMemoryIdempotencyStore(limit int) *MemoryIdempotencyStore {
+	if limit <= 0 {
+		limit = DefaultIdempotencyKeysLimit
+	}
+	return &MemoryIdempotencyStore{
+		limit:   limit,
+		entries: make(map[string]*list.Element),
+		order:   list.New(),
+	}
+}
+
+func (s *MemoryIdempotencyStore) Reserve(ctx context.Context, key, fingerprint string, ttl time.Duration) (*IdempotencyEntry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	if element, ok := s.entries[key]; ok {
+		entry := element.Value.(*memoryIdempotencyEntry)
+		if now.Before(entry.expiresAt) {
+			existing := entry.IdempotencyEntry
+			return &existing, nil
+		}
+		s.remove(element)
+	}
+
+	if len(s.entries) >= s.limit && !s.evict(now) {
+		return nil, ErrIdempotencyKeysFull
+	}
+	s.entries[key] = s.order.PushBack(&memoryIdempotencyEntry{
+		IdempotencyEntry: IdempotencyEntry{Fingerprint: fingerprint},
+		key:              key,
+		expiresAt:        now.Add(ttl),
+	})
+	return nil, nil
+}
+
+func (s *MemoryIdempotencyStore) Complete(ctx context.Context, key string, response IdempotentResponse, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// The key may have expired and been evicted meanwhile; there is nothing
+	// to replay then
+	if element, ok := s.entries[key]; ok {
+		entry := element.Value.(*memoryIdempotencyEntry)
+		entry.Response = &response
+		entry.expiresAt = time.Now().Add(ttl)
+	}
+	return nil
+}
+
+func (s *MemoryIdempotencyStore) Release(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if element, ok := s.entries[key]; ok {
+		s.remove(element)
+	}
+	return nil
+}
+
+// evict forgets one key to make room: the oldest expired one, or else the
+// oldest completed one. It reports false if every key is held by a running
+// request.
+func (s *MemoryIdempotencyStore) evict(now time.Time) bool {
+	var completed *list.Element
+	for element := s.order.Front(); element != nil; element = element.Next() {
+		entry := element.Value.(*memoryIdempotencyEntry)
+		if !now.Before(entry.expiresAt) {
+			s.remove(element)
+			return true
+		}
+		if completed == nil && entry.Response != nil {
+			completed = element
+		}
+	}
+	if completed == nil {
+		return false
+	}
+	s.remove(completed)
+	return true
+}
+
+func (s *MemoryIdempotencyStore) remove(element *list.Element) {
+	entry := s.order.Remove(element).(*memoryIdempotencyEntry)
+	delete(s.entries, entry.key)
+}
\ No newline at end of file
@@ -0,0 +1,71 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestMemoryIdempotencyStoreReserve(t *testing.T) {
+	keys := NewMemoryIdempotencyStore(0)
+	ctx := context.Background()
+
+	if entry, err := keys.Reserve(ctx, "key", "body", time.Minute); entry != nil || err != nil {
+		t.Fatalf("Reserve of a free key = %+v, %v, want nil", entry, err)
+	}
+	entry, err := keys.Reserve(ctx, "key", "other", time.Minute)
+	if err != nil || entry == nil || entry.Fingerprint != "body" || entry.Response != nil {
+		t.Fatalf("Reserve of a running key = %+v, %v, want its entry without a response", entry, err)
+	}
+
+	keys.Complete(ctx, "key", IdempotentResponse{Status: 201, Body: []byte("created")}, time.Minute)
+	entry, err = keys.Reserve(ctx, "key", "body", time.Minute)
+	if err != nil || entry == nil || entry.Response == nil || entry.Response.Status != 201 {
+		t.Fatalf("Reserve of a completed key = %+v, %v, want its response", entry, err)
+	}
+
+	keys.Release(ctx, "key")
+	if entry, err := keys.Reserve(ctx, "key", "body", time.Minute); entry != nil || err != nil {
+		t.Errorf("Reserve of a released key = %+v, %v, want nil", entry, err)
+	}
+}
+
+func TestMemoryIdempotencyStoreExpiry(t *testing.T) {
+	keys := NewMemoryIdempotencyStore(0)
+	ctx := context.Background()
+
+	keys.Reserve(ctx, "key", "body", -time.Second)
+	if entry, err := keys.Reserve(ctx, "key", "other", time.Minute); entry != nil || err != nil {
+		t.Errorf("Reserve of an expired key = %+v, %v, want nil", entry, err)
+	}
+}
+
+func TestMemoryIdempotencyStoreEviction(t *testing.T) {
+	keys := NewMemoryIdempotencyStore(3)
+	ctx := context.Background()
+
+	keys.Reserve(ctx, "running", "body", time.Minute)
+	keys.Reserve(ctx, "completed", "body", time.Minute)
+	keys.Complete(ctx, "completed", IdempotentResponse{Status: 201}, time.Minute)
+	keys.Reserve(ctx, "expired", "body", -time.Second)
+
+	// The expired key goes first, even though it was reserved last
+	if _, err := keys.Reserve(ctx, "new-1", "body", time.Minute); err != nil {
+		t.Fatalf("Reserve failed: %v", err)
+	}
+	if entry, _ := keys.Reserve(ctx, "completed", "body", time.Minute); entry == nil {
+		t.Fatal("completed key was evicted before the expired one")
+	}
+
+	// Then the oldest completed key, and never the running one
+	if _, err := keys.Reserve(ctx, "new-2", "body", time.Minute); err != nil {
+		t.Fatalf("Reserve failed: %v", err)
+	}
+	if entry, _ := keys.Reserve(ctx, "running", "other", time.Minute); entry == nil || entry.Fingerprint != "body" {
+		t.Fatal("running key was evicted")
+	}
+	if _, err := keys.Reserve(ctx, "new-3", "body", time.Minute); !errors.Is(err, ErrIdempotencyKeysFull) {
+		t.Errorf("Reserve with every key running = %v, want ErrIdempotencyKeysFull", err)
+	}
+}
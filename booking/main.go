@@ -9,9 +9,17 @@ import (
 
 	"booking/internal/client"
 	"booking/internal/config"
+	"booking/internal/database"
+	"booking/internal/idempotency"
 	"booking/internal/kafka"
 	"booking/internal/logger"
+	"booking/internal/models"
+	"booking/internal/outbox"
+	"booking/internal/repository"
 	"booking/internal/router"
+	"booking/internal/saga"
+	"booking/internal/store"
+	"booking/internal/tracing"
 )
 
 func main() {
@@ -20,6 +28,18 @@ func main() {
 
 	logger.Info(ctx, "Starting booking service", "port", cfg.Port)
 
+	// Initialize tracing
+	shutdownTracing, err := tracing.Init(ctx, tracing.Options{
+		ServiceName:  "booking",
+		OTLPEndpoint: cfg.OTLPEndpoint,
+		File:         cfg.TracesFile,
+	})
+	if err != nil {
+		logger.Error(ctx, "Failed to initialize tracing", "error", err)
+		log.Fatal(err)
+	}
+	defer shutdownTracing(context.Background())
+
 	// Initialize Kafka client
 	kafkaClient, err := kafka.NewClient(cfg.KafkaBrokers)
 	if err != nil {
@@ -28,13 +48,65 @@ func main() {
 	}
 	defer kafkaClient.Close()
 
+	// Keep the cancellation results published by the worker
+	cancellations := store.NewCancellationStore(store.DefaultCancellationResultsLimit)
+	resultConsumer, err := kafka.NewCancellationResultConsumer(cfg.KafkaBrokers, func(ctx context.Context, result models.CancellationResult) {
+		cancellations.Put(result)
+	})
+	if err != nil {
+		logger.Error(ctx, "Failed to create cancellation result consumer", "error", err)
+		log.Fatal(err)
+	}
+	defer resultConsumer.Close()
+
+	go func() {
+		if err := resultConsumer.Start(ctx); err != nil {
+			logger.Error(ctx, "Cancellation result consumer failed", "error", err)
+		}
+	}()
+
 	// Initialize payment client
 	paymentClient := client.NewPaymentClient(cfg.PaymentServiceURL)
 
 	// Initialize booking management client
 	bookingManagementClient := client.NewBookingManagementClient(cfg.BookingManagementServiceURL)
 
-	r := router.NewRouter(paymentClient, kafkaClient, bookingManagementClient)
+	// Initialize database connection for the booking sagas
+	db, err := database.NewConnection(cfg)
+	if err != nil {
+		logger.Error(ctx, "Failed to connect to database", "error", err)
+		log.Fatal(err)
+	}
+	defer db.Close()
+
+	// The saga and outbox tables are created by the booking-management
+	// migrations; refuse to run against a schema without them
+	if err := repository.CheckSchemaVersion(ctx, db.DB); err != nil {
+		logger.Error(ctx, "Unexpected database schema version", "error", err)
+		log.Fatal(err)
+	}
+
+	sagaRepo := repository.NewSagaRepository(db.DB)
+	outboxRepo := repository.NewOutboxRepository(db.DB)
+
+	// Publish the events stored in the outbox
+	relay := outbox.NewRelay(outboxRepo, kafkaClient, outbox.Options{
+		PollInterval: cfg.OutboxPollInterval,
+		BaseDelay:    cfg.OutboxRetryBaseDelay,
+		MaxDelay:     cfg.OutboxRetryMaxDelay,
+		Retention:    cfg.OutboxRetention,
+	})
+	go relay.Run(ctx)
+
+	// Resume or compensate sagas interrupted by a restart
+	sagas := saga.NewOrchestrator(sagaRepo, bookingManagementClient, paymentClient)
+	go sagas.Watch(ctx, cfg.SagaRecoveryInterval, cfg.SagaStaleAfter)
+
+	// Replay the responses of retried bookings and cancellations
+	idempotencyKeys := store.NewMemoryIdempotencyStore(store.DefaultIdempotencyKeysLimit)
+	idempotent := idempotency.Middleware(idempotencyKeys, cfg.IdempotencyKeyTTL)
+
+	r := router.NewRouter(sagas, outboxRepo, bookingManagementClient, cancellations, cfg.OutboxStuckAfter, idempotent)
 
 	server := &http.Server{
 		Addr:         fmt.Sprintf(":%s", cfg.Port),
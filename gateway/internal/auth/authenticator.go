@@ -0,0 +1,165 @@
+package auth
+
+import (
+	"context"
+	"crypto/rsa"
+	"errors"
+	"fmt"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var (
+	ErrMissingToken = errors.New("missing bearer token")
+	ErrInvalidToken = errors.New("invalid token")
+)
+
+// Principal is the authenticated caller of a request.
+type Principal struct {
+	Subject string
+	Role    Role
+}
+
+type principalKey struct{}
+
+func WithPrincipal(ctx context.Context, principal *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, principal)
+}
+
+func GetPrincipalFromContext(ctx context.Context) *Principal {
+	if principal, ok := ctx.Value(principalKey{}).(*Principal); ok {
+		return principal
+	}
+	return nil
+}
+
+type Options struct {
+	HS256Secret string
+	JWKSFile    string
+	Issuer      string
+	Audience    string
+	RolesClaim  string
+	RoleMapping string
+}
+
+// Authenticator validates HS256 tokens signed with a shared secret and
+// RS256 tokens signed with a key from a local JWKS file.
+type Authenticator struct {
+	hmacSecret  []byte
+	rsaKeys     map[string]*rsa.PublicKey
+	rolesClaim  string
+	roleMapping map[string]Role
+	parser      *jwt.Parser
+}
+
+// NewAuthenticator returns nil when neither an HS256 secret nor a JWKS file
+// is configured, meaning authentication is disabled.
+func NewAuthenticator(opts Options) (*Authenticator, error) {
+	if opts.HS256Secret == "" && opts.JWKSFile == "" {
+		return nil, nil
+	}
+
+	a := &Authenticator{rolesClaim: opts.RolesClaim}
+
+	var methods []string
+	if opts.HS256Secret != "" {
+		a.hmacSecret = []byte(opts.HS256Secret)
+		methods = append(methods, jwt.SigningMethodHS256.Alg())
+	}
+	if opts.JWKSFile != "" {
+		keys, err := loadJWKS(opts.JWKSFile)
+		if err != nil {
+			return nil, err
+		}
+		a.rsaKeys = keys
+		methods = append(methods, jwt.SigningMethodRS256.Alg())
+	}
+
+	mapping, err := ParseRoleMapping(opts.RoleMapping)
+	if err != nil {
+		return nil, err
+	}
+	a.roleMapping = mapping
+
+	parserOpts := []jwt.ParserOption{
+		jwt.WithValidMethods(methods),
+		jwt.WithExpirationRequired(),
+	}
+	if opts.Issuer != "" {
+		parserOpts = append(parserOpts, jwt.WithIssuer(opts.Issuer))
+	}
+	if opts.Audience != "" {
+		parserOpts = append(parserOpts, jwt.WithAudience(opts.Audience))
+	}
+	a.parser = jwt.NewParser(parserOpts...)
+
+	return a, nil
+}
+
+// Authenticate validates a raw token and returns its principal.
+func (a *Authenticator) Authenticate(tokenString string) (*Principal, error) {
+	claims := jwt.MapClaims{}
+	if _, err := a.parser.ParseWithClaims(tokenString, claims, a.keyFunc); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+
+	subject, err := claims.GetSubject()
+	if err != nil || subject == "" {
+		return nil, fmt.Errorf("%w: missing subject", ErrInvalidToken)
+	}
+
+	return &Principal{Subject: subject, Role: a.roleFromClaims(claims)}, nil
+}
+
+func (a *Authenticator) keyFunc(token *jwt.Token) (any, error) {
+	switch token.Method.Alg() {
+	case jwt.SigningMethodHS256.Alg():
+		return a.hmacSecret, nil
+	case jwt.SigningMethodRS256.Alg():
+		kid, _ := token.Header["kid"].(string)
+		if key, ok := a.rsaKeys[kid]; ok {
+			return key, nil
+		}
+		// Tokens without a kid are accepted when the JWKS has a single key
+		if kid == "" && len(a.rsaKeys) == 1 {
+			for _, key := range a.rsaKeys {
+				return key, nil
+			}
+		}
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	default:
+		return nil, fmt.Errorf("unexpected signing method %s", token.Method.Alg())
+	}
+}
+
+// roleFromClaims maps the roles claim (a string or a list of strings) to the
+// highest role it grants. Any authenticated caller is at least a guest.
+func (a *Authenticator) roleFromClaims(claims jwt.MapClaims) Role {
+	var values []string
+	switch raw := claims[a.rolesClaim].(type) {
+	case string:
+		values = []string{raw}
+	case []any:
+		for _, item := range raw {
+			if value, ok := item.(string); ok {
+				values = append(values, value)
+			}
+		}
+	}
+
+	best := RoleGuest
+	for _, value := range values {
+		role, ok := a.roleMapping[value]
+		if !ok {
+			parsed, err := ParseRole(value)
+			if err != nil {
+				continue
+			}
+			role = parsed
+		}
+		if roleRank[role] > roleRank[best] {
+			best = role
+		}
+	}
+	return best
+}
@@ -0,0 +1,216 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const testSecret = "test-secret"
+
+// newTestAuthenticator accepts HS256 tokens signed with testSecret and RS256
+// tokens signed with the returned key, published under kid "k1".
+func newTestAuthenticator(t *testing.T) (*Authenticator, *rsa.PrivateKey) {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	set := jwks{Keys: []jwk{{
+		Kty: "RSA",
+		Kid: "k1",
+		Use: "sig",
+		Alg: "RS256",
+		N:   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+		E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+	}}}
+	data, _ := json.Marshal(set)
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatalf("failed to write JWKS file: %v", err)
+	}
+
+	a, err := NewAuthenticator(Options{
+		HS256Secret: testSecret,
+		JWKSFile:    path,
+		Issuer:      "test-issuer",
+		RolesClaim:  "roles",
+		RoleMapping: "front-desk=staff",
+	})
+	if err != nil {
+		t.Fatalf("failed to create authenticator: %v", err)
+	}
+	return a, key
+}
+
+func claims(overrides jwt.MapClaims) jwt.MapClaims {
+	c := jwt.MapClaims{
+		"sub": "alice",
+		"iss": "test-issuer",
+		"exp": time.Now().Add(time.Hour).Unix(),
+	}
+	for name, value := range overrides {
+		if value == nil {
+			delete(c, name)
+		} else {
+			c[name] = value
+		}
+	}
+	return c
+}
+
+func sign(t *testing.T, method jwt.SigningMethod, c jwt.MapClaims, key any, kid string) string {
+	t.Helper()
+	token := jwt.NewWithClaims(method, c)
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+	signed, err := token.SignedString(key)
+	if err != nil {
+		t.Fatalf("failed to sign token: %v", err)
+	}
+	return signed
+}
+
+func TestAuthenticate(t *testing.T) {
+	a, key := newTestAuthenticator(t)
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		token string
+		valid bool
+	}{
+		{"HS256", sign(t, jwt.SigningMethodHS256, claims(nil), []byte(testSecret), ""), true},
+		{"RS256", sign(t, jwt.SigningMethodRS256, claims(nil), key, "k1"), true},
+		{"RS256 without kid", sign(t, jwt.SigningMethodRS256, claims(nil), key, ""), true},
+		{"HS256 with a bad signature", sign(t, jwt.SigningMethodHS256, claims(nil), []byte("other-secret"), ""), false},
+		{"RS256 with a bad signature", sign(t, jwt.SigningMethodRS256, claims(nil), otherKey, "k1"), false},
+		{"RS256 with an unknown kid", sign(t, jwt.SigningMethodRS256, claims(nil), key, "k2"), false},
+		{"HS384", sign(t, jwt.SigningMethodHS384, claims(nil), []byte(testSecret), ""), false},
+		{"alg none", sign(t, jwt.SigningMethodNone, claims(nil), jwt.UnsafeAllowNoneSignatureType, ""), false},
+		{"expired", sign(t, jwt.SigningMethodHS256, claims(jwt.MapClaims{"exp": time.Now().Add(-time.Minute).Unix()}), []byte(testSecret), ""), false},
+		{"without exp", sign(t, jwt.SigningMethodHS256, claims(jwt.MapClaims{"exp": nil}), []byte(testSecret), ""), false},
+		{"not yet valid", sign(t, jwt.SigningMethodHS256, claims(jwt.MapClaims{"nbf": time.Now().Add(time.Hour).Unix()}), []byte(testSecret), ""), false},
+		{"wrong issuer", sign(t, jwt.SigningMethodHS256, claims(jwt.MapClaims{"iss": "someone-else"}), []byte(testSecret), ""), false},
+		{"without subject", sign(t, jwt.SigningMethodHS256, claims(jwt.MapClaims{"sub": nil}), []byte(testSecret), ""), false},
+		{"malformed", "not.a.jwt", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			principal, err := a.Authenticate(tt.token)
+			if !tt.valid {
+				if !errors.Is(err, ErrInvalidToken) {
+					t.Errorf("Authenticate = %+v, %v, want ErrInvalidToken", principal, err)
+				}
+				return
+			}
+			if err != nil || principal.Subject != "alice" {
+				t.Errorf("Authenticate = %+v, %v, want subject alice", principal, err)
+			}
+		})
+	}
+}
+
+func TestRoleFromClaims(t *testing.T) {
+	a, _ := newTestAuthenticator(t)
+
+	tests := []struct {
+		name  string
+		roles any
+		want  Role
+	}{
+		{"no roles claim", nil, RoleGuest},
+		{"single role", "staff", RoleStaff},
+		{"highest of a list", []any{"guest", "admin", "staff"}, RoleAdmin},
+		{"mapped claim value", []any{"front-desk"}, RoleStaff},
+		{"unknown values", []any{"superuser", 42}, RoleGuest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := jwt.MapClaims{}
+			if tt.roles != nil {
+				c["roles"] = tt.roles
+			}
+			if got := a.roleFromClaims(c); got != tt.want {
+				t.Errorf("roleFromClaims = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRequire(t *testing.T) {
+	a, _ := newTestAuthenticator(t)
+
+	var forwarded http.Header
+	handler := a.Middleware(Require(RoleStaff, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		forwarded = r.Header.Clone()
+		w.WriteHeader(http.StatusOK)
+	})))
+
+	token := func(roles any) string {
+		return "Bearer " + sign(t, jwt.SigningMethodHS256, claims(jwt.MapClaims{"roles": roles}), []byte(testSecret), "")
+	}
+
+	tests := []struct {
+		name          string
+		method        string
+		authorization string
+		want          int
+	}{
+		{"no token", "GET", "", http.StatusUnauthorized},
+		{"not a bearer token", "GET", "Basic YWxpY2U6c2VjcmV0", http.StatusUnauthorized},
+		{"invalid token", "GET", "Bearer not.a.jwt", http.StatusUnauthorized},
+		{"missing role", "GET", token("guest"), http.StatusForbidden},
+		{"required role", "GET", token("staff"), http.StatusOK},
+		{"higher role", "GET", token("admin"), http.StatusOK},
+		{"preflight without token", "OPTIONS", "", http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/booking-management/users", nil)
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+			// Clients cannot forge the identity forwarded upstream
+			req.Header.Set(SubjectHeader, "mallory")
+			req.Header.Set(RoleHeader, "admin")
+
+			forwarded = nil
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.want {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.want)
+			}
+			if rec.Code == http.StatusUnauthorized && rec.Header().Get("WWW-Authenticate") == "" {
+				t.Error("401 without a WWW-Authenticate header")
+			}
+			if forwarded == nil {
+				return
+			}
+			if tt.authorization == "" {
+				if forwarded.Get(SubjectHeader) != "" || forwarded.Get(RoleHeader) != "" {
+					t.Errorf("forged identity was forwarded: %s %s", forwarded.Get(SubjectHeader), forwarded.Get(RoleHeader))
+				}
+			} else if forwarded.Get(SubjectHeader) != "alice" {
+				t.Errorf("forwarded subject = %q, want alice", forwarded.Get(SubjectHeader))
+			}
+		})
+	}
+}
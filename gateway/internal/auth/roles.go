@@ -0,0 +1,61 @@
+package auth
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Role is a caller's privilege level. Roles are ordered: admin can do
+// everything staff can, and staff everything a guest can.
+type Role string
+
+const (
+	RoleNone  Role = ""
+	RoleGuest Role = "guest"
+	RoleStaff Role = "staff"
+	RoleAdmin Role = "admin"
+)
+
+var roleRank = map[Role]int{
+	RoleNone:  0,
+	RoleGuest: 1,
+	RoleStaff: 2,
+	RoleAdmin: 3,
+}
+
+// ParseRole validates a role name; an empty name means no role is required.
+func ParseRole(name string) (Role, error) {
+	role := Role(strings.ToLower(strings.TrimSpace(name)))
+	if _, ok := roleRank[role]; !ok {
+		return RoleNone, fmt.Errorf("unknown role %q", name)
+	}
+	return role, nil
+}
+
+// Satisfies reports whether r grants at least the required role.
+func (r Role) Satisfies(required Role) bool {
+	return roleRank[r] >= roleRank[required]
+}
+
+// ParseRoleMapping parses "claim-value=role,..." into a lookup table.
+func ParseRoleMapping(mapping string) (map[string]Role, error) {
+	roles := make(map[string]Role)
+	for _, entry := range strings.Split(mapping, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		value, name, found := strings.Cut(entry, "=")
+		if !found {
+			return nil, fmt.Errorf("invalid role mapping entry %q", entry)
+		}
+
+		role, err := ParseRole(name)
+		if err != nil || role == RoleNone {
+			return nil, fmt.Errorf("invalid role mapping entry %q", entry)
+		}
+		roles[strings.TrimSpace(value)] = role
+	}
+	return roles, nil
+}
@@ -0,0 +1,211 @@
+package breaker
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrOpen is returned by Allow while a breaker rejects calls.
+var ErrOpen = errors.New("circuit breaker is open")
+
+// OpenError reports a rejected call and how long until the breaker lets a
+// probe request through again.
+type OpenError struct {
+	Upstream   string
+	RetryAfter time.Duration
+}
+
+func (e *OpenError) Error() string {
+	return fmt.Sprintf("circuit breaker for %s is open, retry after %s", e.Upstream, e.RetryAfter.Round(time.Second))
+}
+
+func (e *OpenError) Unwrap() error {
+	return ErrOpen
+}
+
+type State int
+
+const (
+	StateClosed State = iota
+	StateOpen
+	StateHalfOpen
+)
+
+func (s State) String() string {
+	switch s {
+	case StateClosed:
+		return "closed"
+	case StateOpen:
+		return "open"
+	case StateHalfOpen:
+		return "half-open"
+	default:
+		return "unknown"
+	}
+}
+
+// Settings control when a breaker opens and how it recovers.
+type Settings struct {
+	// FailureThreshold is the number of consecutive failures that opens the breaker.
+	FailureThreshold int
+	// OpenTimeout is how long the breaker stays open before allowing probes.
+	OpenTimeout time.Duration
+	// HalfOpenRequests is the number of probe requests allowed while half-open;
+	// the breaker closes once that many succeed.
+	HalfOpenRequests int
+}
+
+// Breaker is a closed/open/half-open circuit breaker for a single upstream.
+type Breaker struct {
+	name     string
+	host     string
+	settings Settings
+
+	mu                  sync.Mutex
+	state               State
+	consecutiveFailures int
+	halfOpenInFlight    int
+	halfOpenSuccesses   int
+	openedAt            time.Time
+	lastFailureAt       time.Time
+	lastError           string
+}
+
+func New(name, host string, settings Settings) *Breaker {
+	if settings.FailureThreshold <= 0 {
+		settings.FailureThreshold = 1
+	}
+	if settings.HalfOpenRequests <= 0 {
+		settings.HalfOpenRequests = 1
+	}
+	return &Breaker{name: name, host: host, settings: settings}
+}
+
+// Name returns the upstream name the breaker was registered with.
+func (b *Breaker) Name() string {
+	return b.name
+}
+
+// Allow reports whether a call may proceed. Every allowed call must be
+// followed by exactly one of Success, Failure or Ignore.
+func (b *Breaker) Allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == StateOpen {
+		elapsed := time.Since(b.openedAt)
+		if elapsed < b.settings.OpenTimeout {
+			return &OpenError{Upstream: b.name, RetryAfter: b.settings.OpenTimeout - elapsed}
+		}
+		b.setState(StateHalfOpen)
+	}
+
+	if b.state == StateHalfOpen {
+		if b.halfOpenInFlight >= b.settings.HalfOpenRequests {
+			return &OpenError{Upstream: b.name, RetryAfter: time.Second}
+		}
+		b.halfOpenInFlight++
+	}
+
+	return nil
+}
+
+// Success records a successful call.
+func (b *Breaker) Success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.consecutiveFailures = 0
+	if b.state == StateHalfOpen {
+		b.releaseHalfOpen()
+		b.halfOpenSuccesses++
+		if b.halfOpenSuccesses >= b.settings.HalfOpenRequests {
+			b.setState(StateClosed)
+		}
+	}
+}
+
+// Failure records a failed call, opening the breaker once the threshold is
+// reached or immediately if a half-open probe fails.
+func (b *Breaker) Failure(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.consecutiveFailures++
+	b.lastFailureAt = time.Now()
+	if err != nil {
+		b.lastError = err.Error()
+	}
+
+	switch b.state {
+	case StateHalfOpen:
+		b.setState(StateOpen)
+	case StateClosed:
+		if b.consecutiveFailures >= b.settings.FailureThreshold {
+			b.setState(StateOpen)
+		}
+	}
+}
+
+// Ignore releases an allowed call whose outcome says nothing about the
+// upstream, such as one cancelled by the client.
+func (b *Breaker) Ignore() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == StateHalfOpen {
+		b.releaseHalfOpen()
+	}
+}
+
+// releaseHalfOpen frees a probe slot. Calls admitted before the breaker
+// moved to half-open never took a slot, so the count is clamped at zero.
+func (b *Breaker) releaseHalfOpen() {
+	if b.halfOpenInFlight > 0 {
+		b.halfOpenInFlight--
+	}
+}
+
+func (b *Breaker) setState(state State) {
+	b.state = state
+	b.halfOpenInFlight = 0
+	b.halfOpenSuccesses = 0
+	if state == StateOpen {
+		b.openedAt = time.Now()
+	}
+}
+
+// Snapshot is a point-in-time view of a breaker.
+type Snapshot struct {
+	Name                string     `json:"name"`
+	Host                string     `json:"host"`
+	State               string     `json:"state"`
+	ConsecutiveFailures int        `json:"consecutive_failures"`
+	OpenedAt            *time.Time `json:"opened_at,omitempty"`
+	LastFailureAt       *time.Time `json:"last_failure_at,omitempty"`
+	LastError           string     `json:"last_error,omitempty"`
+}
+
+func (b *Breaker) Snapshot() Snapshot {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	snapshot := Snapshot{
+		Name:                b.name,
+		Host:                b.host,
+		State:               b.state.String(),
+		ConsecutiveFailures: b.consecutiveFailures,
+		LastError:           b.lastError,
+	}
+	if b.state != StateClosed {
+		openedAt := b.openedAt
+		snapshot.OpenedAt = &openedAt
+	}
+	if !b.lastFailureAt.IsZero() {
+		lastFailureAt := b.lastFailureAt
+		snapshot.LastFailureAt = &lastFailureAt
+	}
+	return snapshot
+}
@@ -0,0 +1,148 @@
+package breaker
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// step is one call on a breaker and the state expected after it. "elapse"
+// moves the breaker past its open timeout; allow steps also check whether
+// the call was rejected.
+type step struct {
+	action   string
+	rejected bool
+	state    State
+}
+
+func TestBreakerTransitions(t *testing.T) {
+	tests := []struct {
+		name     string
+		settings Settings
+		steps    []step
+	}{
+		{
+			name:     "stays closed below the threshold",
+			settings: Settings{FailureThreshold: 3, OpenTimeout: time.Minute, HalfOpenRequests: 1},
+			steps: []step{
+				{action: "allow", state: StateClosed},
+				{action: "failure", state: StateClosed},
+				{action: "allow", state: StateClosed},
+				{action: "failure", state: StateClosed},
+			},
+		},
+		{
+			name:     "success resets the failure count",
+			settings: Settings{FailureThreshold: 2, OpenTimeout: time.Minute, HalfOpenRequests: 1},
+			steps: []step{
+				{action: "failure", state: StateClosed},
+				{action: "success", state: StateClosed},
+				{action: "failure", state: StateClosed},
+			},
+		},
+		{
+			name:     "opens at the threshold and rejects calls",
+			settings: Settings{FailureThreshold: 2, OpenTimeout: time.Minute, HalfOpenRequests: 1},
+			steps: []step{
+				{action: "failure", state: StateClosed},
+				{action: "failure", state: StateOpen},
+				{action: "allow", rejected: true, state: StateOpen},
+			},
+		},
+		{
+			name:     "half-opens after the timeout and closes on success",
+			settings: Settings{FailureThreshold: 1, OpenTimeout: time.Minute, HalfOpenRequests: 1},
+			steps: []step{
+				{action: "failure", state: StateOpen},
+				{action: "elapse", state: StateOpen},
+				{action: "allow", state: StateHalfOpen},
+				{action: "success", state: StateClosed},
+				{action: "allow", state: StateClosed},
+			},
+		},
+		{
+			name:     "reopens when a probe fails",
+			settings: Settings{FailureThreshold: 3, OpenTimeout: time.Minute, HalfOpenRequests: 2},
+			steps: []step{
+				{action: "failure", state: StateClosed},
+				{action: "failure", state: StateClosed},
+				{action: "failure", state: StateOpen},
+				{action: "elapse", state: StateOpen},
+				{action: "allow", state: StateHalfOpen},
+				{action: "failure", state: StateOpen},
+				{action: "allow", rejected: true, state: StateOpen},
+			},
+		},
+		{
+			name:     "limits concurrent probes and needs all of them to succeed",
+			settings: Settings{FailureThreshold: 1, OpenTimeout: time.Minute, HalfOpenRequests: 2},
+			steps: []step{
+				{action: "failure", state: StateOpen},
+				{action: "elapse", state: StateOpen},
+				{action: "allow", state: StateHalfOpen},
+				{action: "allow", state: StateHalfOpen},
+				{action: "allow", rejected: true, state: StateHalfOpen},
+				{action: "success", state: StateHalfOpen},
+				{action: "success", state: StateClosed},
+			},
+		},
+		{
+			name:     "an ignored probe frees its slot",
+			settings: Settings{FailureThreshold: 1, OpenTimeout: time.Minute, HalfOpenRequests: 1},
+			steps: []step{
+				{action: "failure", state: StateOpen},
+				{action: "elapse", state: StateOpen},
+				{action: "allow", state: StateHalfOpen},
+				{action: "allow", rejected: true, state: StateHalfOpen},
+				{action: "ignore", state: StateHalfOpen},
+				{action: "allow", state: StateHalfOpen},
+				{action: "success", state: StateClosed},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := New("booking", "booking:8081", tt.settings)
+			for i, s := range tt.steps {
+				switch s.action {
+				case "allow":
+					err := b.Allow()
+					if rejected := err != nil; rejected != s.rejected {
+						t.Fatalf("step %d: Allow() = %v, want rejected %v", i, err, s.rejected)
+					}
+					if err != nil && !errors.Is(err, ErrOpen) {
+						t.Fatalf("step %d: Allow() = %v, want ErrOpen", i, err)
+					}
+				case "success":
+					b.Success()
+				case "failure":
+					b.Failure(errors.New("upstream returned status 503"))
+				case "ignore":
+					b.Ignore()
+				case "elapse":
+					b.openedAt = b.openedAt.Add(-tt.settings.OpenTimeout)
+				}
+				if b.state != s.state {
+					t.Fatalf("step %d (%s): state = %s, want %s", i, s.action, b.state, s.state)
+				}
+			}
+		})
+	}
+}
+
+func TestOpenErrorRetryAfter(t *testing.T) {
+	b := New("booking", "booking:8081", Settings{FailureThreshold: 1, OpenTimeout: time.Minute})
+	b.Failure(nil)
+
+	var openErr *OpenError
+	if err := b.Allow(); !errors.As(err, &openErr) {
+		t.Fatalf("Allow() = %v, want an *OpenError", err)
+	}
+	if openErr.RetryAfter <= 0 || openErr.RetryAfter > time.Minute {
+		t.Errorf("RetryAfter = %s, want within the open timeout", openErr.RetryAfter)
+	}
+	if openErr.Upstream != "booking" {
+		t.Errorf("Upstream = %q, want booking", openErr.Upstream)
+	}
+}
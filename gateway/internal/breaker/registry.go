@@ -0,0 +1,92 @@
+package breaker
+
+import (
+	"container/list"
+	"sort"
+	"sync"
+)
+
+// maxDynamicBreakers bounds the breakers of hosts only seen at runtime.
+// Those hosts come from client-controlled divert keys, so the least recently
+// used breaker is dropped once the limit is reached.
+const maxDynamicBreakers = 256
+
+// Registry holds one breaker per upstream host. Configured upstreams are
+// registered up front and kept for good; hosts only seen at runtime, such as
+// diverted upstreams, get a breaker with the default settings on first use,
+// up to maxDynamicBreakers of them.
+type Registry struct {
+	defaults   Settings
+	maxDynamic int
+
+	mu       sync.Mutex
+	breakers map[string]*Breaker
+	dynamic  map[string]*list.Element
+	lru      *list.List // of *Breaker, most recently used first
+}
+
+func NewRegistry(defaults Settings) *Registry {
+	return &Registry{
+		defaults:   defaults,
+		maxDynamic: maxDynamicBreakers,
+		breakers:   make(map[string]*Breaker),
+		dynamic:    make(map[string]*list.Element),
+		lru:        list.New(),
+	}
+}
+
+// Register adds a named upstream host with its own settings.
+func (r *Registry) Register(name, host string, settings Settings) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.breakers[host] = New(name, host, settings)
+}
+
+// Get returns the breaker for host, creating it if needed.
+func (r *Registry) Get(host string) *Breaker {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if b, ok := r.breakers[host]; ok {
+		return b
+	}
+	if element, ok := r.dynamic[host]; ok {
+		r.lru.MoveToFront(element)
+		return element.Value.(*Breaker)
+	}
+
+	for r.lru.Len() >= r.maxDynamic {
+		oldest := r.lru.Back()
+		r.lru.Remove(oldest)
+		delete(r.dynamic, oldest.Value.(*Breaker).host)
+	}
+	b := New(host, host, r.defaults)
+	r.dynamic[host] = r.lru.PushFront(b)
+	return b
+}
+
+// Snapshots returns the state of every breaker, sorted by name.
+func (r *Registry) Snapshots() []Snapshot {
+	r.mu.Lock()
+	breakers := make([]*Breaker, 0, len(r.breakers)+r.lru.Len())
+	for _, b := range r.breakers {
+		breakers = append(breakers, b)
+	}
+	for element := r.lru.Front(); element != nil; element = element.Next() {
+		breakers = append(breakers, element.Value.(*Breaker))
+	}
+	r.mu.Unlock()
+
+	snapshots := make([]Snapshot, 0, len(breakers))
+	for _, b := range breakers {
+		snapshots = append(snapshots, b.Snapshot())
+	}
+	sort.Slice(snapshots, func(i, j int) bool {
+		if snapshots[i].Name != snapshots[j].Name {
+			return snapshots[i].Name < snapshots[j].Name
+		}
+		return snapshots[i].Host < snapshots[j].Host
+	})
+	return snapshots
+}
@@ -0,0 +1,48 @@
+package breaker
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestRegistryBoundsDynamicBreakers(t *testing.T) {
+	r := NewRegistry(Settings{FailureThreshold: 1, OpenTimeout: time.Minute})
+	r.maxDynamic = 3
+	r.Register("booking", "booking:8081", Settings{FailureThreshold: 5})
+
+	first := r.Get("booking.ns0:8081")
+	for i := 1; i < 10; i++ {
+		r.Get(fmt.Sprintf("booking.ns%d:8081", i))
+	}
+
+	if n := len(r.dynamic); n != 3 {
+		t.Fatalf("registry holds %d dynamic breakers, want 3", n)
+	}
+	if r.Get("booking.ns0:8081") == first {
+		t.Error("least recently used breaker was kept")
+	}
+	if b := r.Get("booking:8081"); b.Name() != "booking" {
+		t.Errorf("registered breaker was replaced by %q", b.Name())
+	}
+	if n := len(r.Snapshots()); n != 4 {
+		t.Errorf("Snapshots returned %d breakers, want 4", n)
+	}
+}
+
+func TestRegistryKeepsRecentlyUsedBreakers(t *testing.T) {
+	r := NewRegistry(Settings{FailureThreshold: 1, OpenTimeout: time.Minute})
+	r.maxDynamic = 2
+
+	a := r.Get("booking.a:8081")
+	r.Get("booking.b:8081")
+	r.Get("booking.a:8081") // a is now the most recently used
+	r.Get("booking.c:8081") // evicts b
+
+	if r.Get("booking.a:8081") != a {
+		t.Error("recently used breaker was evicted")
+	}
+	if _, ok := r.dynamic["booking.b:8081"]; ok {
+		t.Error("least recently used breaker was kept")
+	}
+}
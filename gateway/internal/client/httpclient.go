@@ -1,60 +1,118 @@
 package client
 
 import (
-	"bytes"
-	"context"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
+	"strconv"
 	"time"
 
+	"gateway/internal/breaker"
 	"gateway/internal/logger"
+	"gateway/internal/metrics"
 	"gateway/internal/middleware"
+	"gateway/internal/tracing"
 )
 
+// HTTPClient is the transport used by the gateway proxy to reach upstream
+// services. It propagates baggage, guards every upstream host with a circuit
+// breaker and retries idempotent calls, leaving body handling to the caller
+// so requests and responses can be streamed.
 type HTTPClient struct {
-	client *http.Client
+	transport http.RoundTripper
+	breakers  *breaker.Registry
+	retry     RetryPolicy
 }
 
-func NewHTTPClient() *HTTPClient {
+func NewHTTPClient(breakers *breaker.Registry, retry RetryPolicy) *HTTPClient {
 	return &HTTPClient{
-		client: &http.Client{
-			Timeout: 30 * time.Second,
-		},
+		transport: tracing.NewTransport(&http.Transport{
+			Proxy: http.ProxyFromEnvironment,
+			DialContext: (&net.Dialer{
+				Timeout:   5 * time.Second,
+				KeepAlive: 30 * time.Second,
+			}).DialContext,
+			MaxIdleConns:          100,
+			MaxIdleConnsPerHost:   20,
+			IdleConnTimeout:       90 * time.Second,
+			ResponseHeaderTimeout: 30 * time.Second,
+			ExpectContinueTimeout: 1 * time.Second,
+		}),
+		breakers: breakers,
+		retry:    retry,
 	}
 }
 
-func (c *HTTPClient) ProxyRequest(ctx context.Context, method, targetURL string, body []byte, headers map[string]string) (*http.Response, error) {
-	var reqBody io.Reader
-	if body != nil {
-		reqBody = bytes.NewReader(body)
-	}
-
-	req, err := http.NewRequestWithContext(ctx, method, targetURL, reqBody)
-	if err != nil {
-		logger.Error(ctx, "Failed to create HTTP request", "error", err, "url", targetURL)
-		return nil, fmt.Errorf("failed to create request: %w", err)
-	}
-
-	// Copy headers from original request
-	for key, value := range headers {
-		req.Header.Set(key, value)
-	}
+func (c *HTTPClient) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
 
 	// Propagate baggage header
 	if baggage := middleware.GetBaggageFromContext(ctx); baggage != "" {
+		req = req.Clone(ctx)
 		req.Header.Set("Baggage", baggage)
 	}
 
-	logger.Info(ctx, "Proxying request", "method", method, "url", targetURL)
+	cb := c.breakers.Get(req.URL.Host)
+	retryable := canRetry(req)
 
-	resp, err := c.client.Do(req)
-	if err != nil {
-		logger.Error(ctx, "Failed to proxy request", "error", err, "url", targetURL)
-		return nil, fmt.Errorf("failed to proxy request: %w", err)
-	}
+	for attempt := 0; ; attempt++ {
+		if err := cb.Allow(); err != nil {
+			logger.Warn(ctx, "Upstream circuit breaker rejected request", "error", err, "url", req.URL.String())
+			metrics.ObserveUpstream(cb.Name(), req.Method, "circuit_open", 0)
+			return nil, err
+		}
+
+		logger.Info(ctx, "Sending upstream request", "method", req.Method, "url", req.URL.String(), "attempt", attempt+1)
+
+		start := time.Now()
+		resp, err := c.transport.RoundTrip(req)
+		elapsed := time.Since(start)
 
-	logger.Info(ctx, "Request proxied successfully", "status", resp.StatusCode, "url", targetURL)
+		switch {
+		case ctx.Err() != nil:
+			// Cancelled by the client or the route timeout; says nothing about the upstream
+			cb.Ignore()
+			metrics.ObserveUpstream(cb.Name(), req.Method, "canceled", elapsed)
+		case err != nil:
+			cb.Failure(err)
+			metrics.ObserveUpstream(cb.Name(), req.Method, "error", elapsed)
+		case resp.StatusCode >= http.StatusInternalServerError:
+			cb.Failure(fmt.Errorf("upstream returned status %d", resp.StatusCode))
+			metrics.ObserveUpstream(cb.Name(), req.Method, strconv.Itoa(resp.StatusCode), elapsed)
+		default:
+			cb.Success()
+			metrics.ObserveUpstream(cb.Name(), req.Method, strconv.Itoa(resp.StatusCode), elapsed)
+		}
 
-	return resp, nil
-}
\ No newline at end of file
+		if err != nil {
+			logger.Error(ctx, "Upstream request failed", "error", err, "url", req.URL.String(), "attempt", attempt+1)
+		}
+
+		shouldRetry := err != nil || isRetryableStatus(resp.StatusCode)
+		if !shouldRetry || !retryable || attempt >= c.retry.MaxRetries || ctx.Err() != nil {
+			return resp, err
+		}
+
+		if resp != nil {
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+
+		if waitErr := c.retry.wait(ctx, attempt); waitErr != nil {
+			return nil, waitErr
+		}
+
+		if req.GetBody != nil {
+			body, bodyErr := req.GetBody()
+			if bodyErr != nil {
+				return nil, bodyErr
+			}
+			req = req.Clone(ctx)
+			req.Body = body
+		}
+
+		metrics.IncUpstreamRetry(cb.Name())
+		logger.Info(ctx, "Retrying upstream request", "method", req.Method, "url", req.URL.String(), "attempt", attempt+2)
+	}
+}
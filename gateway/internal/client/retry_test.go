@@ -0,0 +1,144 @@
+package client
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"gateway/internal/breaker"
+)
+
+func TestBackoffBounds(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  RetryPolicy
+		attempt int
+		max     time.Duration
+	}{
+		{"first attempt", RetryPolicy{BaseDelay: 100 * time.Millisecond, MaxDelay: 2 * time.Second}, 0, 100 * time.Millisecond},
+		{"doubles per attempt", RetryPolicy{BaseDelay: 100 * time.Millisecond, MaxDelay: 2 * time.Second}, 3, 800 * time.Millisecond},
+		{"capped at the max delay", RetryPolicy{BaseDelay: 100 * time.Millisecond, MaxDelay: 2 * time.Second}, 6, 2 * time.Second},
+		{"shift overflow is capped", RetryPolicy{BaseDelay: 100 * time.Millisecond, MaxDelay: 2 * time.Second}, 80, 2 * time.Second},
+		{"no delay", RetryPolicy{}, 2, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var longest time.Duration
+			for range 2000 {
+				delay := tt.policy.backoff(tt.attempt)
+				if delay < 0 || delay > tt.max {
+					t.Fatalf("backoff(%d) = %s, want within [0, %s]", tt.attempt, delay, tt.max)
+				}
+				longest = max(longest, delay)
+			}
+			// Full jitter spreads delays over the whole range
+			if longest < tt.max/2 {
+				t.Errorf("longest of 2000 delays is %s, want jitter up to %s", longest, tt.max)
+			}
+		})
+	}
+}
+
+func TestCanRetry(t *testing.T) {
+	// Client requests built from a strings.Reader can be replayed with GetBody
+	replayable, _ := http.NewRequest("PUT", "http://booking-management:8080/rooms/1", strings.NewReader("{}"))
+	oneShot := httptest.NewRequest("PUT", "/rooms/1", nil)
+	oneShot.Body = io.NopCloser(strings.NewReader("{}"))
+
+	tests := []struct {
+		name string
+		req  *http.Request
+		want bool
+	}{
+		{"GET", httptest.NewRequest("GET", "/rooms", nil), true},
+		{"DELETE", httptest.NewRequest("DELETE", "/rooms/1", nil), true},
+		{"PUT with a replayable body", replayable, true},
+		{"PUT with a one-shot body", oneShot, false},
+		{"POST", httptest.NewRequest("POST", "/book", nil), false},
+		{"PATCH", httptest.NewRequest("PATCH", "/rooms/1", nil), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := canRetry(tt.req); got != tt.want {
+				t.Errorf("canRetry = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// scriptedTransport answers each call with the next status, or a transport
+// error for status 0, and counts the calls.
+type scriptedTransport struct {
+	statuses []int
+	calls    int
+}
+
+func (s *scriptedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	status := s.statuses[min(s.calls, len(s.statuses)-1)]
+	s.calls++
+	if status == 0 {
+		return nil, errors.New("connection refused")
+	}
+	return &http.Response{StatusCode: status, Body: http.NoBody, Request: req}, nil
+}
+
+func TestRoundTripRetryBudget(t *testing.T) {
+	tests := []struct {
+		name      string
+		method    string
+		statuses  []int
+		wantCalls int
+		wantErr   bool
+		want      int
+	}{
+		{"stops at the retry budget", "GET", []int{503}, 3, false, 503},
+		{"retries transport errors", "GET", []int{0}, 3, true, 0},
+		{"stops at the first success", "GET", []int{502, 200}, 2, false, 200},
+		{"does not retry other errors", "GET", []int{500}, 1, false, 500},
+		{"does not retry POST", "POST", []int{503}, 1, false, 503},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			breakers := breaker.NewRegistry(breaker.Settings{FailureThreshold: 100, OpenTimeout: time.Minute})
+			transport := &scriptedTransport{statuses: tt.statuses}
+			c := &HTTPClient{
+				transport: transport,
+				breakers:  breakers,
+				retry:     RetryPolicy{MaxRetries: 2, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond},
+			}
+
+			resp, err := c.RoundTrip(httptest.NewRequest(tt.method, "http://booking:8081/status/1", nil))
+			if transport.calls != tt.wantCalls {
+				t.Errorf("upstream called %d times, want %d", transport.calls, tt.wantCalls)
+			}
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("RoundTrip error = %v, want error %v", err, tt.wantErr)
+			}
+			if err == nil && resp.StatusCode != tt.want {
+				t.Errorf("status = %d, want %d", resp.StatusCode, tt.want)
+			}
+		})
+	}
+}
+
+func TestRoundTripStopsWhenBreakerOpens(t *testing.T) {
+	breakers := breaker.NewRegistry(breaker.Settings{FailureThreshold: 2, OpenTimeout: time.Minute})
+	transport := &scriptedTransport{statuses: []int{503}}
+	c := &HTTPClient{
+		transport: transport,
+		breakers:  breakers,
+		retry:     RetryPolicy{MaxRetries: 5, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond},
+	}
+
+	_, err := c.RoundTrip(httptest.NewRequest("GET", "http://booking:8081/status/1", nil))
+	if !errors.Is(err, breaker.ErrOpen) {
+		t.Errorf("RoundTrip error = %v, want ErrOpen", err)
+	}
+	if transport.calls != 2 {
+		t.Errorf("upstream called %d times, want 2", transport.calls)
+	}
+}
@@ -1,20 +1,66 @@
 package config
 
-import "os"
+import (
+	"os"
+	"strconv"
+	"time"
+)
 
 type Config struct {
-	Port                       string
-	AdminServiceURL           string
-	BookingServiceURL         string
+	Port                        string
+	AdminServiceURL             string
+	BookingServiceURL           string
 	BookingManagementServiceURL string
+	DivertBaggageKey            string
+	DivertProbeTimeout          time.Duration
+	DivertProbeCacheTTL         time.Duration
+	RoutesFile                  string
+	DefaultMaxRequestBody       int64
+	BreakerFailureThreshold     int
+	BreakerOpenTimeout          time.Duration
+	BreakerHalfOpenRequests     int
+	RetryMax                    int
+	RetryBaseDelay              time.Duration
+	RetryMaxDelay               time.Duration
+	HealthProbeTimeout          time.Duration
+	JWTHS256Secret              string
+	JWTJWKSFile                 string
+	JWTIssuer                   string
+	JWTAudience                 string
+	JWTRolesClaim               string
+	JWTRoleMapping              string
+	RateLimitTrustForwardedFor  bool
+	OTLPEndpoint                string
+	TracesFile                  string
 }
 
 func Load() *Config {
 	return &Config{
-		Port:                       getEnv("PORT", "8082"),
-		AdminServiceURL:           getEnv("ADMIN_SERVICE_URL", "http://admin:3001"),
-		BookingServiceURL:         getEnv("BOOKING_SERVICE_URL", "http://booking:8081"),
+		Port:                        getEnv("PORT", "8082"),
+		AdminServiceURL:             getEnv("ADMIN_SERVICE_URL", "http://admin:3001"),
+		BookingServiceURL:           getEnv("BOOKING_SERVICE_URL", "http://booking:8081"),
 		BookingManagementServiceURL: getEnv("BOOKING_MANAGEMENT_SERVICE_URL", "http://booking-management:8080"),
+		DivertBaggageKey:            getEnv("DIVERT_BAGGAGE_KEY", "okteto-divert"),
+		DivertProbeTimeout:          getDurationEnv("DIVERT_PROBE_TIMEOUT", 500*time.Millisecond),
+		DivertProbeCacheTTL:         getDurationEnv("DIVERT_PROBE_CACHE_TTL", 10*time.Second),
+		RoutesFile:                  getEnv("ROUTES_FILE", ""),
+		DefaultMaxRequestBody:       getByteSizeEnv("DEFAULT_MAX_REQUEST_BODY", 10<<20),
+		BreakerFailureThreshold:     getIntEnv("BREAKER_FAILURE_THRESHOLD", 5),
+		BreakerOpenTimeout:          getDurationEnv("BREAKER_OPEN_TIMEOUT", 30*time.Second),
+		BreakerHalfOpenRequests:     getIntEnv("BREAKER_HALF_OPEN_REQUESTS", 1),
+		RetryMax:                    getIntEnv("RETRY_MAX", 2),
+		RetryBaseDelay:              getDurationEnv("RETRY_BASE_DELAY", 100*time.Millisecond),
+		RetryMaxDelay:               getDurationEnv("RETRY_MAX_DELAY", 2*time.Second),
+		HealthProbeTimeout:          getDurationEnv("HEALTH_PROBE_TIMEOUT", 2*time.Second),
+		JWTHS256Secret:              getEnv("JWT_HS256_SECRET", ""),
+		JWTJWKSFile:                 getEnv("JWT_JWKS_FILE", ""),
+		JWTIssuer:                   getEnv("JWT_ISSUER", ""),
+		JWTAudience:                 getEnv("JWT_AUDIENCE", ""),
+		JWTRolesClaim:               getEnv("JWT_ROLES_CLAIM", "roles"),
+		JWTRoleMapping:              getEnv("JWT_ROLE_MAPPING", ""),
+		RateLimitTrustForwardedFor:  getEnv("RATE_LIMIT_TRUST_FORWARDED_FOR", "false") == "true",
+		OTLPEndpoint:                getEnv("OTEL_EXPORTER_OTLP_ENDPOINT", ""),
+		TracesFile:                  getEnv("OTEL_TRACES_FILE", ""),
 	}
 }
 
@@ -23,4 +69,31 @@ func getEnv(key, defaultValue string) string {
 		return value
 	}
 	return defaultValue
-}
\ No newline at end of file
+}
+
+func getIntEnv(key string, defaultValue int) int {
+	if value := os.Getenv(key); value != "" {
+		if number, err := strconv.Atoi(value); err == nil {
+			return number
+		}
+	}
+	return defaultValue
+}
+
+func getDurationEnv(key string, defaultValue time.Duration) time.Duration {
+	if value := os.Getenv(key); value != "" {
+		if duration, err := time.ParseDuration(value); err == nil {
+			return duration
+		}
+	}
+	return defaultValue
+}
+
+func getByteSizeEnv(key string, defaultValue int64) int64 {
+	if value := os.Getenv(key); value != "" {
+		if size, err := ParseByteSize(value); err == nil {
+			return size
+		}
+	}
+	return defaultValue
+}
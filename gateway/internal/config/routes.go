@@ -0,0 +1,287 @@
+package config
+
+import (
+	_ "embed"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"gateway/internal/auth"
+)
+
+//go:embed routes.yaml
+var defaultRoutes []byte
+
+// RouteTable describes the upstreams the gateway knows about and the routes
+// that forward requests to them. It is loaded from a YAML or JSON file.
+type RouteTable struct {
+	Upstreams map[string]Upstream `yaml:"upstreams"`
+	Routes    []Route             `yaml:"routes"`
+}
+
+// Upstream is a backend service. HealthPath is probed by the deep health
+// check; a Critical upstream being down makes the gateway report unhealthy.
+type Upstream struct {
+	URL        string          `yaml:"url"`
+	HealthPath string          `yaml:"healthPath"`
+	Critical   bool            `yaml:"critical"`
+	Breaker    BreakerSettings `yaml:"breaker"`
+}
+
+// HealthPathOrDefault returns the health check path, defaulting to /health.
+func (u Upstream) HealthPathOrDefault() string {
+	if u.HealthPath == "" {
+		return "/health"
+	}
+	return u.HealthPath
+}
+
+// BreakerSettings override the gateway-wide circuit breaker settings for one
+// upstream. Zero values keep the defaults.
+type BreakerSettings struct {
+	FailureThreshold int      `yaml:"failureThreshold"`
+	OpenTimeout      Duration `yaml:"openTimeout"`
+	HalfOpenRequests int      `yaml:"halfOpenRequests"`
+}
+
+// Route forwards requests matching Path to an upstream. A path ending in
+// "/*" matches everything below that prefix; any other path matches exactly.
+// Rewrite replaces the matched path (or prefix) before forwarding.
+//
+// Role is the minimum caller role (guest, staff or admin) required when
+// authentication is enabled; an empty role makes the route public.
+//
+// MaxRequestBody and MaxResponseBody cap body sizes (0 uses the gateway
+// default for requests and no limit for responses). Streaming routes, such as
+// Server-Sent Events, are exempt from the route timeout and the server write
+// timeout.
+type Route struct {
+	Path            string     `yaml:"path"`
+	Upstream        string     `yaml:"upstream"`
+	Rewrite         string     `yaml:"rewrite"`
+	Methods         []string   `yaml:"methods"`
+	Timeout         Duration   `yaml:"timeout"`
+	MaxRequestBody  ByteSize   `yaml:"maxRequestBody"`
+	MaxResponseBody ByteSize   `yaml:"maxResponseBody"`
+	Streaming       bool       `yaml:"streaming"`
+	Role            string     `yaml:"role"`
+	RateLimit       *RateLimit `yaml:"rateLimit"`
+}
+
+// RateLimit allows Requests per Per window for each client, with bursts of
+// up to Burst requests (defaults to Requests). Key selects how clients are
+// told apart: "ip" (default), "apikey" (X-API-Key header) or "subject"
+// (authenticated JWT subject); the last two fall back to the client IP.
+type RateLimit struct {
+	Requests int      `yaml:"requests"`
+	Per      Duration `yaml:"per"`
+	Burst    int      `yaml:"burst"`
+	Key      string   `yaml:"key"`
+}
+
+// Duration is a time.Duration that unmarshals from strings such as "5s".
+type Duration time.Duration
+
+func (d *Duration) UnmarshalYAML(value *yaml.Node) error {
+	var raw string
+	if err := value.Decode(&raw); err != nil {
+		return err
+	}
+
+	duration, err := time.ParseDuration(raw)
+	if err != nil {
+		return fmt.Errorf("invalid duration %q: %w", raw, err)
+	}
+
+	*d = Duration(duration)
+	return nil
+}
+
+// ByteSize is a size in bytes that unmarshals from a plain number or from
+// strings such as "512KB" or "10MB" (1024-based units).
+type ByteSize int64
+
+func (b *ByteSize) UnmarshalYAML(value *yaml.Node) error {
+	var raw string
+	if err := value.Decode(&raw); err != nil {
+		return err
+	}
+
+	size, err := ParseByteSize(raw)
+	if err != nil {
+		return err
+	}
+
+	*b = ByteSize(size)
+	return nil
+}
+
+// ParseByteSize parses a size such as "1048576", "512KB" or "10MB".
+func ParseByteSize(raw string) (int64, error) {
+	units := []struct {
+		suffix     string
+		multiplier int64
+	}{
+		{"GB", 1 << 30},
+		{"MB", 1 << 20},
+		{"KB", 1 << 10},
+		{"B", 1},
+	}
+
+	value := strings.ToUpper(strings.TrimSpace(raw))
+	multiplier := int64(1)
+	for _, unit := range units {
+		if strings.HasSuffix(value, unit.suffix) {
+			value = strings.TrimSpace(strings.TrimSuffix(value, unit.suffix))
+			multiplier = unit.multiplier
+			break
+		}
+	}
+
+	size, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || size < 0 {
+		return 0, fmt.Errorf("invalid size %q", raw)
+	}
+	return size * multiplier, nil
+}
+
+// IsWildcard reports whether the route forwards a whole path prefix.
+func (rt Route) IsWildcard() bool {
+	return strings.HasSuffix(rt.Path, "/*")
+}
+
+// Prefix returns the path prefix matched by a wildcard route.
+func (rt Route) Prefix() string {
+	return strings.TrimSuffix(rt.Path, "*")
+}
+
+// TargetPath maps an incoming request path to the path sent upstream.
+func (rt Route) TargetPath(requestPath string) string {
+	if rt.IsWildcard() {
+		if rt.Rewrite == "" {
+			return requestPath
+		}
+		return rt.Rewrite + strings.TrimPrefix(requestPath, rt.Prefix())
+	}
+
+	if rt.Rewrite != "" {
+		return rt.Rewrite
+	}
+	return requestPath
+}
+
+// LoadRoutes reads the route table from cfg.RoutesFile, or the built-in table
+// when no file is configured. Upstream URLs from the environment are used for
+// any upstream the file does not define.
+func LoadRoutes(cfg *Config) (*RouteTable, error) {
+	data := defaultRoutes
+	if cfg.RoutesFile != "" {
+		fileData, err := os.ReadFile(cfg.RoutesFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read routes file: %w", err)
+		}
+		data = fileData
+	}
+
+	var table RouteTable
+	if err := yaml.Unmarshal(data, &table); err != nil {
+		return nil, fmt.Errorf("failed to parse routes file: %w", err)
+	}
+
+	defaults := map[string]string{
+		"admin":              cfg.AdminServiceURL,
+		"booking":            cfg.BookingServiceURL,
+		"booking-management": cfg.BookingManagementServiceURL,
+	}
+	if table.Upstreams == nil {
+		table.Upstreams = make(map[string]Upstream)
+	}
+	for name, url := range defaults {
+		if upstream := table.Upstreams[name]; upstream.URL == "" {
+			upstream.URL = url
+			table.Upstreams[name] = upstream
+		}
+	}
+
+	if err := table.validate(); err != nil {
+		return nil, err
+	}
+
+	table.sortRoutes()
+	return &table, nil
+}
+
+func (t *RouteTable) validate() error {
+	for name, upstream := range t.Upstreams {
+		if upstream.URL == "" {
+			return fmt.Errorf("upstream %q has no url", name)
+		}
+	}
+
+	for i := range t.Routes {
+		route := &t.Routes[i]
+		if !strings.HasPrefix(route.Path, "/") {
+			return fmt.Errorf("route %d: path %q must start with /", i, route.Path)
+		}
+		if strings.Contains(strings.TrimSuffix(route.Path, "/*"), "*") {
+			return fmt.Errorf("route %q: wildcard is only allowed as a trailing /*", route.Path)
+		}
+		if _, ok := t.Upstreams[route.Upstream]; !ok {
+			return fmt.Errorf("route %q: unknown upstream %q", route.Path, route.Upstream)
+		}
+		if _, err := auth.ParseRole(route.Role); err != nil {
+			return fmt.Errorf("route %q: %w", route.Path, err)
+		}
+		if err := route.RateLimit.validate(); err != nil {
+			return fmt.Errorf("route %q: %w", route.Path, err)
+		}
+		if route.Timeout < 0 {
+			return fmt.Errorf("route %q: timeout must not be negative", route.Path)
+		}
+
+		if len(route.Methods) == 0 {
+			route.Methods = []string{"GET"}
+		}
+		for j, method := range route.Methods {
+			route.Methods[j] = strings.ToUpper(method)
+		}
+	}
+	return nil
+}
+
+func (rl *RateLimit) validate() error {
+	if rl == nil {
+		return nil
+	}
+	if rl.Requests <= 0 || rl.Per <= 0 {
+		return fmt.Errorf("rateLimit requires positive requests and per")
+	}
+	if rl.Burst == 0 {
+		rl.Burst = rl.Requests
+	}
+	switch rl.Key {
+	case "":
+		rl.Key = "ip"
+	case "ip", "apikey", "subject":
+	default:
+		return fmt.Errorf("unknown rateLimit key %q", rl.Key)
+	}
+	return nil
+}
+
+// sortRoutes orders exact routes before wildcard routes, and longer wildcard
+// prefixes before shorter ones, so the most specific route matches first.
+func (t *RouteTable) sortRoutes() {
+	sort.SliceStable(t.Routes, func(i, j int) bool {
+		a, b := t.Routes[i], t.Routes[j]
+		if a.IsWildcard() != b.IsWildcard() {
+			return !a.IsWildcard()
+		}
+		return len(a.Path) > len(b.Path)
+	})
+}
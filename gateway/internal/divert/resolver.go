@@ -0,0 +1,179 @@
+package divert
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"gateway/internal/logger"
+	"gateway/internal/middleware"
+)
+
+// namespacePattern matches a valid Kubernetes namespace (RFC 1123 label), so a
+// baggage value can never be used to point the gateway at an arbitrary host.
+var namespacePattern = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]{0,61}[a-z0-9])?$`)
+
+// maxCachedProbes bounds the probe cache. Divert keys come from clients, so
+// every new namespace would otherwise add an entry that is never removed.
+const maxCachedProbes = 1024
+
+// Resolver chooses the upstream for a request based on the divert key carried
+// in its baggage. A request with "okteto-divert=<namespace>" is sent to the
+// same service in that namespace (e.g. booking.<namespace>:8081) as long as
+// it is reachable; otherwise the shared default upstream is used.
+type Resolver struct {
+	baggageKey   string
+	probeTimeout time.Duration
+	cacheTTL     time.Duration
+
+	mu        sync.Mutex
+	probes    map[string]probeResult
+	maxProbes int
+}
+
+type probeResult struct {
+	healthy   bool
+	checkedAt time.Time
+}
+
+func NewResolver(baggageKey string, probeTimeout, cacheTTL time.Duration) *Resolver {
+	return &Resolver{
+		baggageKey:   baggageKey,
+		probeTimeout: probeTimeout,
+		cacheTTL:     cacheTTL,
+		probes:       make(map[string]probeResult),
+		maxProbes:    maxCachedProbes,
+	}
+}
+
+// Resolve returns the upstream base URL to use for the given default service URL.
+func (r *Resolver) Resolve(ctx context.Context, defaultURL string) string {
+	if r.baggageKey == "" {
+		return defaultURL
+	}
+
+	namespace := middleware.GetBaggageMember(ctx, r.baggageKey)
+	if namespace == "" {
+		return defaultURL
+	}
+
+	target, err := divertedURL(defaultURL, namespace)
+	if err != nil {
+		logger.Warn(ctx, "Ignoring invalid divert key", "namespace", namespace, "error", err)
+		return defaultURL
+	}
+
+	if !r.isReachable(ctx, target) {
+		logger.Warn(ctx, "Diverted upstream unavailable, falling back to default", "diverted", target.String(), "default", defaultURL)
+		return defaultURL
+	}
+
+	logger.Info(ctx, "Diverting request", "namespace", namespace, "upstream", target.String())
+	return target.String()
+}
+
+// divertedURL rewrites the host of serviceURL to "<service>.<namespace>",
+// keeping the scheme, port and path of the default upstream.
+func divertedURL(serviceURL, namespace string) (*url.URL, error) {
+	if !namespacePattern.MatchString(namespace) {
+		return nil, fmt.Errorf("invalid namespace %q", namespace)
+	}
+
+	u, err := url.Parse(serviceURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse service URL: %w", err)
+	}
+
+	service, _, _ := strings.Cut(u.Hostname(), ".")
+	if service == "" {
+		return nil, fmt.Errorf("service URL %q has no host", serviceURL)
+	}
+
+	host := service + "." + namespace
+	if port := u.Port(); port != "" {
+		host = net.JoinHostPort(host, port)
+	}
+
+	diverted := *u
+	diverted.Host = host
+	return &diverted, nil
+}
+
+// isReachable checks that a TCP connection can be opened to the diverted
+// upstream. Results are cached so only one probe per upstream runs per TTL.
+func (r *Resolver) isReachable(ctx context.Context, target *url.URL) bool {
+	address := target.Host
+	if target.Port() == "" {
+		port := "80"
+		if target.Scheme == "https" {
+			port = "443"
+		}
+		address = net.JoinHostPort(target.Hostname(), port)
+	}
+
+	if healthy, ok := r.cachedProbe(address); ok {
+		return healthy
+	}
+
+	probeCtx, cancel := context.WithTimeout(ctx, r.probeTimeout)
+	defer cancel()
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(probeCtx, "tcp", address)
+	healthy := err == nil
+	if healthy {
+		conn.Close()
+	} else {
+		logger.Debug(ctx, "Diverted upstream probe failed", "address", address, "error", err)
+	}
+
+	r.cacheProbe(address, healthy)
+	return healthy
+}
+
+// cachedProbe returns the cached probe result for address, if it has not
+// expired. Expired results are dropped.
+func (r *Resolver) cachedProbe(address string) (healthy bool, ok bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	cached, ok := r.probes[address]
+	if !ok {
+		return false, false
+	}
+	if time.Since(cached.checkedAt) >= r.cacheTTL {
+		delete(r.probes, address)
+		return false, false
+	}
+	return cached.healthy, true
+}
+
+// cacheProbe stores a probe result. When the cache is full, expired results
+// are dropped first, then the oldest ones until there is room.
+func (r *Resolver) cacheProbe(address string, healthy bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.probes[address]; !ok && len(r.probes) >= r.maxProbes {
+		for key, cached := range r.probes {
+			if time.Since(cached.checkedAt) >= r.cacheTTL {
+				delete(r.probes, key)
+			}
+		}
+		for len(r.probes) >= r.maxProbes {
+			oldest, oldestAt := "", time.Time{}
+			for key, cached := range r.probes {
+				if oldest == "" || cached.checkedAt.Before(oldestAt) {
+					oldest, oldestAt = key, cached.checkedAt
+				}
+			}
+			delete(r.probes, oldest)
+		}
+	}
+	r.probes[address] = probeResult{healthy: healthy, checkedAt: time.Now()}
+}
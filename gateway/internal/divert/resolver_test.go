@@ -0,0 +1,99 @@
+package divert
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"gateway/internal/middleware"
+)
+
+func TestDivertedURL(t *testing.T) {
+	tests := []struct {
+		namespace string
+		want      string
+	}{
+		{"alice", "http://booking.alice:8081"},
+		{"team-1", "http://booking.team-1:8081"},
+		{"Alice", ""},
+		{"-alice", ""},
+		{"alice-", ""},
+		{"evil.com", ""},
+		{"alice:9000", ""},
+		{"alice/x", ""},
+		{"", ""},
+		{strings.Repeat("a", 64), ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.namespace, func(t *testing.T) {
+			got, err := divertedURL("http://booking:8081", tt.namespace)
+			if tt.want == "" {
+				if err == nil {
+					t.Errorf("divertedURL accepted %q as %s", tt.namespace, got)
+				}
+				return
+			}
+			if err != nil || got.String() != tt.want {
+				t.Errorf("divertedURL = %v, %v, want %s", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolveIgnoresInvalidNamespace(t *testing.T) {
+	r := NewResolver("okteto-divert", time.Second, time.Minute)
+	ctx := middleware.WithBaggage(context.Background(), "okteto-divert=evil.example.com")
+
+	if got := r.Resolve(ctx, "http://booking:8081"); got != "http://booking:8081" {
+		t.Errorf("Resolve = %s, want the default upstream", got)
+	}
+	if len(r.probes) != 0 {
+		t.Errorf("invalid namespace was probed: %v", r.probes)
+	}
+}
+
+func TestProbeCacheIsBounded(t *testing.T) {
+	r := NewResolver("okteto-divert", time.Second, time.Minute)
+	r.maxProbes = 4
+
+	for i := range 10 {
+		r.cacheProbe(fmt.Sprintf("booking.ns%d:8081", i), true)
+	}
+	if len(r.probes) != 4 {
+		t.Fatalf("cache holds %d probes, want 4", len(r.probes))
+	}
+	// The newest results are kept
+	if _, ok := r.cachedProbe("booking.ns9:8081"); !ok {
+		t.Error("newest probe was evicted")
+	}
+	if _, ok := r.cachedProbe("booking.ns0:8081"); ok {
+		t.Error("oldest probe was kept")
+	}
+}
+
+func TestProbeCacheDropsExpired(t *testing.T) {
+	r := NewResolver("okteto-divert", time.Second, time.Minute)
+	r.maxProbes = 4
+
+	for i := range 4 {
+		r.probes[fmt.Sprintf("booking.old%d:8081", i)] = probeResult{healthy: true, checkedAt: time.Now().Add(-2 * time.Minute)}
+	}
+	r.probes["booking.fresh:8081"] = probeResult{healthy: true, checkedAt: time.Now()}
+
+	if _, ok := r.cachedProbe("booking.old0:8081"); ok {
+		t.Error("expired probe was returned")
+	}
+	if _, ok := r.probes["booking.old0:8081"]; ok {
+		t.Error("expired probe was not dropped on lookup")
+	}
+
+	r.cacheProbe("booking.new:8081", false)
+	if len(r.probes) != 2 {
+		t.Errorf("cache holds %d probes after a full insert, want the fresh and the new one", len(r.probes))
+	}
+	if healthy, ok := r.cachedProbe("booking.new:8081"); !ok || healthy {
+		t.Errorf("cachedProbe = %v, %v, want an unhealthy cached result", healthy, ok)
+	}
+}
@@ -22,11 +22,14 @@ func Gateway(w http.ResponseWriter, r *http.Request) {
 
 	response := GatewayResponse{
 		Service:     "API Gateway",
-		Version:     "1.0.0",
+		Version:     gatewayVersion,
 		Description: "API Gateway for booking management system",
 		Endpoints: map[string]string{
-			"health": "/healthz",
-			"root":   "/",
+			"health":    "/healthz",
+			"deep":      "/healthz?deep=1",
+			"ready":     "/readyz",
+			"upstreams": "/gateway/upstreams",
+			"root":      "/",
 		},
 		Timestamp: time.Now().UTC().Format(time.RFC3339),
 	}
@@ -1,32 +1,193 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
+	"io"
 	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
 
+	"gateway/internal/config"
 	"gateway/internal/logger"
 )
 
+const (
+	gatewayVersion = "1.0.0"
+
+	statusHealthy   = "healthy"
+	statusDegraded  = "degraded"
+	statusUnhealthy = "unhealthy"
+
+	dependencyUp   = "up"
+	dependencyDown = "down"
+)
+
 type HealthResponse struct {
+	Status       string             `json:"status"`
+	Service      string             `json:"service"`
+	Version      string             `json:"version"`
+	Timestamp    string             `json:"timestamp"`
+	Dependencies []DependencyHealth `json:"dependencies,omitempty"`
+}
+
+type DependencyHealth struct {
+	Name      string `json:"name"`
+	URL       string `json:"url"`
 	Status    string `json:"status"`
-	Service   string `json:"service"`
-	Version   string `json:"version"`
-	Timestamp string `json:"timestamp"`
+	Critical  bool   `json:"critical"`
+	LatencyMS int64  `json:"latency_ms"`
+	Version   string `json:"version,omitempty"`
+	Error     string `json:"error,omitempty"`
 }
 
-func Health(w http.ResponseWriter, r *http.Request) {
+type HealthHandler struct {
+	upstreams  map[string]config.Upstream
+	httpClient *http.Client
+}
+
+// NewHealthHandler creates a health handler that probes the given upstreams
+// directly, bypassing divert routing, circuit breakers and retries.
+func NewHealthHandler(upstreams map[string]config.Upstream, probeTimeout time.Duration) *HealthHandler {
+	return &HealthHandler{
+		upstreams: upstreams,
+		httpClient: &http.Client{
+			Timeout: probeTimeout,
+		},
+	}
+}
+
+// Health reports the gateway's own health; with ?deep=1 it also probes every upstream
+func (h *HealthHandler) Health(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	logger.Info(ctx, "Health check requested")
 
+	if deep := r.URL.Query().Get("deep"); deep == "1" || deep == "true" {
+		h.writeDeepHealth(w, r)
+		return
+	}
+
 	response := HealthResponse{
-		Status:    "healthy",
+		Status:    statusHealthy,
 		Service:   "gateway",
-		Version:   "1.0.0",
-		Timestamp: "2024-01-01T12:00:00Z",
+		Version:   gatewayVersion,
+		Timestamp: time.Now().UTC().Format(time.RFC3339),
 	}
 
+	h.writeResponse(ctx, w, http.StatusOK, response)
+}
+
+// Ready reports whether every critical upstream is reachable
+func (h *HealthHandler) Ready(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	logger.Info(ctx, "Readiness check requested")
+
+	h.writeDeepHealth(w, r)
+}
+
+func (h *HealthHandler) writeDeepHealth(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	dependencies := h.probeAll(ctx)
+
+	status := statusHealthy
+	statusCode := http.StatusOK
+	for _, dependency := range dependencies {
+		if dependency.Status == dependencyUp {
+			continue
+		}
+		if dependency.Critical {
+			status = statusUnhealthy
+			statusCode = http.StatusServiceUnavailable
+			break
+		}
+		status = statusDegraded
+	}
+
+	response := HealthResponse{
+		Status:       status,
+		Service:      "gateway",
+		Version:      gatewayVersion,
+		Timestamp:    time.Now().UTC().Format(time.RFC3339),
+		Dependencies: dependencies,
+	}
+
+	h.writeResponse(ctx, w, statusCode, response)
+}
+
+// probeAll checks every upstream concurrently, returning results sorted by name
+func (h *HealthHandler) probeAll(ctx context.Context) []DependencyHealth {
+	names := make([]string, 0, len(h.upstreams))
+	for name := range h.upstreams {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	results := make([]DependencyHealth, len(names))
+	var wg sync.WaitGroup
+	for i, name := range names {
+		wg.Add(1)
+		go func(i int, name string) {
+			defer wg.Done()
+			results[i] = h.probe(ctx, name, h.upstreams[name])
+		}(i, name)
+	}
+	wg.Wait()
+
+	return results
+}
+
+func (h *HealthHandler) probe(ctx context.Context, name string, upstream config.Upstream) (result DependencyHealth) {
+	healthURL := strings.TrimSuffix(upstream.URL, "/") + upstream.HealthPathOrDefault()
+	result = DependencyHealth{
+		Name:     name,
+		URL:      healthURL,
+		Status:   dependencyDown,
+		Critical: upstream.Critical,
+	}
+
+	start := time.Now()
+	defer func() {
+		result.LatencyMS = time.Since(start).Milliseconds()
+	}()
+
+	req, err := http.NewRequestWithContext(ctx, "GET", healthURL, nil)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	resp, err := h.httpClient.Do(req)
+	if err != nil {
+		logger.Warn(ctx, "Upstream health probe failed", "upstream", name, "error", err)
+		result.Error = err.Error()
+		return result
+	}
+	defer resp.Body.Close()
+
+	// Health responses are small; cap the read in case an upstream misbehaves
+	var body struct {
+		Version string `json:"version"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 64<<10)).Decode(&body); err == nil {
+		result.Version = body.Version
+	}
+
+	if resp.StatusCode >= http.StatusOK && resp.StatusCode < http.StatusMultipleChoices {
+		result.Status = dependencyUp
+	} else {
+		result.Error = http.StatusText(resp.StatusCode)
+		logger.Warn(ctx, "Upstream reported unhealthy", "upstream", name, "status", resp.StatusCode)
+	}
+
+	return result
+}
+
+func (h *HealthHandler) writeResponse(ctx context.Context, w http.ResponseWriter, statusCode int, response HealthResponse) {
 	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
+	w.WriteHeader(statusCode)
 
 	if err := json.NewEncoder(w).Encode(response); err != nil {
 		logger.Error(ctx, "Failed to encode health response", "error", err)
@@ -34,5 +195,5 @@ func Health(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	logger.Info(ctx, "Health check completed successfully")
-}
\ No newline at end of file
+	logger.Info(ctx, "Health check completed successfully", "status", response.Status)
+}
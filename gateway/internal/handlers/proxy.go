@@ -1,173 +1,189 @@
 package handlers
 
 import (
+	"context"
+	"errors"
 	"io"
 	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strconv"
 	"strings"
+	"time"
 
+	"gateway/internal/breaker"
 	"gateway/internal/client"
 	"gateway/internal/config"
+	"gateway/internal/divert"
 	"gateway/internal/logger"
 )
 
+var errResponseTooLarge = errors.New("upstream response exceeds maximum body size")
+
 type ProxyHandler struct {
 	httpClient *client.HTTPClient
 	config     *config.Config
+	resolver   *divert.Resolver
 }
 
-func NewProxyHandler(httpClient *client.HTTPClient, config *config.Config) *ProxyHandler {
+func NewProxyHandler(httpClient *client.HTTPClient, config *config.Config, resolver *divert.Resolver) *ProxyHandler {
 	return &ProxyHandler{
 		httpClient: httpClient,
 		config:     config,
+		resolver:   resolver,
 	}
 }
 
-// Admin service proxy handlers
-func (p *ProxyHandler) ProxyAdminEmployees(w http.ResponseWriter, r *http.Request) {
-	p.proxyToService(w, r, p.config.AdminServiceURL, "/admin/employee")
-}
+// Route returns the handler forwarding requests for a single route table entry.
+// Request and response bodies are streamed, never buffered in the gateway.
+func (p *ProxyHandler) Route(route config.Route, serviceURL string) http.HandlerFunc {
+	proxy := &httputil.ReverseProxy{
+		Rewrite: func(pr *httputil.ProxyRequest) {
+			p.rewrite(pr, route, serviceURL)
+		},
+		Transport:      p.httpClient,
+		ModifyResponse: p.modifyResponse(route),
+		ErrorHandler:   p.handleError,
+	}
 
-func (p *ProxyHandler) ProxyAdminComplaints(w http.ResponseWriter, r *http.Request) {
-	p.proxyToService(w, r, p.config.AdminServiceURL, "/admin/complaint")
-}
+	maxRequestBody := int64(route.MaxRequestBody)
+	if maxRequestBody == 0 {
+		maxRequestBody = p.config.DefaultMaxRequestBody
+	}
 
-func (p *ProxyHandler) ProxyAdminHealth(w http.ResponseWriter, r *http.Request) {
-	p.proxyToService(w, r, p.config.AdminServiceURL, "/health")
-}
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
 
-func (p *ProxyHandler) ProxyAdminRoot(w http.ResponseWriter, r *http.Request) {
-	p.proxyToService(w, r, p.config.AdminServiceURL, "/")
-}
+		logger.Info(ctx, "Proxying request", "method", r.Method, "path", r.URL.Path, "upstream", route.Upstream)
 
-// Booking service proxy handlers
-func (p *ProxyHandler) ProxyBookingHealth(w http.ResponseWriter, r *http.Request) {
-	p.proxyToService(w, r, p.config.BookingServiceURL, "/health")
-}
+		// Answer CORS preflight requests without the upstream; their headers
+		// are set by router.EnableCORS
+		if r.Method == "OPTIONS" {
+			w.WriteHeader(http.StatusOK)
+			logger.Info(ctx, "Handled OPTIONS preflight request", "upstream", route.Upstream)
+			return
+		}
 
-func (p *ProxyHandler) ProxyBookingBook(w http.ResponseWriter, r *http.Request) {
-	p.proxyToService(w, r, p.config.BookingServiceURL, "/book")
-}
+		// Reject oversized bodies up front when the client declares the length;
+		// chunked bodies are cut off by MaxBytesReader while streaming
+		if maxRequestBody > 0 {
+			if r.ContentLength > maxRequestBody {
+				logger.Warn(ctx, "Request body too large", "content_length", r.ContentLength, "limit", maxRequestBody)
+				http.Error(w, "Request body too large", http.StatusRequestEntityTooLarge)
+				return
+			}
+			r.Body = http.MaxBytesReader(w, r.Body, maxRequestBody)
+		}
 
-func (p *ProxyHandler) ProxyBookingCancel(w http.ResponseWriter, r *http.Request) {
-	p.proxyToService(w, r, p.config.BookingServiceURL, "/cancel")
-}
+		if route.Streaming {
+			// Long-lived streams must outlive the server-wide write timeout
+			if err := http.NewResponseController(w).SetWriteDeadline(time.Time{}); err != nil {
+				logger.Warn(ctx, "Failed to clear write deadline for streaming route", "error", err)
+			}
+		} else if route.Timeout > 0 {
+			ctx, cancel := context.WithTimeout(ctx, time.Duration(route.Timeout))
+			defer cancel()
+			r = r.WithContext(ctx)
+		}
 
-// Booking-management service proxy handlers
-func (p *ProxyHandler) ProxyBookingMgmtHealthz(w http.ResponseWriter, r *http.Request) {
-	p.proxyToService(w, r, p.config.BookingManagementServiceURL, "/healthz")
+		proxy.ServeHTTP(w, r)
+	}
 }
 
-func (p *ProxyHandler) ProxyBookingMgmtUsers(w http.ResponseWriter, r *http.Request) {
-	p.proxyToService(w, r, p.config.BookingManagementServiceURL, "/users")
-}
+// rewrite points the outbound request at the (possibly diverted) upstream
+func (p *ProxyHandler) rewrite(pr *httputil.ProxyRequest, route config.Route, serviceURL string) {
+	ctx := pr.In.Context()
 
-func (p *ProxyHandler) ProxyBookingMgmtRooms(w http.ResponseWriter, r *http.Request) {
-	p.proxyToService(w, r, p.config.BookingManagementServiceURL, "/rooms")
-}
+	// Pick the diverted upstream if the request baggage asks for one
+	target, err := url.Parse(p.resolver.Resolve(ctx, serviceURL))
+	if err != nil {
+		logger.Error(ctx, "Failed to parse upstream URL", "error", err, "service", serviceURL)
+		target, _ = url.Parse(serviceURL)
+	}
 
-func (p *ProxyHandler) ProxyBookingMgmtBookings(w http.ResponseWriter, r *http.Request) {
-	p.proxyToService(w, r, p.config.BookingManagementServiceURL, "/bookings")
-}
+	// Use the escaped path so encoded path parameters reach the upstream unchanged
+	escapedPath := strings.TrimSuffix(target.EscapedPath(), "/") + route.TargetPath(pr.In.URL.EscapedPath())
+	path, err := url.PathUnescape(escapedPath)
+	if err != nil {
+		path = escapedPath
+	}
 
-func (p *ProxyHandler) ProxyBookingMgmtValidate(w http.ResponseWriter, r *http.Request) {
-	p.proxyToService(w, r, p.config.BookingManagementServiceURL, "/validate")
+	pr.Out.URL.Scheme = target.Scheme
+	pr.Out.URL.Host = target.Host
+	pr.Out.URL.Path = path
+	pr.Out.URL.RawPath = escapedPath
+	pr.Out.Host = ""
+	pr.SetXForwarded()
 }
 
-// Generic proxy method
-func (p *ProxyHandler) proxyToService(w http.ResponseWriter, r *http.Request, serviceURL, path string) {
-	ctx := r.Context()
+func (p *ProxyHandler) modifyResponse(route config.Route) func(*http.Response) error {
+	maxResponseBody := int64(route.MaxResponseBody)
 
-	logger.Info(ctx, "Proxying request", "method", r.Method, "path", path, "service", serviceURL)
-
-	// Handle OPTIONS requests directly for CORS preflight
-	if r.Method == "OPTIONS" {
-		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, baggage, Baggage, X-Requested-With")
-		w.Header().Set("Access-Control-Max-Age", "86400") // 24 hours
-		w.WriteHeader(http.StatusOK)
-		logger.Info(ctx, "Handled OPTIONS preflight request", "service", serviceURL)
-		return
-	}
+	return func(resp *http.Response) error {
+		ctx := resp.Request.Context()
 
-	// Read request body
-	body, err := io.ReadAll(r.Body)
-	if err != nil {
-		logger.Error(ctx, "Failed to read request body", "error", err)
-		http.Error(w, "Failed to read request body", http.StatusBadRequest)
-		return
-	}
-	defer r.Body.Close()
-
-	// Extract headers
-	headers := make(map[string]string)
-	for key, values := range r.Header {
-		if len(values) > 0 {
-			// Skip hop-by-hop headers
-			if p.isHopByHopHeader(key) {
-				continue
+		if maxResponseBody > 0 {
+			if resp.ContentLength > maxResponseBody {
+				return errResponseTooLarge
 			}
-			headers[key] = values[0]
+			resp.Body = &limitedBody{ReadCloser: resp.Body, remaining: maxResponseBody}
 		}
-	}
 
-	// Make the proxied request
-	targetURL := serviceURL + path
-	resp, err := p.httpClient.ProxyRequest(ctx, r.Method, targetURL, body, headers)
-	if err != nil {
-		logger.Error(ctx, "Failed to proxy request", "error", err, "service", serviceURL)
-		http.Error(w, "Service temporarily unavailable", http.StatusServiceUnavailable)
-		return
-	}
-	defer resp.Body.Close()
-
-	// Copy response headers (skip CORS headers as they're handled by gateway middleware)
-	for key, values := range resp.Header {
-		// Skip CORS-related headers to avoid conflicts with gateway CORS middleware
-		if strings.HasPrefix(strings.ToLower(key), "access-control-") {
-			continue
-		}
-		for _, value := range values {
-			w.Header().Add(key, value)
+		// Drop the upstream CORS headers so only those of the gateway CORS
+		// middleware reach the client
+		for key := range resp.Header {
+			if strings.HasPrefix(strings.ToLower(key), "access-control-") {
+				resp.Header.Del(key)
+			}
 		}
-	}
 
-	// Ensure CORS headers are set for all responses
-	w.Header().Set("Access-Control-Allow-Origin", "*")
-	w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-	w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, baggage, Baggage, X-Requested-With")
+		logger.Info(ctx, "Request proxied successfully", "status", resp.StatusCode, "upstream", route.Upstream)
+		return nil
+	}
+}
 
-	// Copy status code
-	w.WriteHeader(resp.StatusCode)
+func (p *ProxyHandler) handleError(w http.ResponseWriter, r *http.Request, err error) {
+	ctx := r.Context()
 
-	// Copy response body
-	_, err = io.Copy(w, resp.Body)
-	if err != nil {
-		logger.Error(ctx, "Failed to copy response body", "error", err)
-		return
+	var maxBytesErr *http.MaxBytesError
+	var openErr *breaker.OpenError
+	switch {
+	case errors.As(err, &maxBytesErr):
+		logger.Warn(ctx, "Request body too large", "limit", maxBytesErr.Limit)
+		http.Error(w, "Request body too large", http.StatusRequestEntityTooLarge)
+	case errors.Is(err, errResponseTooLarge):
+		logger.Error(ctx, "Upstream response too large", "error", err)
+		http.Error(w, "Upstream response too large", http.StatusBadGateway)
+	case errors.As(err, &openErr):
+		w.Header().Set("Retry-After", strconv.Itoa(int(openErr.RetryAfter.Round(time.Second).Seconds())+1))
+		http.Error(w, "Service temporarily unavailable", http.StatusServiceUnavailable)
+	case errors.Is(r.Context().Err(), context.Canceled):
+		// The client went away; the upstream call has already been cancelled
+		logger.Info(ctx, "Client disconnected before upstream responded", "path", r.URL.Path)
+	case errors.Is(err, context.DeadlineExceeded):
+		logger.Error(ctx, "Upstream request timed out", "error", err)
+		http.Error(w, "Upstream request timed out", http.StatusGatewayTimeout)
+	default:
+		logger.Error(ctx, "Failed to proxy request", "error", err)
+		http.Error(w, "Service temporarily unavailable", http.StatusServiceUnavailable)
 	}
+}
 
-	logger.Info(ctx, "Request proxied successfully", "status", resp.StatusCode, "service", serviceURL)
+// limitedBody fails the response stream once an upstream sends more than the
+// configured maximum, for responses whose length is not known up front.
+type limitedBody struct {
+	io.ReadCloser
+	remaining int64
 }
 
-// Check if header is hop-by-hop and should not be forwarded
-func (p *ProxyHandler) isHopByHopHeader(header string) bool {
-	hopByHopHeaders := []string{
-		"Connection",
-		"Keep-Alive",
-		"Proxy-Authenticate",
-		"Proxy-Authorization",
-		"Te",
-		"Trailers",
-		"Transfer-Encoding",
-		"Upgrade",
+func (b *limitedBody) Read(p []byte) (int, error) {
+	if b.remaining <= 0 {
+		return 0, errResponseTooLarge
 	}
-
-	header = strings.ToLower(header)
-	for _, hopByHop := range hopByHopHeaders {
-		if strings.ToLower(hopByHop) == header {
-			return true
-		}
+	if int64(len(p)) > b.remaining {
+		p = p[:b.remaining]
 	}
-	return false
-}
\ No newline at end of file
+	n, err := b.ReadCloser.Read(p)
+	b.remaining -= int64(n)
+	return n, err
+}
@@ -0,0 +1,43 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"gateway/internal/breaker"
+	"gateway/internal/logger"
+)
+
+type UpstreamsResponse struct {
+	Upstreams []breaker.Snapshot `json:"upstreams"`
+	Timestamp string             `json:"timestamp"`
+}
+
+type UpstreamsHandler struct {
+	breakers *breaker.Registry
+}
+
+func NewUpstreamsHandler(breakers *breaker.Registry) *UpstreamsHandler {
+	return &UpstreamsHandler{breakers: breakers}
+}
+
+// Upstreams reports the circuit breaker state of every known upstream
+func (h *UpstreamsHandler) Upstreams(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	logger.Info(ctx, "Upstream status requested")
+
+	response := UpstreamsResponse{
+		Upstreams: h.breakers.Snapshots(),
+		Timestamp: time.Now().UTC().Format(time.RFC3339),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		logger.Error(ctx, "Failed to encode upstreams response", "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+}
@@ -5,6 +5,8 @@ import (
 	"log/slog"
 	"os"
 
+	"go.opentelemetry.io/otel/trace"
+
 	"gateway/internal/middleware"
 )
 
@@ -19,6 +21,9 @@ func logWithBaggage(ctx context.Context, level slog.Level, msg string, args ...a
 	if baggage != "" {
 		args = append(args, "baggage", baggage)
 	}
+	if spanCtx := trace.SpanContextFromContext(ctx); spanCtx.IsValid() {
+		args = append(args, "trace_id", spanCtx.TraceID().String(), "span_id", spanCtx.SpanID().String())
+	}
 	defaultLogger.Log(ctx, level, msg, args...)
 }
 
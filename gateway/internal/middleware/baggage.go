@@ -3,6 +3,8 @@ package middleware
 import (
 	"context"
 	"net/http"
+	"net/url"
+	"strings"
 )
 
 type baggageKey struct{}
@@ -35,4 +37,39 @@ func GetBaggageFromContext(ctx context.Context) string {
 		return baggage
 	}
 	return ""
-}
\ No newline at end of file
+}
+
+// GetBaggageMember returns the value of a single W3C baggage list member
+// carried in the context, or an empty string if it is not present.
+func GetBaggageMember(ctx context.Context, key string) string {
+	return ParseBaggage(GetBaggageFromContext(ctx))[key]
+}
+
+// ParseBaggage parses a W3C baggage header value into its key/value members.
+// Member properties are ignored and values are percent-decoded.
+func ParseBaggage(baggage string) map[string]string {
+	members := make(map[string]string)
+	for _, member := range strings.Split(baggage, ",") {
+		// Drop member properties, e.g. "key=value;prop=1"
+		if i := strings.Index(member, ";"); i >= 0 {
+			member = member[:i]
+		}
+
+		key, value, found := strings.Cut(member, "=")
+		if !found {
+			continue
+		}
+
+		key = strings.TrimSpace(key)
+		value = strings.TrimSpace(value)
+		if key == "" {
+			continue
+		}
+
+		if decoded, err := url.PathUnescape(value); err == nil {
+			value = decoded
+		}
+		members[key] = value
+	}
+	return members
+}
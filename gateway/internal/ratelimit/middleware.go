@@ -0,0 +1,111 @@
+package ratelimit
+
+import (
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"gateway/internal/auth"
+	"gateway/internal/logger"
+)
+
+// Key strategies for identifying the client a bucket belongs to.
+const (
+	KeyIP      = "ip"
+	KeyAPIKey  = "apikey"
+	KeySubject = "subject"
+)
+
+// APIKeyHeader carries the client API key for the apikey strategy.
+const APIKeyHeader = "X-API-Key"
+
+// Policy is the rate limit applied to one route.
+type Policy struct {
+	Name  string
+	Limit Limit
+	Key   string
+}
+
+type Limiter struct {
+	store             Store
+	trustForwardedFor bool
+}
+
+func NewLimiter(store Store, trustForwardedFor bool) *Limiter {
+	return &Limiter{store: store, trustForwardedFor: trustForwardedFor}
+}
+
+// Wrap enforces the policy before calling next, answering 429 with
+// Retry-After once the client's bucket is empty. Every response carries the
+// RateLimit-Limit, RateLimit-Remaining and RateLimit-Reset headers.
+func (l *Limiter) Wrap(policy Policy, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		// CORS preflight requests are not counted
+		if r.Method == "OPTIONS" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		key := policy.Name + "|" + l.clientKey(r, policy.Key)
+		result, err := l.store.Take(ctx, key, policy.Limit)
+		if err != nil {
+			// Fail open: an unavailable store must not take the gateway down
+			logger.Error(ctx, "Rate limit store failed, allowing request", "error", err, "route", policy.Name)
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		w.Header().Set("RateLimit-Limit", strconv.Itoa(policy.Limit.Burst))
+		w.Header().Set("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+		w.Header().Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(result.Reset)))
+
+		if !result.Allowed {
+			logger.Warn(ctx, "Rate limit exceeded", "route", policy.Name, "key", key)
+			w.Header().Set("Retry-After", strconv.Itoa(ceilSeconds(result.RetryAfter)))
+			http.Error(w, "Too many requests", http.StatusTooManyRequests)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// clientKey identifies the caller, falling back to the client IP when the
+// requested identity is not present on the request.
+func (l *Limiter) clientKey(r *http.Request, strategy string) string {
+	switch strategy {
+	case KeyAPIKey:
+		if apiKey := r.Header.Get(APIKeyHeader); apiKey != "" {
+			return "apikey:" + apiKey
+		}
+	case KeySubject:
+		if principal := auth.GetPrincipalFromContext(r.Context()); principal != nil {
+			return "sub:" + principal.Subject
+		}
+	}
+	return "ip:" + l.clientIP(r)
+}
+
+func (l *Limiter) clientIP(r *http.Request) string {
+	if l.trustForwardedFor {
+		if forwarded := r.Header.Get("X-Forwarded-For"); forwarded != "" {
+			client, _, _ := strings.Cut(forwarded, ",")
+			return strings.TrimSpace(client)
+		}
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,46 @@
+package ratelimit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestWrapRejectsEmptyBucket(t *testing.T) {
+	store, _ := newTestStore(t)
+	limiter := NewLimiter(store, false)
+	handler := limiter.Wrap(Policy{Name: "/booking/book", Limit: Limit{Rate: 0.5, Burst: 1}, Key: KeyIP},
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }))
+
+	send := func(method, remoteAddr string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/booking/book", nil)
+		req.RemoteAddr = remoteAddr
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	if rec := send("POST", "10.0.0.1:1234"); rec.Code != http.StatusOK || rec.Header().Get("RateLimit-Remaining") != "0" {
+		t.Fatalf("first request = %d with RateLimit-Remaining %q, want 200 with 0", rec.Code, rec.Header().Get("RateLimit-Remaining"))
+	}
+	// Preflight requests are not counted
+	if rec := send("OPTIONS", "10.0.0.1:1234"); rec.Code != http.StatusOK {
+		t.Errorf("preflight request = %d, want 200", rec.Code)
+	}
+
+	rec := send("POST", "10.0.0.1:5678")
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("second request = %d, want 429", rec.Code)
+	}
+	if got := rec.Header().Get("Retry-After"); got != "2" {
+		t.Errorf("Retry-After = %q, want 2", got)
+	}
+	if got := rec.Header().Get("RateLimit-Limit"); got != "1" {
+		t.Errorf("RateLimit-Limit = %q, want 1", got)
+	}
+
+	// Another client has its own bucket
+	if rec := send("POST", "10.0.0.2:1234"); rec.Code != http.StatusOK {
+		t.Errorf("request of another client = %d, want 200", rec.Code)
+	}
+}
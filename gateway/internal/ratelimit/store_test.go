@@ -0,0 +1,83 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// newTestStore returns a store whose clock only moves when advance is called.
+func newTestStore(t *testing.T) (store *MemoryStore, advance func(time.Duration)) {
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	now := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	store = NewMemoryStore(ctx, time.Hour)
+	store.now = func() time.Time { return now }
+	return store, func(d time.Duration) { now = now.Add(d) }
+}
+
+func TestMemoryStoreRefill(t *testing.T) {
+	// 2 tokens per second, up to 4
+	limit := Limit{Rate: 2, Burst: 4}
+
+	steps := []struct {
+		name       string
+		advance    time.Duration
+		allowed    bool
+		remaining  int
+		retryAfter time.Duration
+	}{
+		{"starts full", 0, true, 3, 0},
+		{"takes a token", 0, true, 2, 0},
+		{"takes a token", 0, true, 1, 0},
+		{"takes the last token", 0, true, 0, 0},
+		{"empty", 0, false, 0, 500 * time.Millisecond},
+		{"half a token refilled", 250 * time.Millisecond, false, 0, 250 * time.Millisecond},
+		{"a whole token refilled", 250 * time.Millisecond, true, 0, 0},
+		{"one token per half second", 500 * time.Millisecond, true, 0, 0},
+		{"refill is capped at the burst", time.Hour, true, 3, 0},
+	}
+
+	store, advance := newTestStore(t)
+	for i, step := range steps {
+		advance(step.advance)
+		result, err := store.Take(context.Background(), "client", limit)
+		if err != nil {
+			t.Fatalf("step %d (%s): Take failed: %v", i, step.name, err)
+		}
+		if result.Allowed != step.allowed || result.Remaining != step.remaining || result.RetryAfter != step.retryAfter {
+			t.Fatalf("step %d (%s): Take = %+v, want allowed %v, remaining %d, retry after %s",
+				i, step.name, result, step.allowed, step.remaining, step.retryAfter)
+		}
+	}
+}
+
+func TestMemoryStoreReset(t *testing.T) {
+	store, _ := newTestStore(t)
+	limit := Limit{Rate: 2, Burst: 4}
+
+	var result Result
+	for range 3 {
+		result, _ = store.Take(context.Background(), "client", limit)
+	}
+	// 3 tokens taken at 2 per second
+	if result.Reset != 1500*time.Millisecond {
+		t.Errorf("Reset = %s, want 1.5s", result.Reset)
+	}
+}
+
+func TestMemoryStoreKeysAreIndependent(t *testing.T) {
+	store, _ := newTestStore(t)
+	limit := Limit{Rate: 1, Burst: 1}
+
+	if result, _ := store.Take(context.Background(), "a", limit); !result.Allowed {
+		t.Fatal("first request of a was rejected")
+	}
+	if result, _ := store.Take(context.Background(), "a", limit); result.Allowed {
+		t.Error("second request of a was allowed")
+	}
+	if result, _ := store.Take(context.Background(), "b", limit); !result.Allowed {
+		t.Error("first request of b was rejected")
+	}
+}
@@ -1,60 +1,135 @@
 package router
 
 import (
+	"context"
 	"net/http"
+	"net/url"
+	"time"
 
 	"github.com/gorilla/mux"
 
+	"gateway/internal/auth"
+	"gateway/internal/breaker"
 	"gateway/internal/client"
 	"gateway/internal/config"
+	"gateway/internal/divert"
 	"gateway/internal/handlers"
+	"gateway/internal/metrics"
 	"gateway/internal/middleware"
+	"gateway/internal/ratelimit"
+	"gateway/internal/tracing"
 )
 
-func NewRouter(cfg *config.Config) *mux.Router {
+// NewRouter builds the gateway router. A nil authenticator disables
+// authentication and the per-route role requirements.
+func NewRouter(cfg *config.Config, routes *config.RouteTable, authenticator *auth.Authenticator) *mux.Router {
 	r := mux.NewRouter()
 
 	// Add middleware
+	r.Use(tracing.Middleware)
+	r.Use(metrics.Middleware)
 	r.Use(middleware.BaggageMiddleware)
+	if authenticator != nil {
+		r.Use(authenticator.Middleware)
+	} else {
+		r.Use(auth.StripTrustedHeaders)
+	}
 
-	// Create HTTP client and proxy handler
-	httpClient := client.NewHTTPClient()
-	proxyHandler := handlers.NewProxyHandler(httpClient, cfg)
+	// Create circuit breakers, HTTP client, divert resolver and proxy handler
+	breakers := newBreakerRegistry(cfg, routes)
+	httpClient := client.NewHTTPClient(breakers, client.RetryPolicy{
+		MaxRetries: cfg.RetryMax,
+		BaseDelay:  cfg.RetryBaseDelay,
+		MaxDelay:   cfg.RetryMaxDelay,
+	})
+	resolver := divert.NewResolver(cfg.DivertBaggageKey, cfg.DivertProbeTimeout, cfg.DivertProbeCacheTTL)
+	proxyHandler := handlers.NewProxyHandler(httpClient, cfg, resolver)
+	limiter := ratelimit.NewLimiter(ratelimit.NewMemoryStore(context.Background(), time.Minute), cfg.RateLimitTrustForwardedFor)
 
 	// Gateway routes
 	r.HandleFunc("/", handlers.Gateway).Methods("GET")
-	r.HandleFunc("/healthz", handlers.Health).Methods("GET")
-
-	// Admin service proxy routes
-	r.HandleFunc("/admin", proxyHandler.ProxyAdminRoot).Methods("GET", "OPTIONS")
-	r.HandleFunc("/admin/", proxyHandler.ProxyAdminRoot).Methods("GET", "OPTIONS")
-	r.HandleFunc("/admin/health", proxyHandler.ProxyAdminHealth).Methods("GET", "OPTIONS")
-	r.HandleFunc("/admin/employee", proxyHandler.ProxyAdminEmployees).Methods("GET", "POST", "OPTIONS")
-	r.HandleFunc("/admin/complaint", proxyHandler.ProxyAdminComplaints).Methods("GET", "POST", "OPTIONS")
-
-	// Booking service proxy routes
-	r.HandleFunc("/booking/health", proxyHandler.ProxyBookingHealth).Methods("GET", "OPTIONS")
-	r.HandleFunc("/booking/book", proxyHandler.ProxyBookingBook).Methods("POST", "OPTIONS")
-	r.HandleFunc("/booking/cancel", proxyHandler.ProxyBookingCancel).Methods("POST", "OPTIONS")
-
-	// Booking-management service proxy routes
-	r.HandleFunc("/booking-management/healthz", proxyHandler.ProxyBookingMgmtHealthz).Methods("GET", "OPTIONS")
-	r.HandleFunc("/booking-management/users", proxyHandler.ProxyBookingMgmtUsers).Methods("GET", "OPTIONS")
-	r.HandleFunc("/booking-management/rooms", proxyHandler.ProxyBookingMgmtRooms).Methods("GET", "OPTIONS")
-	r.HandleFunc("/booking-management/bookings", proxyHandler.ProxyBookingMgmtBookings).Methods("GET", "OPTIONS")
-	r.HandleFunc("/booking-management/validate", proxyHandler.ProxyBookingMgmtValidate).Methods("POST", "OPTIONS")
+	healthHandler := handlers.NewHealthHandler(routes.Upstreams, cfg.HealthProbeTimeout)
+	r.HandleFunc("/healthz", healthHandler.Health).Methods("GET")
+	r.HandleFunc("/readyz", healthHandler.Ready).Methods("GET")
+	r.HandleFunc("/gateway/upstreams", handlers.NewUpstreamsHandler(breakers).Upstreams).Methods("GET")
+	r.Handle("/metrics", metrics.Handler()).Methods("GET")
+
+	// Upstream proxy routes from the route table
+	for _, route := range routes.Routes {
+		var handler http.Handler = proxyHandler.Route(route, routes.Upstreams[route.Upstream].URL)
+		if authenticator != nil {
+			role, _ := auth.ParseRole(route.Role)
+			handler = auth.Require(role, handler)
+		}
+		if route.RateLimit != nil {
+			// Authentication has already run for every request, so the limit
+			// can be keyed on the subject; it applies before the role check,
+			// so callers without the role still spend tokens
+			handler = limiter.Wrap(ratelimit.Policy{
+				Name: route.Path,
+				Limit: ratelimit.Limit{
+					Rate:  float64(route.RateLimit.Requests) / time.Duration(route.RateLimit.Per).Seconds(),
+					Burst: route.RateLimit.Burst,
+				},
+				Key: route.RateLimit.Key,
+			}, handler)
+		}
+		methods := append([]string{"OPTIONS"}, route.Methods...)
+
+		if route.IsWildcard() {
+			r.PathPrefix(route.Prefix()).Handler(handler).Methods(methods...)
+		} else {
+			r.Handle(route.Path, handler).Methods(methods...)
+		}
+	}
 
 	return r
 }
 
-// CORS middleware
+// newBreakerRegistry registers a circuit breaker for every configured upstream,
+// applying per-upstream overrides on top of the gateway-wide settings
+func newBreakerRegistry(cfg *config.Config, routes *config.RouteTable) *breaker.Registry {
+	defaults := breaker.Settings{
+		FailureThreshold: cfg.BreakerFailureThreshold,
+		OpenTimeout:      cfg.BreakerOpenTimeout,
+		HalfOpenRequests: cfg.BreakerHalfOpenRequests,
+	}
+	registry := breaker.NewRegistry(defaults)
+
+	for name, upstream := range routes.Upstreams {
+		u, err := url.Parse(upstream.URL)
+		if err != nil {
+			continue
+		}
+
+		settings := defaults
+		if upstream.Breaker.FailureThreshold > 0 {
+			settings.FailureThreshold = upstream.Breaker.FailureThreshold
+		}
+		if upstream.Breaker.OpenTimeout > 0 {
+			settings.OpenTimeout = time.Duration(upstream.Breaker.OpenTimeout)
+		}
+		if upstream.Breaker.HalfOpenRequests > 0 {
+			settings.HalfOpenRequests = upstream.Breaker.HalfOpenRequests
+		}
+		registry.Register(name, u.Host, settings)
+	}
+
+	return registry
+}
+
+// EnableCORS sets the CORS headers of every gateway response, proxied or
+// not, and answers preflight requests itself. It is the only place CORS
+// headers are set; the proxy drops those sent by upstreams.
 func EnableCORS(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, baggage, Baggage")
+		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, baggage, Baggage, traceparent, tracestate, Idempotency-Key, If-Match, X-Requested-With")
+		w.Header().Set("Access-Control-Expose-Headers", "RateLimit-Limit, RateLimit-Remaining, RateLimit-Reset, Retry-After, Idempotent-Replayed, ETag, Location, Link")
 
 		if r.Method == "OPTIONS" {
+			w.Header().Set("Access-Control-Max-Age", "86400") // 24 hours
 			w.WriteHeader(http.StatusOK)
 			return
 		}
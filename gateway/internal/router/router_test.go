@@ -0,0 +1,232 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/gorilla/mux"
+
+	"gateway/internal/auth"
+	"gateway/internal/config"
+)
+
+const testSecret = "secret"
+
+// testUpstream counts the requests that reach it and keeps the last path.
+type testUpstream struct {
+	hits atomic.Int32
+	path atomic.Value
+}
+
+// newTestRouter builds the router of the default route table, with
+// authentication enabled and every upstream pointing at the returned server.
+func newTestRouter(t *testing.T) (*mux.Router, *testUpstream) {
+	t.Helper()
+
+	upstream := &testUpstream{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		upstream.hits.Add(1)
+		upstream.path.Store(r.URL.Path)
+		w.Header().Set("Access-Control-Allow-Origin", "https://upstream.example")
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(server.Close)
+
+	cfg := config.Load()
+	cfg.RoutesFile = ""
+	cfg.AdminServiceURL = server.URL
+	cfg.BookingServiceURL = server.URL
+	cfg.BookingManagementServiceURL = server.URL
+
+	routes, err := config.LoadRoutes(cfg)
+	if err != nil {
+		t.Fatalf("failed to load routes: %v", err)
+	}
+	authenticator, err := auth.NewAuthenticator(auth.Options{HS256Secret: testSecret, RolesClaim: "roles"})
+	if err != nil {
+		t.Fatalf("failed to create authenticator: %v", err)
+	}
+	return NewRouter(cfg, routes, authenticator), upstream
+}
+
+// bearer returns an Authorization header value for alice with role.
+func bearer(t *testing.T, role string) string {
+	t.Helper()
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"sub":   "alice",
+		"roles": role,
+		"exp":   time.Now().Add(time.Hour).Unix(),
+	}).SignedString([]byte(testSecret))
+	if err != nil {
+		t.Fatalf("failed to sign token: %v", err)
+	}
+	return "Bearer " + token
+}
+
+// TestDefaultRoutesRequireRoles sends anonymous requests through the default
+// route table with authentication enabled. A request a protected route
+// rejects by method must not fall through to a weaker route, and the
+// booking-management catch-all needs a role too.
+func TestDefaultRoutesRequireRoles(t *testing.T) {
+	r, upstream := newTestRouter(t)
+
+	tests := []struct {
+		method string
+		path   string
+		want   int
+	}{
+		{"GET", "/booking-management/users", http.StatusUnauthorized},
+		{"GET", "/booking-management/rooms", http.StatusUnauthorized},
+		{"GET", "/booking-management/bookings", http.StatusUnauthorized},
+		{"GET", "/booking-management/users/1", http.StatusUnauthorized},
+		{"GET", "/booking-management/rooms/room_101", http.StatusUnauthorized},
+		{"GET", "/booking-management/bookings/1", http.StatusUnauthorized},
+		{"GET", "/booking-management/bookings/external/booking_1", http.StatusUnauthorized},
+		{"POST", "/booking-management/validate", http.StatusUnauthorized},
+		{"POST", "/booking-management/reservations", http.StatusUnauthorized},
+		{"POST", "/booking-management/rooms/available", http.StatusUnauthorized},
+		{"GET", "/booking-management/reservations/booking_1/release", http.StatusUnauthorized},
+		{"GET", "/booking-management/metrics", http.StatusUnauthorized},
+		{"GET", "/booking-management/reports/occupancy", http.StatusUnauthorized},
+		{"GET", "/booking/cancel", http.StatusMethodNotAllowed},
+		{"GET", "/booking/status/booking_1", http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			upstream.hits.Store(0)
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, httptest.NewRequest(tt.method, tt.path, nil))
+
+			if rec.Code != tt.want {
+				t.Errorf("status = %d, want %d", rec.Code, tt.want)
+			}
+			if n := upstream.hits.Load(); n != 0 {
+				t.Errorf("request reached the upstream %d times", n)
+			}
+		})
+	}
+
+	// Public routes still reach the upstream anonymously
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest("GET", "/booking-management/healthz", nil))
+	if rec.Code != http.StatusOK || upstream.hits.Load() != 1 {
+		t.Errorf("GET /booking-management/healthz = %d with %d upstream hits, want 200 with 1", rec.Code, upstream.hits.Load())
+	}
+}
+
+// TestBookingManagementCatchAll checks that booking-management endpoints
+// without a route of their own are forwarded to staff only, and that a guest
+// request falling through to the catch-all is rejected.
+func TestBookingManagementCatchAll(t *testing.T) {
+	r, upstream := newTestRouter(t)
+
+	tests := []struct {
+		name     string
+		method   string
+		path     string
+		role     string
+		want     int
+		wantPath string
+	}{
+		{"guest on a new endpoint", "GET", "/booking-management/reports/occupancy", "guest", http.StatusForbidden, ""},
+		{"staff on a new endpoint", "GET", "/booking-management/reports/occupancy", "staff", http.StatusOK, "/reports/occupancy"},
+		{"admin on a new endpoint", "DELETE", "/booking-management/reports/occupancy", "admin", http.StatusOK, "/reports/occupancy"},
+		{"guest falling through by method", "POST", "/booking-management/rooms/available", "guest", http.StatusForbidden, ""},
+		{"guest on a public route", "GET", "/booking-management/rooms/available", "guest", http.StatusOK, "/rooms/available"},
+		{"staff on an admin route", "POST", "/booking-management/reservations", "staff", http.StatusForbidden, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			upstream.hits.Store(0)
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			req.Header.Set("Authorization", bearer(t, tt.role))
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, req)
+
+			if rec.Code != tt.want {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.want)
+			}
+			if tt.wantPath == "" {
+				if n := upstream.hits.Load(); n != 0 {
+					t.Errorf("request reached the upstream %d times", n)
+				}
+				return
+			}
+			if path := upstream.path.Load(); path != tt.wantPath {
+				t.Errorf("upstream path = %v, want %s", path, tt.wantPath)
+			}
+		})
+	}
+}
+
+// TestEnableCORS checks that proxied responses carry the gateway CORS headers
+// only, and that preflight requests never reach the upstream.
+func TestEnableCORS(t *testing.T) {
+	r, upstream := newTestRouter(t)
+	handler := EnableCORS(r)
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("GET", "/booking-management/healthz", nil))
+	if got := rec.Header().Values("Access-Control-Allow-Origin"); len(got) != 1 || got[0] != "*" {
+		t.Errorf("Access-Control-Allow-Origin = %q, want only *", got)
+	}
+	if got := rec.Header().Get("Access-Control-Allow-Headers"); !strings.Contains(got, "X-Requested-With") || !strings.Contains(got, "Idempotency-Key") {
+		t.Errorf("Access-Control-Allow-Headers = %q", got)
+	}
+
+	upstream.hits.Store(0)
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("OPTIONS", "/booking/book", nil))
+	if rec.Code != http.StatusOK || rec.Header().Get("Access-Control-Max-Age") != "86400" {
+		t.Errorf("preflight = %d with Access-Control-Max-Age %q", rec.Code, rec.Header().Get("Access-Control-Max-Age"))
+	}
+	if n := upstream.hits.Load(); n != 0 {
+		t.Errorf("preflight reached the upstream %d times", n)
+	}
+}
+
+// TestMiddlewareOrder checks the order requests pass the gateway in:
+// authentication, then the route rate limit, then the route role check.
+func TestMiddlewareOrder(t *testing.T) {
+	r, upstream := newTestRouter(t)
+
+	send := func(authorization string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/booking/book", nil)
+		req.RemoteAddr = "10.0.0.1:1234"
+		if authorization != "" {
+			req.Header.Set("Authorization", authorization)
+		}
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+		return rec
+	}
+
+	// An invalid token is rejected by authentication before it spends a token
+	rec := send("Bearer not.a.jwt")
+	if rec.Code != http.StatusUnauthorized || rec.Header().Get("RateLimit-Remaining") != "" {
+		t.Fatalf("invalid token = %d with RateLimit-Remaining %q, want 401 without it", rec.Code, rec.Header().Get("RateLimit-Remaining"))
+	}
+
+	// Anonymous callers fail the role check but still spend the tokens of
+	// their IP, 10 at most
+	for i := range 10 {
+		if rec := send(""); rec.Code != http.StatusUnauthorized || rec.Header().Get("RateLimit-Remaining") != strconv.Itoa(9-i) {
+			t.Fatalf("anonymous request %d = %d with RateLimit-Remaining %q", i+1, rec.Code, rec.Header().Get("RateLimit-Remaining"))
+		}
+	}
+	if rec := send(""); rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("anonymous request over the limit = %d, want 429", rec.Code)
+	}
+
+	// An authenticated caller from the same IP has a bucket of their own,
+	// keyed on the subject
+	if rec := send(bearer(t, "guest")); rec.Code != http.StatusOK || upstream.hits.Load() != 1 {
+		t.Errorf("authenticated request = %d with %d upstream hits, want 200 with 1", rec.Code, upstream.hits.Load())
+	}
+}
@@ -9,9 +9,11 @@ import (
 	"syscall"
 	"time"
 
+	"gateway/internal/auth"
 	"gateway/internal/config"
 	"gateway/internal/logger"
 	"gateway/internal/router"
+	"gateway/internal/tracing"
 )
 
 func main() {
@@ -20,8 +22,45 @@ func main() {
 
 	logger.Info(ctx, "Starting Gateway service", "port", cfg.Port)
 
+	// Set up tracing
+	shutdownTracing, err := tracing.Init(ctx, tracing.Options{
+		ServiceName:  "gateway",
+		OTLPEndpoint: cfg.OTLPEndpoint,
+		File:         cfg.TracesFile,
+	})
+	if err != nil {
+		logger.Error(ctx, "Failed to set up tracing", "error", err)
+		log.Fatal(err)
+	}
+
+	// Load route table
+	routes, err := config.LoadRoutes(cfg)
+	if err != nil {
+		logger.Error(ctx, "Failed to load route table", "error", err)
+		log.Fatal(err)
+	}
+
+	logger.Info(ctx, "Route table loaded", "routes", len(routes.Routes), "upstreams", len(routes.Upstreams))
+
+	// Set up authentication; disabled when no signing keys are configured
+	authenticator, err := auth.NewAuthenticator(auth.Options{
+		HS256Secret: cfg.JWTHS256Secret,
+		JWKSFile:    cfg.JWTJWKSFile,
+		Issuer:      cfg.JWTIssuer,
+		Audience:    cfg.JWTAudience,
+		RolesClaim:  cfg.JWTRolesClaim,
+		RoleMapping: cfg.JWTRoleMapping,
+	})
+	if err != nil {
+		logger.Error(ctx, "Failed to configure authentication", "error", err)
+		log.Fatal(err)
+	}
+	if authenticator == nil {
+		logger.Warn(ctx, "JWT authentication disabled, route role requirements are not enforced")
+	}
+
 	// Create router
-	r := router.NewRouter(cfg)
+	r := router.NewRouter(cfg, routes, authenticator)
 
 	// Apply CORS middleware
 	handler := router.EnableCORS(r)
@@ -61,5 +100,9 @@ func main() {
 		log.Fatal(err)
 	}
 
+	if err := shutdownTracing(shutdownCtx); err != nil {
+		logger.Error(ctx, "Failed to flush traces", "error", err)
+	}
+
 	logger.Info(ctx, "Gateway service stopped")
 }
\ No newline at end of file
@@ -41,7 +41,9 @@ app.get('/', (req, res) => {
     status: 'running',
     endpoints: {
       health: 'GET /health',
-      processPayment: 'POST /process-payment'
+      processPayment: 'POST /process-payment',
+      refund: 'POST /refund',
+      payment: 'GET /payments/:paymentId'
     }
   });
 });
@@ -1,6 +1,6 @@
 const express = require('express');
 const { createContextLogger } = require('../utils/logger');
-const { simulateStripePayment } = require('../utils/httpClient');
+const { simulateStripePayment, simulateStripeRefund, simulateStripeRetrieve } = require('../utils/httpClient');
 
 const router = express.Router();
 
@@ -112,4 +112,115 @@ router.post('/process-payment', async (req, res) => {
   }
 });
 
+/**
+ * Refund payment endpoint
+ * Gives back a processed payment, e.g. when the booking it paid for could not be completed
+ */
+router.post('/refund', async (req, res) => {
+  const log = createContextLogger(req);
+  const { paymentId, reason } = req.body;
+
+  log.info('Refund started', { paymentId, reason });
+
+  if (!paymentId) {
+    log.error('Invalid refund request - missing paymentId');
+
+    return res.status(400).json({
+      success: false,
+      error: 'Missing required field: paymentId is required'
+    });
+  }
+
+  try {
+    const result = await simulateStripeRefund(req, paymentId);
+
+    if (result.success) {
+      log.info('Payment refunded successfully', {
+        paymentId,
+        refundId: result.refundId,
+        status: result.status
+      });
+
+      res.json({
+        success: true,
+        paymentId,
+        refundId: result.refundId,
+        status: result.status,
+        message: 'Payment refunded successfully'
+      });
+    } else {
+      log.error('Refund failed', {
+        paymentId,
+        error: result.error,
+        status: result.status
+      });
+
+      res.status(422).json({
+        success: false,
+        paymentId,
+        error: result.error,
+        status: result.status
+      });
+    }
+  } catch (error) {
+    log.error('Unexpected error during refund', {
+      paymentId,
+      error: error.message,
+      stack: error.stack
+    });
+
+    res.status(500).json({
+      success: false,
+      paymentId,
+      error: 'Internal server error during refund'
+    });
+  }
+});
+
+/**
+ * Payment status endpoint
+ * Tells a caller that lost the response of a payment or refund what happened:
+ * processing, completed, declined, failed or refunded
+ */
+router.get('/payments/:paymentId', async (req, res) => {
+  const log = createContextLogger(req);
+  const { paymentId } = req.params;
+
+  log.info('Payment lookup started', { paymentId });
+
+  try {
+    const payment = await simulateStripeRetrieve(req, paymentId);
+
+    if (!payment) {
+      log.info('Payment not found', { paymentId });
+
+      return res.status(404).json({
+        success: false,
+        paymentId,
+        error: 'Payment not found'
+      });
+    }
+
+    log.info('Payment found', { paymentId, status: payment.status });
+
+    res.json({
+      success: true,
+      paymentId,
+      status: payment.status
+    });
+  } catch (error) {
+    log.error('Unexpected error during payment lookup', {
+      paymentId,
+      error: error.message,
+      stack: error.stack
+    });
+
+    res.status(500).json({
+      success: false,
+      paymentId,
+      error: 'Internal server error during payment lookup'
+    });
+  }
+});
+
 module.exports = router;
\ No newline at end of file
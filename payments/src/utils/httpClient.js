@@ -1,5 +1,10 @@
 // Note: axios and baggage imports removed as no longer needed for local simulation
 
+// Status of every payment seen, like the provider's own records, so callers
+// can find out what happened to a payment whose response they lost. Kept in
+// memory, so it is lost when the service restarts.
+const payments = new Map();
+
 /**
  * Simulate external payment service call (like Stripe)
  * @param {Object} req - Express request object
@@ -8,6 +13,13 @@
  * @returns {Promise} - Payment processing result
  */
 const simulateStripePayment = async (req, paymentId, cardNumber) => {
+  payments.set(paymentId, 'processing');
+  const result = await chargeCard(cardNumber);
+  payments.set(paymentId, result.status);
+  return result;
+};
+
+const chargeCard = async (cardNumber) => {
   // Local mock simulation - no external HTTP calls
   // Simulate processing time with a small delay
   await new Promise(resolve => setTimeout(resolve, 100 + Math.random() * 200));
@@ -47,6 +59,49 @@ const simulateStripePayment = async (req, paymentId, cardNumber) => {
   }
 };
 
+/**
+ * Simulate an external refund call (like Stripe)
+ * @param {Object} req - Express request object
+ * @param {string} paymentId - Payment ID to refund
+ * @returns {Promise} - Refund result
+ */
+const simulateStripeRefund = async (req, paymentId) => {
+  // Local mock simulation - no external HTTP calls
+  await new Promise(resolve => setTimeout(resolve, 50 + Math.random() * 100));
+
+  // Special test payment ID for a refund the provider rejects
+  if (paymentId === 'pay_refund_fail') {
+    return {
+      success: false,
+      error: 'Refund rejected',
+      status: 'failed'
+    };
+  }
+
+  payments.set(paymentId, 'refunded');
+  return {
+    success: true,
+    refundId: `re_${Date.now()}_${Math.random().toString(36).substr(2, 9)}`,
+    status: 'refunded'
+  };
+};
+
+/**
+ * Simulate looking up a payment at an external service (like Stripe)
+ * @param {Object} req - Express request object
+ * @param {string} paymentId - Payment ID to look up
+ * @returns {Promise} - The payment's status, or null if it was never charged
+ */
+const simulateStripeRetrieve = async (req, paymentId) => {
+  // Local mock simulation - no external HTTP calls
+  await new Promise(resolve => setTimeout(resolve, 20 + Math.random() * 30));
+
+  const status = payments.get(paymentId);
+  return status ? { paymentId, status } : null;
+};
+
 module.exports = {
-  simulateStripePayment
+  simulateStripePayment,
+  simulateStripeRefund,
+  simulateStripeRetrieve
 };
\ No newline at end of file
@@ -29,7 +29,9 @@ describe('Payments Service Integration Tests', () => {
         status: 'running',
         endpoints: {
           health: 'GET /health',
-          processPayment: 'POST /process-payment'
+          processPayment: 'POST /process-payment',
+          refund: 'POST /refund',
+          payment: 'GET /payments/:paymentId'
         }
       });
     });
@@ -182,6 +184,98 @@ describe('Payments Service Integration Tests', () => {
     });
   });
 
+  describe('Refund Flow', () => {
+    test('should refund a processed payment', async () => {
+      await request(app)
+        .post('/process-payment')
+        .send({
+          paymentId: 'pay_refund_flow_test',
+          cardNumber: '4242424242424242'
+        })
+        .expect(200);
+
+      const response = await request(app)
+        .post('/refund')
+        .set('baggage', 'trace-id=refund-flow123')
+        .send({ paymentId: 'pay_refund_flow_test', reason: 'Booking event publishing failed' })
+        .expect(200);
+
+      expect(response.body).toEqual({
+        success: true,
+        paymentId: 'pay_refund_flow_test',
+        refundId: expect.stringMatching(/^re_\d+_[a-z0-9]{9}$/),
+        status: 'refunded',
+        message: 'Payment refunded successfully'
+      });
+    });
+
+    test('should handle rejected refunds', async () => {
+      const response = await request(app)
+        .post('/refund')
+        .send({ paymentId: 'pay_refund_fail' })
+        .expect(422);
+
+      expect(response.body).toEqual({
+        success: false,
+        paymentId: 'pay_refund_fail',
+        error: 'Refund rejected',
+        status: 'failed'
+      });
+    });
+  });
+
+  describe('Payment Status', () => {
+    test('should report a processed and then refunded payment', async () => {
+      await request(app)
+        .post('/process-payment')
+        .send({ paymentId: 'pay_status_flow_test', cardNumber: '4242424242424242' })
+        .expect(200);
+
+      let response = await request(app)
+        .get('/payments/pay_status_flow_test')
+        .expect(200);
+      expect(response.body).toEqual({
+        success: true,
+        paymentId: 'pay_status_flow_test',
+        status: 'completed'
+      });
+
+      await request(app)
+        .post('/refund')
+        .send({ paymentId: 'pay_status_flow_test' })
+        .expect(200);
+
+      response = await request(app)
+        .get('/payments/pay_status_flow_test')
+        .expect(200);
+      expect(response.body.status).toBe('refunded');
+    });
+
+    test('should report a declined payment', async () => {
+      await request(app)
+        .post('/process-payment')
+        .send({ paymentId: 'pay_status_declined_test', cardNumber: '4000000000000002' })
+        .expect(422);
+
+      const response = await request(app)
+        .get('/payments/pay_status_declined_test')
+        .expect(200);
+      expect(response.body.status).toBe('declined');
+    });
+
+    test('should return 404 for a payment never processed', async () => {
+      const response = await request(app)
+        .get('/payments/pay_never_processed')
+        .expect(404);
+
+      expect(response.body).toEqual({
+        success: false,
+        paymentId: 'pay_never_processed',
+        error: 'Payment not found'
+      });
+    });
+  });
+
   describe('Error Handling', () => {
     test('should handle processing errors with special card numbers', async () => {
       // Test with special card number for processing error
@@ -6,7 +6,7 @@ jest.mock('../../../src/utils/logger');
 jest.mock('../../../src/utils/httpClient');
 
 const { createContextLogger } = require('../../../src/utils/logger');
-const { simulateStripePayment } = require('../../../src/utils/httpClient');
+const { simulateStripePayment, simulateStripeRefund, simulateStripeRetrieve } = require('../../../src/utils/httpClient');
 
 // Import and setup app
 const express = require('express');
@@ -288,4 +288,139 @@ describe('Payment Routes', () => {
       });
     });
   });
+
+  describe('POST /refund', () => {
+    test('should refund payment successfully', async () => {
+      simulateStripeRefund.mockResolvedValue({
+        success: true,
+        refundId: 're_1234567890',
+        status: 'refunded'
+      });
+
+      const response = await request(app)
+        .post('/refund')
+        .send({ paymentId: 'pay_test123', reason: 'Booking event publishing failed' })
+        .expect(200);
+
+      expect(response.body).toEqual({
+        success: true,
+        paymentId: 'pay_test123',
+        refundId: 're_1234567890',
+        status: 'refunded',
+        message: 'Payment refunded successfully'
+      });
+      expect(simulateStripeRefund).toHaveBeenCalledWith(expect.anything(), 'pay_test123');
+    });
+
+    test('should return 400 for missing paymentId', async () => {
+      const response = await request(app)
+        .post('/refund')
+        .send({ reason: 'Booking event publishing failed' })
+        .expect(400);
+
+      expect(response.body).toEqual({
+        success: false,
+        error: 'Missing required field: paymentId is required'
+      });
+      expect(simulateStripeRefund).not.toHaveBeenCalled();
+    });
+
+    test('should return 422 for rejected refund', async () => {
+      simulateStripeRefund.mockResolvedValue({
+        success: false,
+        error: 'Refund rejected',
+        status: 'failed'
+      });
+
+      const response = await request(app)
+        .post('/refund')
+        .send({ paymentId: 'pay_test123' })
+        .expect(422);
+
+      expect(response.body).toEqual({
+        success: false,
+        paymentId: 'pay_test123',
+        error: 'Refund rejected',
+        status: 'failed'
+      });
+    });
+
+    test('should return 500 for unexpected errors', async () => {
+      simulateStripeRefund.mockRejectedValue(new Error('Network error'));
+
+      const response = await request(app)
+        .post('/refund')
+        .send({ paymentId: 'pay_test123' })
+        .expect(500);
+
+      expect(response.body).toEqual({
+        success: false,
+        paymentId: 'pay_test123',
+        error: 'Internal server error during refund'
+      });
+    });
+
+    test('should log refund reason', async () => {
+      simulateStripeRefund.mockResolvedValue({
+        success: true,
+        refundId: 're_1234567890',
+        status: 'refunded'
+      });
+
+      await request(app)
+        .post('/refund')
+        .send({ paymentId: 'pay_test123', reason: 'Booking event publishing failed' })
+        .expect(200);
+
+      expect(mockLogger.info).toHaveBeenCalledWith('Refund started', {
+        paymentId: 'pay_test123',
+        reason: 'Booking event publishing failed'
+      });
+    });
+  });
+
+  describe('GET /payments/:paymentId', () => {
+    test('should return the status of a payment', async () => {
+      simulateStripeRetrieve.mockResolvedValue({ paymentId: 'pay_test123', status: 'completed' });
+
+      const response = await request(app)
+        .get('/payments/pay_test123')
+        .expect(200);
+
+      expect(response.body).toEqual({
+        success: true,
+        paymentId: 'pay_test123',
+        status: 'completed'
+      });
+      expect(simulateStripeRetrieve).toHaveBeenCalledWith(expect.anything(), 'pay_test123');
+    });
+
+    test('should return 404 for an unknown payment', async () => {
+      simulateStripeRetrieve.mockResolvedValue(null);
+
+      const response = await request(app)
+        .get('/payments/pay_unknown')
+        .expect(404);
+
+      expect(response.body).toEqual({
+        success: false,
+        paymentId: 'pay_unknown',
+        error: 'Payment not found'
+      });
+    });
+
+    test('should return 500 for unexpected errors', async () => {
+      simulateStripeRetrieve.mockRejectedValue(new Error('Network error'));
+
+      const response = await request(app)
+        .get('/payments/pay_test123')
+        .expect(500);
+
+      expect(response.body).toEqual({
+        success: false,
+        paymentId: 'pay_test123',
+        error: 'Internal server error during payment lookup'
+      });
+    });
+  });
 });
\ No newline at end of file
@@ -1,6 +1,6 @@
 const { describe, test, expect } = require('@jest/globals');
 
-const { simulateStripePayment } = require('../../../src/utils/httpClient');
+const { simulateStripePayment, simulateStripeRefund, simulateStripeRetrieve } = require('../../../src/utils/httpClient');
 
 describe('Payment Simulation Utility', () => {
   describe('simulateStripePayment', () => {
@@ -67,4 +67,41 @@ describe('Payment Simulation Utility', () => {
       expect(endTime - startTime).toBeLessThan(500);
     });
   });
+
+  describe('simulateStripeRefund', () => {
+    test('should return refunded result for normal payments', async () => {
+      const req = { baggage: 'trace-id=refund123' };
+
+      const result = await simulateStripeRefund(req, 'pay_test123');
+
+      expect(result.success).toBe(true);
+      expect(result.refundId).toMatch(/^re_\d+_[a-z0-9]{9}$/);
+      expect(result.status).toBe('refunded');
+    });
+
+    test('should reject refund for test payment pay_refund_fail', async () => {
+      const result = await simulateStripeRefund({}, 'pay_refund_fail');
+
+      expect(result.success).toBe(false);
+      expect(result.error).toBe('Refund rejected');
+      expect(result.status).toBe('failed');
+    });
+  });
+
+  describe('simulateStripeRetrieve', () => {
+    test('should report the status of a payment while and after it is processed', async () => {
+      const processing = simulateStripePayment({}, 'pay_retrieve123', '4242424242424242');
+
+      expect(await simulateStripeRetrieve({}, 'pay_retrieve123')).toEqual({ paymentId: 'pay_retrieve123', status: 'processing' });
+      await processing;
+      expect(await simulateStripeRetrieve({}, 'pay_retrieve123')).toEqual({ paymentId: 'pay_retrieve123', status: 'completed' });
+
+      await simulateStripeRefund({}, 'pay_retrieve123');
+      expect(await simulateStripeRetrieve({}, 'pay_retrieve123')).toEqual({ paymentId: 'pay_retrieve123', status: 'refunded' });
+    });
+
+    test('should return null for a payment never processed', async () => {
+      expect(await simulateStripeRetrieve({}, 'pay_unknown123')).toBeNull();
+    });
+  });
 });
\ No newline at end of file
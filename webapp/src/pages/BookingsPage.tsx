@@ -24,6 +24,13 @@ const BookingsPage: React.FC = () => {
   };
   const [formErrors, setFormErrors] = useState<string[]>([]);
   const [submitting, setSubmitting] = useState(false);
+  // Kept while the same form is resubmitted after an error, so a retry does
+  // not book or charge twice
+  const [pendingAttempt, setPendingAttempt] = useState<{ idempotencyKey: string; paymentId: string } | null>(null);
+
+  useEffect(() => {
+    setPendingAttempt(null);
+  }, [formData]);
 
   useEffect(() => {
     const loadData = async () => {
@@ -51,16 +58,22 @@ const BookingsPage: React.FC = () => {
     setFormErrors([]);
     setSubmitting(true);
 
+    const attempt = pendingAttempt ?? {
+      idempotencyKey: apiService.generateIdempotencyKey(),
+      paymentId: generatePaymentId(),
+    };
+    setPendingAttempt(attempt);
+
     try {
-      // Convert datetime-local format to ISO 8601 with timezone and generate payment ID
+      // Convert datetime-local format to ISO 8601 with timezone and reuse the payment ID of a retried attempt
       const bookingData: BookingRequest = {
         ...formData,
-        paymentId: generatePaymentId(),
+        paymentId: attempt.paymentId,
         startDate: new Date(formData.startDate).toISOString(),
         endDate: new Date(formData.endDate).toISOString()
       };
 
-      const response = await apiService.createBooking(bookingData);
+      const response = await apiService.createBooking(bookingData, attempt.idempotencyKey);
       if (response.success) {
         // Refresh bookings
         const updatedBookings = await apiService.getBookings();
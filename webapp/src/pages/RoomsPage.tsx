@@ -1,6 +1,6 @@
 import React, { useState, useEffect } from 'react';
 import apiService from '../services/api';
-import { Room, ValidationRequest, ValidationResponse } from '../types';
+import { AvailabilityQuery, Room, ValidationRequest, ValidationResponse } from '../types';
 
 const RoomsPage: React.FC = () => {
   const [rooms, setRooms] = useState<Room[]>([]);
@@ -14,6 +14,15 @@ const RoomsPage: React.FC = () => {
   });
   const [validationResult, setValidationResult] = useState<ValidationResponse | null>(null);
   const [validating, setValidating] = useState(false);
+  const [availabilityQuery, setAvailabilityQuery] = useState<AvailabilityQuery>({
+    start: '',
+    end: '',
+    guests: 1,
+  });
+  // Rooms free for availabilityQuery, or null to list every room
+  const [availableRooms, setAvailableRooms] = useState<Room[] | null>(null);
+  const [availabilityError, setAvailabilityError] = useState<string | null>(null);
+  const [searching, setSearching] = useState(false);
 
   useEffect(() => {
     const loadRooms = async () => {
@@ -56,6 +65,22 @@ const RoomsPage: React.FC = () => {
     }
   };
 
+  const handleAvailabilitySearch = async (e: React.FormEvent) => {
+    e.preventDefault();
+    setSearching(true);
+    setAvailabilityError(null);
+
+    try {
+      setAvailableRooms(await apiService.getAvailableRooms(availabilityQuery));
+    } catch (error: any) {
+      setAvailabilityError(error.message || 'Availability search failed');
+    } finally {
+      setSearching(false);
+    }
+  };
+
+  const displayedRooms = availableRooms ?? rooms;
+
   if (loading) {
     return <div className="loading">Loading rooms...</div>;
   }
@@ -144,6 +169,51 @@ const RoomsPage: React.FC = () => {
           </div>
         )}
 
+        <form onSubmit={handleAvailabilitySearch} style={{ display: 'flex', gap: '10px', alignItems: 'flex-end', marginTop: '20px' }}>
+          <div className="form-group">
+            <label>Check-in</label>
+            <input
+              type="date"
+              value={availabilityQuery.start}
+              onChange={(e) => setAvailabilityQuery({ ...availabilityQuery, start: e.target.value })}
+              required
+            />
+          </div>
+          <div className="form-group">
+            <label>Check-out</label>
+            <input
+              type="date"
+              value={availabilityQuery.end}
+              onChange={(e) => setAvailabilityQuery({ ...availabilityQuery, end: e.target.value })}
+              required
+            />
+          </div>
+          <div className="form-group">
+            <label>Guests</label>
+            <input
+              type="number"
+              min="1"
+              value={availabilityQuery.guests}
+              onChange={(e) => setAvailabilityQuery({ ...availabilityQuery, guests: parseInt(e.target.value) })}
+              required
+            />
+          </div>
+          <div className="form-group">
+            <button type="submit" className="btn" disabled={searching}>
+              {searching ? 'Searching...' : 'Find Available Rooms'}
+            </button>
+            {availableRooms && (
+              <button type="button" className="btn" style={{ marginLeft: '10px' }} onClick={() => setAvailableRooms(null)}>
+                Show All Rooms
+              </button>
+            )}
+          </div>
+        </form>
+
+        {availabilityError && (
+          <div style={{ color: '#dc3545', marginBottom: '10px' }}>{availabilityError}</div>
+        )}
+
         <table className="table">
           <thead>
             <tr>
@@ -157,7 +227,7 @@ const RoomsPage: React.FC = () => {
             </tr>
           </thead>
           <tbody>
-            {rooms.map(room => (
+            {displayedRooms.map(room => (
               <tr key={room.id}>
                 <td>{room.id}</td>
                 <td>{room.name}</td>
@@ -171,9 +241,9 @@ const RoomsPage: React.FC = () => {
           </tbody>
         </table>
 
-        {rooms.length === 0 && (
+        {displayedRooms.length === 0 && (
           <div style={{ textAlign: 'center', padding: '40px', color: '#6c757d' }}>
-            No rooms found.
+            {availableRooms ? 'No rooms are available for these dates.' : 'No rooms found.'}
           </div>
         )}
       </div>
@@ -184,6 +254,7 @@ const RoomsPage: React.FC = () => {
         <ul>
           <li><strong>Room Identifier:</strong> Unique identifier for each room</li>
           <li><strong>Capacity:</strong> Maximum number of guests the room can accommodate</li>
+          <li><strong>Availability Search:</strong> Find the rooms free for given dates and guests</li>
           <li><strong>Booking Validation:</strong> Check availability and capacity before booking</li>
         </ul>
         <p>Use the validation feature above to check if a booking request would be valid for specific dates and guest counts.</p>
@@ -18,37 +18,41 @@ const SystemStatusPage: React.FC = () => {
     const checkHealth = async () => {
       try {
         setCertificateError(false);
-        const [gatewayHealth, adminHealth, bookingHealth, bookingManagementHealth] = await Promise.allSettled([
-          apiService.getGatewayHealth(),
-          apiService.getAdminHealth(),
-          apiService.getBookingHealth(),
-          apiService.getBookingManagementHealth(),
-        ]);
-
-        // Check if any requests failed due to certificate errors
-        const results = [gatewayHealth, adminHealth, bookingHealth, bookingManagementHealth];
-        const hasCertificateError = results.some(result => {
-          if (result.status === 'rejected') {
-            const error = result.reason;
-            return error?.code === 'ERR_CERT_AUTHORITY_INVALID' ||
-                   error?.code === 'ERR_CERT_COMMON_NAME_INVALID' ||
-                   error?.message?.includes('certificate') ||
-                   error?.message?.includes('SSL') ||
-                   error?.message?.includes('CERT') ||
-                   error?.name === 'AxiosError';
-          }
-          return false;
-        });
+        // A single deep health call lets the gateway probe every upstream concurrently
+        const [deepHealth] = await Promise.allSettled([apiService.getDeepHealth()]);
+
+        // Check if the request failed due to certificate errors
+        const hasCertificateError = deepHealth.status === 'rejected' && (() => {
+          const error = deepHealth.reason;
+          return error?.code === 'ERR_CERT_AUTHORITY_INVALID' ||
+                 error?.code === 'ERR_CERT_COMMON_NAME_INVALID' ||
+                 error?.message?.includes('certificate') ||
+                 error?.message?.includes('SSL') ||
+                 error?.message?.includes('CERT') ||
+                 error?.name === 'AxiosError';
+        })();
 
         if (hasCertificateError && config.apiBaseUrl.startsWith('https://')) {
           setCertificateError(true);
         }
 
+        const gateway = deepHealth.status === 'fulfilled' ? deepHealth.value : undefined;
+        const dependency = (name: string): HealthResponse | undefined => {
+          const dep = gateway?.dependencies?.find(d => d.name === name);
+          if (!dep) return undefined;
+          return {
+            status: dep.status === 'up' ? 'healthy' : 'unhealthy',
+            service: `${dep.name} (${dep.latency_ms} ms)`,
+            version: dep.version,
+          };
+        };
+
         setHealth({
-          gateway: gatewayHealth.status === 'fulfilled' ? gatewayHealth.value : undefined,
-          admin: adminHealth.status === 'fulfilled' ? adminHealth.value : undefined,
-          booking: bookingHealth.status === 'fulfilled' ? bookingHealth.value : undefined,
-          bookingManagement: bookingManagementHealth.status === 'fulfilled' ? bookingManagementHealth.value : undefined,
+          // The gateway itself is up whenever it answers, even if a dependency is down
+          gateway: gateway ? { ...gateway, status: 'healthy' } : undefined,
+          admin: dependency('admin'),
+          booking: dependency('booking'),
+          bookingManagement: dependency('booking-management'),
         });
       } catch (error) {
         console.error('Error checking health:', error);
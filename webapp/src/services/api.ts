@@ -3,6 +3,7 @@ import config from '../utils/config';
 import {
   User,
   Room,
+  AvailabilityQuery,
   Booking,
   BookingRequest,
   BookingResponse,
@@ -12,7 +13,9 @@ import {
   ValidationRequest,
   ValidationResponse,
   HealthResponse,
+  DeepHealthResponse,
   ApiResponse,
+  Page,
 } from '../types';
 
 class ApiService {
@@ -65,12 +68,27 @@ class ApiService {
     return Math.random().toString(36).substr(2, 9);
   }
 
+  // Sent as Idempotency-Key; reuse it when retrying the same request so the
+  // booking service replays the first response instead of running it again
+  generateIdempotencyKey(): string {
+    if (typeof crypto !== 'undefined' && typeof crypto.randomUUID === 'function') {
+      return crypto.randomUUID();
+    }
+    return `${Date.now().toString(36)}-${Math.random().toString(36).substr(2, 12)}`;
+  }
+
   // Health check endpoints
   async getGatewayHealth(): Promise<HealthResponse> {
     const response = await this.client.get<HealthResponse>(config.endpoints.health);
     return response.data;
   }
 
+  // Gateway health including every upstream, probed concurrently by the gateway
+  async getDeepHealth(): Promise<DeepHealthResponse> {
+    const response = await this.client.get<DeepHealthResponse>(config.endpoints.deepHealth);
+    return response.data;
+  }
+
   async getAdminHealth(): Promise<HealthResponse> {
     const response = await this.client.get<HealthResponse>(config.endpoints.admin.health);
     return response.data;
@@ -108,30 +126,55 @@ class ApiService {
   }
 
   // Booking service methods
-  async createBooking(booking: BookingRequest): Promise<BookingResponse> {
-    const response = await this.client.post<BookingResponse>(config.endpoints.booking.book, booking);
+  async createBooking(booking: BookingRequest, idempotencyKey: string = this.generateIdempotencyKey()): Promise<BookingResponse> {
+    const response = await this.client.post<BookingResponse>(config.endpoints.booking.book, booking, {
+      headers: { 'Idempotency-Key': idempotencyKey },
+    });
     return response.data;
   }
 
-  async cancelBooking(cancellation: CancellationRequest): Promise<BookingResponse> {
-    const response = await this.client.post<BookingResponse>(config.endpoints.booking.cancel, cancellation);
+  async cancelBooking(cancellation: CancellationRequest, idempotencyKey: string = this.generateIdempotencyKey()): Promise<BookingResponse> {
+    const response = await this.client.post<BookingResponse>(config.endpoints.booking.cancel, cancellation, {
+      headers: { 'Idempotency-Key': idempotencyKey },
+    });
     return response.data;
   }
 
   // Booking-management service methods
+
+  // Follows next_cursor until the last page of a list endpoint
+  private async getAllPages<T>(url: string): Promise<T[]> {
+    const items: T[] = [];
+    let cursor: string | undefined;
+    do {
+      const response = await this.client.get<Page<T>>(url, { params: { limit: 500, cursor } });
+      if (response.status >= 400) {
+        throw new Error(typeof response.data === 'string' ? response.data : `Failed to fetch ${url}`);
+      }
+      items.push(...response.data.data);
+      cursor = response.data.next_cursor;
+    } while (cursor);
+    return items;
+  }
+
   async getUsers(): Promise<User[]> {
-    const response = await this.client.get<User[]>(config.endpoints.bookingManagement.users);
-    return response.data;
+    return this.getAllPages<User>(config.endpoints.bookingManagement.users);
   }
 
   async getRooms(): Promise<Room[]> {
-    const response = await this.client.get<Room[]>(config.endpoints.bookingManagement.rooms);
+    return this.getAllPages<Room>(config.endpoints.bookingManagement.rooms);
+  }
+
+  async getAvailableRooms(query: AvailabilityQuery): Promise<Room[]> {
+    const response = await this.client.get<Room[]>(config.endpoints.bookingManagement.availableRooms, { params: query });
+    if (response.status >= 400) {
+      throw new Error(typeof response.data === 'string' ? response.data : 'Availability search failed');
+    }
     return response.data;
   }
 
   async getBookings(): Promise<Booking[]> {
-    const response = await this.client.get<Booking[]>(config.endpoints.bookingManagement.bookings);
-    return response.data;
+    return this.getAllPages<Booking>(config.endpoints.bookingManagement.bookings);
   }
 
   async validateBooking(validation: ValidationRequest): Promise<ValidationResponse> {
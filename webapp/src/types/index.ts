@@ -75,6 +75,24 @@ export interface Complaint {
   updated_at?: string;
 }
 
+// Query of GET /booking-management/rooms/available; dates are YYYY-MM-DD
+export interface AvailabilityQuery {
+  start: string;
+  end: string;
+  guests: number;
+  floor?: number;
+  beds?: number;
+  bathrooms?: number;
+  sort?: string;
+}
+
+// One page of a booking-management list endpoint; next_cursor is absent on
+// the last page
+export interface Page<T> {
+  data: T[];
+  next_cursor?: string;
+}
+
 export interface ValidationRequest {
   room_id: string;
   number_of_guests: number;
@@ -103,4 +121,18 @@ export interface HealthResponse {
   service?: string;
   timestamp?: string;
   version?: string;
+}
+
+export interface DependencyHealth {
+  name: string;
+  url: string;
+  status: 'up' | 'down';
+  critical: boolean;
+  latency_ms: number;
+  version?: string;
+  error?: string;
+}
+
+export interface DeepHealthResponse extends HealthResponse {
+  dependencies?: DependencyHealth[];
 }
\ No newline at end of file
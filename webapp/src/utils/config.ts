@@ -17,6 +17,7 @@ export const config = {
   endpoints: {
     // Gateway health
     health: '/healthz',
+    deepHealth: '/healthz?deep=1',
 
     // Admin service routes
     admin: {
@@ -38,6 +39,7 @@ export const config = {
       healthz: '/booking-management/healthz',
       users: '/booking-management/users',
       rooms: '/booking-management/rooms',
+      availableRooms: '/booking-management/rooms/available',
       bookings: '/booking-management/bookings',
       validate: '/booking-management/validate',
     },
@@ -2,16 +2,33 @@ package config
 
 import (
 	"os"
+	"strconv"
 	"strings"
+	"time"
 )
 
 type Config struct {
-	KafkaBrokers []string
-	DBHost       string
-	DBPort       string
-	DBUser       string
-	DBPass       string
-	DBName       string
+	KafkaBrokers       []string
+	KafkaGroupID       string
+	KafkaInitialOffset string
+	RetryMaxAttempts   int
+	RetryBaseDelay     time.Duration
+	RetryMaxDelay      time.Duration
+	DBHost             string
+	DBPort             string
+	DBUser             string
+	DBPass             string
+	DBName             string
+	MetricsPort        string
+	OTLPEndpoint       string
+	TracesFile         string
+
+	DivertBaggageKey        string
+	DivertMode              string
+	DivertNamespace         string
+	DivertClaimedKeys       []string
+	DivertHeartbeatInterval time.Duration
+	DivertClaimTTL          time.Duration
 }
 
 func Load() *Config {
@@ -23,13 +40,36 @@ func Load() *Config {
 		brokers[i] = strings.TrimSpace(broker)
 	}
 
+	// Diverted workers need their own group, otherwise they would share
+	// partitions with the shared worker and miss their messages
+	namespace := getEnv("DIVERT_NAMESPACE", "")
+	defaultGroupID := "worker"
+	if namespace != "" {
+		defaultGroupID = "worker-" + namespace
+	}
+
 	return &Config{
-		KafkaBrokers: brokers,
-		DBHost:       getEnv("DB_HOST", "localhost"),
-		DBPort:       getEnv("DB_PORT", "5432"),
-		DBUser:       getEnv("DB_USER", "postgres"),
-		DBPass:       getEnv("DB_PASS", "postgres"),
-		DBName:       getEnv("DB_NAME", "booking_management"),
+		KafkaBrokers:       brokers,
+		KafkaGroupID:       getEnv("KAFKA_GROUP_ID", defaultGroupID),
+		KafkaInitialOffset: getEnv("KAFKA_INITIAL_OFFSET", "newest"),
+		RetryMaxAttempts:   getIntEnv("RETRY_MAX_ATTEMPTS", 5),
+		RetryBaseDelay:     getDurationEnv("RETRY_BASE_DELAY", 500*time.Millisecond),
+		RetryMaxDelay:      getDurationEnv("RETRY_MAX_DELAY", 30*time.Second),
+		DBHost:             getEnv("DB_HOST", "localhost"),
+		DBPort:             getEnv("DB_PORT", "5432"),
+		DBUser:             getEnv("DB_USER", "postgres"),
+		DBPass:             getEnv("DB_PASS", "postgres"),
+		DBName:             getEnv("DB_NAME", "booking_management"),
+		MetricsPort:        getEnv("METRICS_PORT", "9090"),
+		OTLPEndpoint:       getEnv("OTEL_EXPORTER_OTLP_ENDPOINT", ""),
+		TracesFile:         getEnv("OTEL_TRACES_FILE", ""),
+
+		DivertBaggageKey:        getEnv("DIVERT_BAGGAGE_KEY", "okteto-divert"),
+		DivertMode:              getEnv("DIVERT_MODE", ""),
+		DivertNamespace:         namespace,
+		DivertClaimedKeys:       getListEnv("DIVERT_CLAIMED_KEYS"),
+		DivertHeartbeatInterval: getDurationEnv("DIVERT_HEARTBEAT_INTERVAL", 10*time.Second),
+		DivertClaimTTL:          getDurationEnv("DIVERT_CLAIM_TTL", 30*time.Second),
 	}
 }
 
@@ -38,4 +78,33 @@ func getEnv(key, defaultValue string) string {
 		return value
 	}
 	return defaultValue
-}
\ No newline at end of file
+}
+
+func getIntEnv(key string, defaultValue int) int {
+	if value := os.Getenv(key); value != "" {
+		if number, err := strconv.Atoi(value); err == nil {
+			return number
+		}
+	}
+	return defaultValue
+}
+
+func getDurationEnv(key string, defaultValue time.Duration) time.Duration {
+	if value := os.Getenv(key); value != "" {
+		if duration, err := time.ParseDuration(value); err == nil {
+			return duration
+		}
+	}
+	return defaultValue
+}
+
+// getListEnv splits a comma-separated variable, dropping empty entries
+func getListEnv(key string) []string {
+	var values []string
+	for _, value := range strings.Split(os.Getenv(key), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
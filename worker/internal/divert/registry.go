@@ -0,0 +1,123 @@
+package divert
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"worker/internal/logger"
+)
+
+// Store persists the heartbeats of diverted workers.
+type Store interface {
+	Heartbeat(ctx context.Context, key, consumerID string) error
+	Release(ctx context.Context, key, consumerID string) error
+	ActiveKeys(ctx context.Context, ttl time.Duration) ([]string, error)
+}
+
+// Registry tracks which divert keys are claimed by diverted workers. A key
+// is claimed when it is listed statically or when a worker has sent a
+// heartbeat for it within the TTL.
+type Registry struct {
+	store  Store
+	static map[string]bool
+	ttl    time.Duration
+
+	mu     sync.RWMutex
+	active map[string]bool
+}
+
+func NewRegistry(store Store, static []string, ttl time.Duration) *Registry {
+	staticKeys := make(map[string]bool, len(static))
+	for _, key := range static {
+		if key != "" {
+			staticKeys[key] = true
+		}
+	}
+
+	return &Registry{
+		store:  store,
+		static: staticKeys,
+		ttl:    ttl,
+		active: make(map[string]bool),
+	}
+}
+
+func (r *Registry) IsClaimed(key string) bool {
+	if r.static[key] {
+		return true
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.active[key]
+}
+
+// Refresh reloads the keys with a live heartbeat. On error the previous set
+// is kept, so a database hiccup doesn't hand diverted messages to the
+// shared worker.
+func (r *Registry) Refresh(ctx context.Context) error {
+	keys, err := r.store.ActiveKeys(ctx, r.ttl)
+	if err != nil {
+		return err
+	}
+
+	active := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		active[key] = true
+	}
+
+	r.mu.Lock()
+	r.active = active
+	r.mu.Unlock()
+	return nil
+}
+
+// Watch refreshes the claimed keys every interval until ctx is done.
+func (r *Registry) Watch(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := r.Refresh(ctx); err != nil && ctx.Err() == nil {
+				logger.Warn(ctx, "Failed to refresh claimed divert keys", "error", err)
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// Claim sends a heartbeat for key every interval until ctx is done. The
+// first heartbeat is sent before Claim returns so callers can fail fast.
+func (r *Registry) Claim(ctx context.Context, key, consumerID string, interval time.Duration) error {
+	if err := r.store.Heartbeat(ctx, key, consumerID); err != nil {
+		return err
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				if err := r.store.Heartbeat(ctx, key, consumerID); err != nil && ctx.Err() == nil {
+					logger.Warn(ctx, "Failed to send divert heartbeat", "divertKey", key, "error", err)
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return nil
+}
+
+// Release drops the claim of consumerID on key so shared workers pick its
+// messages up again without waiting for the TTL to expire.
+func (r *Registry) Release(ctx context.Context, key, consumerID string) error {
+	return r.store.Release(ctx, key, consumerID)
+}
@@ -0,0 +1,103 @@
+package divert
+
+import (
+	"context"
+	"fmt"
+
+	"worker/internal/middleware"
+)
+
+// Mode selects which messages a worker handles.
+type Mode string
+
+const (
+	// ModeAll handles every message.
+	ModeAll Mode = "all"
+	// ModeDiverted handles only messages whose divert key names this
+	// worker's namespace.
+	ModeDiverted Mode = "diverted"
+	// ModeShared handles messages without a divert key and messages whose
+	// divert key is not claimed by a running diverted worker.
+	ModeShared Mode = "shared"
+)
+
+// Reasons a message is skipped, also used as metric label values.
+const (
+	ReasonNotDiverted    = "not_diverted"
+	ReasonOtherNamespace = "other_namespace"
+	ReasonClaimed        = "claimed"
+)
+
+// ParseMode parses a DIVERT_MODE value. An empty value picks diverted mode
+// when the worker has a namespace and shared mode otherwise.
+func ParseMode(value, namespace string) (Mode, error) {
+	switch Mode(value) {
+	case "":
+		if namespace != "" {
+			return ModeDiverted, nil
+		}
+		return ModeShared, nil
+	case ModeAll, ModeShared:
+		return Mode(value), nil
+	case ModeDiverted:
+		if namespace == "" {
+			return "", fmt.Errorf("divert mode %q requires a namespace", value)
+		}
+		return ModeDiverted, nil
+	default:
+		return "", fmt.Errorf("unknown divert mode %q", value)
+	}
+}
+
+// ClaimSource reports whether a diverted worker currently claims a divert key.
+type ClaimSource interface {
+	IsClaimed(key string) bool
+}
+
+// Selector decides whether a consumed message belongs to this worker based
+// on the divert key in the baggage the producer attached to it.
+type Selector struct {
+	mode       Mode
+	baggageKey string
+	namespace  string
+	claims     ClaimSource
+}
+
+func NewSelector(mode Mode, baggageKey, namespace string, claims ClaimSource) *Selector {
+	return &Selector{
+		mode:       mode,
+		baggageKey: baggageKey,
+		namespace:  namespace,
+		claims:     claims,
+	}
+}
+
+func (s *Selector) Mode() Mode {
+	return s.mode
+}
+
+// Select reports whether the message whose baggage is carried in ctx should
+// be handled, and if not, why.
+func (s *Selector) Select(ctx context.Context) (bool, string) {
+	if s == nil || s.mode == ModeAll || s.baggageKey == "" {
+		return true, ""
+	}
+
+	key := middleware.GetBaggageMember(ctx, s.baggageKey)
+
+	switch s.mode {
+	case ModeDiverted:
+		if key == "" {
+			return false, ReasonNotDiverted
+		}
+		if key != s.namespace {
+			return false, ReasonOtherNamespace
+		}
+		return true, ""
+	default:
+		if key != "" && s.claims != nil && s.claims.IsClaimed(key) {
+			return false, ReasonClaimed
+		}
+		return true, ""
+	}
+}
@@ -0,0 +1,119 @@
+package divert
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"worker/internal/middleware"
+)
+
+func TestParseMode(t *testing.T) {
+	tests := []struct {
+		value, namespace string
+		want             Mode
+		wantErr          bool
+	}{
+		{"", "alice", ModeDiverted, false},
+		{"", "", ModeShared, false},
+		{"all", "", ModeAll, false},
+		{"shared", "alice", ModeShared, false},
+		{"diverted", "alice", ModeDiverted, false},
+		{"diverted", "", "", true},
+		{"everything", "", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value+"/"+tt.namespace, func(t *testing.T) {
+			got, err := ParseMode(tt.value, tt.namespace)
+			if (err != nil) != tt.wantErr || got != tt.want {
+				t.Errorf("ParseMode(%q, %q) = %q, %v, want %q, error %v", tt.value, tt.namespace, got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+}
+
+type claimedKeys map[string]bool
+
+func (c claimedKeys) IsClaimed(key string) bool {
+	return c[key]
+}
+
+func TestSelect(t *testing.T) {
+	claims := claimedKeys{"alice": true}
+	diverted := NewSelector(ModeDiverted, "divert", "alice", claims)
+	shared := NewSelector(ModeShared, "divert", "", claims)
+	all := NewSelector(ModeAll, "divert", "", claims)
+
+	tests := []struct {
+		name       string
+		selector   *Selector
+		baggage    string
+		want       bool
+		wantReason string
+	}{
+		{"diverted takes its own namespace", diverted, "divert=alice,user=1", true, ""},
+		{"diverted skips messages without a divert key", diverted, "user=1", false, ReasonNotDiverted},
+		{"diverted skips other namespaces", diverted, "divert=bob", false, ReasonOtherNamespace},
+		{"shared takes messages without a divert key", shared, "", true, ""},
+		{"shared takes unclaimed divert keys", shared, "divert=bob", true, ""},
+		{"shared skips claimed divert keys", shared, "divert=alice", false, ReasonClaimed},
+		{"all takes claimed divert keys", all, "divert=alice", true, ""},
+		{"nil selector takes everything", nil, "divert=alice", true, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := middleware.WithBaggage(context.Background(), tt.baggage)
+			got, reason := tt.selector.Select(ctx)
+			if got != tt.want || reason != tt.wantReason {
+				t.Errorf("Select(%q) = %v, %q, want %v, %q", tt.baggage, got, reason, tt.want, tt.wantReason)
+			}
+		})
+	}
+}
+
+type fakeStore struct {
+	keys []string
+	err  error
+}
+
+func (s *fakeStore) Heartbeat(ctx context.Context, key, consumerID string) error { return nil }
+func (s *fakeStore) Release(ctx context.Context, key, consumerID string) error   { return nil }
+func (s *fakeStore) ActiveKeys(ctx context.Context, ttl time.Duration) ([]string, error) {
+	return s.keys, s.err
+}
+
+func TestRegistryRefresh(t *testing.T) {
+	store := &fakeStore{keys: []string{"alice"}}
+	registry := NewRegistry(store, []string{"static", ""}, time.Minute)
+	ctx := context.Background()
+
+	if !registry.IsClaimed("static") || registry.IsClaimed("alice") || registry.IsClaimed("") {
+		t.Fatal("before the first refresh only the static key is claimed")
+	}
+
+	if err := registry.Refresh(ctx); err != nil {
+		t.Fatalf("Refresh failed: %v", err)
+	}
+	if !registry.IsClaimed("alice") {
+		t.Error("key with a heartbeat is not claimed")
+	}
+
+	// A failed refresh keeps the keys claimed, so their messages are not
+	// handed to the shared worker
+	store.keys, store.err = nil, errors.New("database unavailable")
+	if err := registry.Refresh(ctx); err == nil {
+		t.Fatal("Refresh hid the store error")
+	}
+	if !registry.IsClaimed("alice") {
+		t.Error("failed refresh dropped a claimed key")
+	}
+
+	store.err = nil
+	if err := registry.Refresh(ctx); err != nil {
+		t.Fatalf("Refresh failed: %v", err)
+	}
+	if registry.IsClaimed("alice") || !registry.IsClaimed("static") {
+		t.Error("expired heartbeat is still claimed, or the static key was dropped")
+	}
+}
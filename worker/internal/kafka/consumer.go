@@ -3,19 +3,38 @@ package kafka
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"sync"
+	"strconv"
+	"time"
 
 	"github.com/IBM/sarama"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
 
+	"worker/internal/divert"
 	"worker/internal/logger"
-	"worker/internal/middleware"
+	"worker/internal/metrics"
 	"worker/internal/models"
 )
 
+var tracer = otel.Tracer("worker/internal/kafka")
+
+// redeliveryDelay is how long the consumer waits after a message could be
+// neither handled nor dead-lettered before ending the group session, which
+// makes the message come back.
+const redeliveryDelay = time.Second
+
 type Consumer struct {
-	consumer sarama.Consumer
-	handlers EventHandlers
+	group       sarama.ConsumerGroup
+	handlers    EventHandlers
+	selector    *divert.Selector
+	retry       RetryPolicy
+	deadLetters *DeadLetterQueue
+	topics      map[string]func(context.Context, *sarama.ConsumerMessage) error
 }
 
 type EventHandlers struct {
@@ -23,106 +42,214 @@ type EventHandlers struct {
 	CancellationHandler func(ctx context.Context, event models.CancellationEvent) error
 }
 
-func NewConsumer(brokers []string, handlers EventHandlers) (*Consumer, error) {
+// ConsumerOptions configure the consumer group.
+type ConsumerOptions struct {
+	Brokers []string
+	GroupID string
+	// InitialOffset is "oldest" or "newest" and only applies to partitions
+	// the group has not committed an offset for yet.
+	InitialOffset string
+	// Retry controls how often a failing message is retried before it is
+	// sent to the dead-letter topic.
+	Retry RetryPolicy
+}
+
+// NewConsumer creates a consumer group member for the booking topics.
+// Messages the selector rejects are skipped; a nil selector handles every
+// message.
+func NewConsumer(opts ConsumerOptions, handlers EventHandlers, selector *divert.Selector) (*Consumer, error) {
 	config := sarama.NewConfig()
 	config.Consumer.Return.Errors = true
-	config.Consumer.Group.Rebalance.Strategy = sarama.BalanceStrategyRoundRobin
+	config.Consumer.Group.Rebalance.GroupStrategies = []sarama.BalanceStrategy{sarama.NewBalanceStrategyRoundRobin()}
 
-	consumer, err := sarama.NewConsumer(brokers, config)
+	switch opts.InitialOffset {
+	case "oldest":
+		config.Consumer.Offsets.Initial = sarama.OffsetOldest
+	case "newest", "":
+		config.Consumer.Offsets.Initial = sarama.OffsetNewest
+	default:
+		return nil, fmt.Errorf("invalid initial offset %q, expected oldest or newest", opts.InitialOffset)
+	}
+
+	deadLetters, err := NewDeadLetterQueue(opts.Brokers)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create Kafka consumer: %w", err)
+		return nil, err
 	}
 
-	return &Consumer{
-		consumer: consumer,
-		handlers: handlers,
-	}, nil
+	group, err := sarama.NewConsumerGroup(opts.Brokers, opts.GroupID, config)
+	if err != nil {
+		deadLetters.Close()
+		return nil, fmt.Errorf("failed to create Kafka consumer group: %w", err)
+	}
+
+	c := &Consumer{
+		group:       group,
+		handlers:    handlers,
+		selector:    selector,
+		retry:       opts.Retry,
+		deadLetters: deadLetters,
+	}
+	c.topics = map[string]func(context.Context, *sarama.ConsumerMessage) error{
+		"booking-events":        c.handleBookingEvent,
+		"booking-cancellations": c.handleCancellationEvent,
+	}
+	return c, nil
 }
 
+// Start joins the consumer group and consumes until ctx is done. A session
+// ends on every rebalance and after a failed message, and a new one resumes
+// from the last committed offsets.
 func (c *Consumer) Start(ctx context.Context) error {
-	var wg sync.WaitGroup
+	topics := make([]string, 0, len(c.topics))
+	for topic := range c.topics {
+		topics = append(topics, topic)
+	}
 
-	// Start consuming booking events
-	wg.Add(1)
 	go func() {
-		defer wg.Done()
-		if err := c.consumeTopic(ctx, "booking-events", c.handleBookingEvent); err != nil {
-			logger.Error(ctx, "Error consuming booking events", "error", err)
+		for err := range c.group.Errors() {
+			logger.Error(ctx, "Consumer group error", "error", err)
 		}
 	}()
 
-	// Start consuming cancellation events
-	wg.Add(1)
-	go func() {
-		defer wg.Done()
-		if err := c.consumeTopic(ctx, "booking-cancellations", c.handleCancellationEvent); err != nil {
-			logger.Error(ctx, "Error consuming cancellation events", "error", err)
+	logger.Info(ctx, "Kafka consumer started, listening for events", "topics", topics)
+
+	for {
+		sessionCtx, endSession := context.WithCancel(ctx)
+		err := c.group.Consume(sessionCtx, topics, &groupHandler{consumer: c, endSession: endSession})
+		endSession()
+
+		if ctx.Err() != nil || errors.Is(err, sarama.ErrClosedConsumerGroup) {
+			logger.Info(ctx, "Kafka consumer stopped")
+			return nil
 		}
-	}()
+		if err != nil && !errors.Is(err, context.Canceled) {
+			logger.Error(ctx, "Consumer group session failed", "error", err)
+			select {
+			case <-time.After(redeliveryDelay):
+			case <-ctx.Done():
+				return nil
+			}
+		}
+	}
+}
 
-	logger.Info(ctx, "Kafka consumer started, listening for events")
+// groupHandler handles the claims of a single consumer group session.
+type groupHandler struct {
+	consumer   *Consumer
+	endSession context.CancelFunc
+}
 
-	wg.Wait()
+func (h *groupHandler) Setup(session sarama.ConsumerGroupSession) error {
+	logger.Info(session.Context(), "Consumer group session started", "memberId", session.MemberID(), "claims", session.Claims())
 	return nil
 }
 
-func (c *Consumer) consumeTopic(ctx context.Context, topic string, handler func(context.Context, *sarama.ConsumerMessage) error) error {
-	partitions, err := c.consumer.Partitions(topic)
-	if err != nil {
-		return fmt.Errorf("failed to get partitions for topic %s: %w", topic, err)
-	}
+func (h *groupHandler) Cleanup(session sarama.ConsumerGroupSession) error {
+	logger.Info(session.Context(), "Consumer group session ended", "memberId", session.MemberID())
+	return nil
+}
 
-	var wg sync.WaitGroup
-	for _, partition := range partitions {
-		wg.Add(1)
-		go func(partition int32) {
-			defer wg.Done()
+// ConsumeClaim handles the messages of one partition in order. Offsets are
+// marked only once a message is handled, skipped or dead-lettered, so a
+// message is never lost: otherwise the session is ended and the message is
+// consumed again from the last committed offset.
+func (h *groupHandler) ConsumeClaim(session sarama.ConsumerGroupSession, claim sarama.ConsumerGroupClaim) error {
+	ctx := session.Context()
+	handler := h.consumer.topics[claim.Topic()]
 
-			pc, err := c.consumer.ConsumePartition(topic, partition, sarama.OffsetNewest)
-			if err != nil {
-				logger.Error(ctx, "Failed to start consumer for partition", "topic", topic, "partition", partition, "error", err)
-				return
-			}
-			defer pc.Close()
+	logger.Info(ctx, "Started consuming partition", "topic", claim.Topic(), "partition", claim.Partition(), "offset", claim.InitialOffset())
 
-			logger.Info(ctx, "Started consuming partition", "topic", topic, "partition", partition)
+	for {
+		select {
+		case message, ok := <-claim.Messages():
+			if !ok {
+				return nil
+			}
 
-			for {
+			metrics.ObserveReceived(message.Topic, message.Partition, message.Offset, claim.HighWaterMarkOffset())
+			if err := h.consumer.process(ctx, message, handler); err != nil {
+				logger.Error(ctx, "Ending consumer session so the message is redelivered",
+					"topic", message.Topic, "partition", message.Partition, "offset", message.Offset)
 				select {
-				case message := <-pc.Messages():
-					if message != nil {
-						if err := handler(ctx, message); err != nil {
-							logger.Error(ctx, "Failed to handle message", "topic", topic, "partition", partition, "error", err)
-						}
-					}
-				case err := <-pc.Errors():
-					if err != nil {
-						logger.Error(ctx, "Consumer error", "topic", topic, "partition", partition, "error", err)
-					}
+				case <-time.After(redeliveryDelay):
 				case <-ctx.Done():
-					logger.Info(ctx, "Consumer stopping", "topic", topic, "partition", partition)
-					return
 				}
+				h.endSession()
+				return nil
 			}
-		}(partition)
+			session.MarkMessage(message, "")
+		case <-ctx.Done():
+			return nil
+		}
 	}
+}
+
+// process runs handler for message inside a consumer span, unless the
+// divert selector leaves the message to another worker. Transient failures
+// are retried with backoff; a message that fails permanently or runs out of
+// attempts is sent to the dead-letter topic. An error is returned only when
+// the message could be neither handled nor dead-lettered.
+func (c *Consumer) process(ctx context.Context, message *sarama.ConsumerMessage, handler func(context.Context, *sarama.ConsumerMessage) error) error {
+	msgCtx, span := startConsumerSpan(ctx, message)
+	defer span.End()
 
-	wg.Wait()
+	if ok, reason := c.selector.Select(msgCtx); !ok {
+		logger.Info(msgCtx, "Skipping message for another divert namespace",
+			"topic", message.Topic, "partition", message.Partition, "offset", message.Offset, "reason", reason)
+		metrics.IncSkipped(message.Topic, reason)
+		span.SetAttributes(attribute.String("messaging.skip_reason", reason))
+		return nil
+	}
+
+	var err error
+	attempt := 1
+	for ; ; attempt++ {
+		start := time.Now()
+		err = handler(msgCtx, message)
+		metrics.ObserveHandled(message.Topic, time.Since(start), err)
+		if err == nil {
+			return nil
+		}
+		if ctx.Err() != nil {
+			// Shutting down or rebalancing; the message is redelivered
+			return ctx.Err()
+		}
+		span.RecordError(err)
+
+		if IsPermanent(err) || attempt >= c.retry.MaxAttempts {
+			break
+		}
+
+		logger.Warn(msgCtx, "Failed to handle message, retrying",
+			"topic", message.Topic, "partition", message.Partition, "offset", message.Offset, "attempt", attempt, "error", err)
+		metrics.IncRetry(message.Topic)
+		if waitErr := c.retry.sleep(ctx, attempt); waitErr != nil {
+			return waitErr
+		}
+	}
+
+	reason := "exhausted"
+	if IsPermanent(err) {
+		reason = "permanent"
+	}
+	span.SetStatus(codes.Error, err.Error())
+	logger.Error(msgCtx, "Failed to handle message, sending it to the dead-letter topic",
+		"topic", message.Topic, "partition", message.Partition, "offset", message.Offset, "attempts", attempt, "reason", reason, "error", err)
+
+	if dlqErr := c.deadLetters.Publish(msgCtx, message, err, attempt); dlqErr != nil {
+		logger.Error(msgCtx, "Failed to publish message to the dead-letter topic", "topic", message.Topic, "error", dlqErr)
+		return dlqErr
+	}
+	metrics.IncDeadLettered(message.Topic, reason)
 	return nil
 }
 
 func (c *Consumer) handleBookingEvent(ctx context.Context, message *sarama.ConsumerMessage) error {
-	// Extract baggage from message headers if available
-	baggage := extractBaggageFromHeaders(message.Headers)
-	if baggage != "" {
-		ctx = middleware.WithBaggage(ctx, baggage)
-	}
-
 	logger.Info(ctx, "Received booking event", "key", string(message.Key), "partition", message.Partition, "offset", message.Offset)
 
 	var event models.BookingEvent
 	if err := json.Unmarshal(message.Value, &event); err != nil {
-		return fmt.Errorf("failed to unmarshal booking event: %w", err)
+		return Permanent(fmt.Errorf("failed to unmarshal booking event: %w", err))
 	}
 
 	if c.handlers.BookingHandler != nil {
@@ -134,17 +261,11 @@ func (c *Consumer) handleBookingEvent(ctx context.Context, message *sarama.Consu
 }
 
 func (c *Consumer) handleCancellationEvent(ctx context.Context, message *sarama.ConsumerMessage) error {
-	// Extract baggage from message headers if available
-	baggage := extractBaggageFromHeaders(message.Headers)
-	if baggage != "" {
-		ctx = middleware.WithBaggage(ctx, baggage)
-	}
-
 	logger.Info(ctx, "Received cancellation event", "key", string(message.Key), "partition", message.Partition, "offset", message.Offset)
 
 	var event models.CancellationEvent
 	if err := json.Unmarshal(message.Value, &event); err != nil {
-		return fmt.Errorf("failed to unmarshal cancellation event: %w", err)
+		return Permanent(fmt.Errorf("failed to unmarshal cancellation event: %w", err))
 	}
 
 	if c.handlers.CancellationHandler != nil {
@@ -156,14 +277,25 @@ func (c *Consumer) handleCancellationEvent(ctx context.Context, message *sarama.
 }
 
 func (c *Consumer) Close() error {
-	return c.consumer.Close()
+	err := c.group.Close()
+	if dlqErr := c.deadLetters.Close(); err == nil {
+		err = dlqErr
+	}
+	return err
 }
 
-func extractBaggageFromHeaders(headers []*sarama.RecordHeader) string {
-	for _, header := range headers {
-		if string(header.Key) == "Baggage" {
-			return string(header.Value)
-		}
-	}
-	return ""
-}
\ No newline at end of file
+// startConsumerSpan starts the span covering the handling of message,
+// continuing the trace and baggage the producer put in its headers.
+func startConsumerSpan(ctx context.Context, message *sarama.ConsumerMessage) (context.Context, trace.Span) {
+	ctx = ExtractHeaders(ctx, message.Headers)
+	return tracer.Start(ctx, message.Topic+" process",
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(
+			semconv.MessagingSystemKafka,
+			semconv.MessagingDestinationName(message.Topic),
+			semconv.MessagingOperationTypeDeliver,
+			semconv.MessagingDestinationPartitionID(strconv.Itoa(int(message.Partition))),
+			semconv.MessagingKafkaMessageOffset(int(message.Offset)),
+		),
+	)
+}
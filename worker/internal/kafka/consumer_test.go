@@ -0,0 +1,213 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/IBM/sarama/mocks"
+
+	"worker/internal/divert"
+)
+
+type claimedKeys map[string]bool
+
+func (c claimedKeys) IsClaimed(key string) bool {
+	return c[key]
+}
+
+// bookingMessage returns a booking-events message carrying baggage.
+func bookingMessage(offset int64, baggage string) *sarama.ConsumerMessage {
+	message := &sarama.ConsumerMessage{
+		Topic:  "booking-events",
+		Offset: offset,
+		Key:    []byte("booking_1"),
+		Value:  []byte(`{"bookingId":"booking_1"}`),
+	}
+	if baggage != "" {
+		message.Headers = []*sarama.RecordHeader{{Key: []byte(BaggageHeader), Value: []byte(baggage)}}
+	}
+	return message
+}
+
+func TestProcessSkipsMessagesOfOtherNamespaces(t *testing.T) {
+	tests := []struct {
+		name     string
+		selector *divert.Selector
+		baggage  string
+		handled  bool
+	}{
+		{"shared worker skips claimed key", divert.NewSelector(divert.ModeShared, "divert", "", claimedKeys{"alice": true}), "divert=alice", false},
+		{"shared worker handles unclaimed key", divert.NewSelector(divert.ModeShared, "divert", "", claimedKeys{"alice": true}), "divert=bob", true},
+		{"diverted worker skips shared messages", divert.NewSelector(divert.ModeDiverted, "divert", "alice", nil), "", false},
+		{"diverted worker handles its namespace", divert.NewSelector(divert.ModeDiverted, "divert", "alice", nil), "divert=alice", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &Consumer{selector: tt.selector, retry: RetryPolicy{MaxAttempts: 1}}
+
+			handled := false
+			err := c.process(context.Background(), bookingMessage(1, tt.baggage), func(ctx context.Context, message *sarama.ConsumerMessage) error {
+				handled = true
+				return nil
+			})
+			if err != nil {
+				t.Fatalf("process failed: %v", err)
+			}
+			if handled != tt.handled {
+				t.Errorf("handled = %v, want %v", handled, tt.handled)
+			}
+		})
+	}
+}
+
+// fakeSession records the offsets a consumer group session marks.
+type fakeSession struct {
+	sarama.ConsumerGroupSession
+	ctx    context.Context
+	marked []int64
+}
+
+func (s *fakeSession) Context() context.Context {
+	return s.ctx
+}
+
+func (s *fakeSession) MarkMessage(message *sarama.ConsumerMessage, metadata string) {
+	s.marked = append(s.marked, message.Offset)
+}
+
+// fakeClaim is a claim of partition 0 of booking-events.
+type fakeClaim struct {
+	messages chan *sarama.ConsumerMessage
+}
+
+func (c *fakeClaim) Topic() string                            { return "booking-events" }
+func (c *fakeClaim) Partition() int32                         { return 0 }
+func (c *fakeClaim) InitialOffset() int64                     { return 0 }
+func (c *fakeClaim) HighWaterMarkOffset() int64               { return 10 }
+func (c *fakeClaim) Messages() <-chan *sarama.ConsumerMessage { return c.messages }
+
+func TestConsumeClaimMarksOnlyFinishedMessages(t *testing.T) {
+	deadLetters := mocks.NewSyncProducer(t, nil)
+	deadLetters.ExpectSendMessageAndFail(sarama.ErrOutOfBrokers)
+	defer deadLetters.Close()
+
+	c := &Consumer{retry: RetryPolicy{MaxAttempts: 1}, deadLetters: &DeadLetterQueue{producer: deadLetters}}
+	c.topics = map[string]func(context.Context, *sarama.ConsumerMessage) error{
+		"booking-events": func(ctx context.Context, message *sarama.ConsumerMessage) error {
+			if message.Offset == 3 {
+				return Permanent(errors.New("user not found"))
+			}
+			return nil
+		},
+	}
+
+	claim := &fakeClaim{messages: make(chan *sarama.ConsumerMessage, 4)}
+	for offset := int64(1); offset <= 4; offset++ {
+		claim.messages <- bookingMessage(offset, "")
+	}
+	session := &fakeSession{ctx: context.Background()}
+	ended := false
+	handler := &groupHandler{consumer: c, endSession: func() { ended = true }}
+
+	if err := handler.ConsumeClaim(session, claim); err != nil {
+		t.Fatalf("ConsumeClaim failed: %v", err)
+	}
+
+	// Message 3 could be neither handled nor dead-lettered, so it and
+	// everything after it stays unmarked and the session ends to redeliver it
+	if !reflect.DeepEqual(session.marked, []int64{1, 2}) {
+		t.Errorf("marked offsets = %v, want [1 2]", session.marked)
+	}
+	if !ended {
+		t.Error("session was not ended after the failed message")
+	}
+	if len(claim.messages) != 1 {
+		t.Errorf("%d messages left unread, want 1", len(claim.messages))
+	}
+}
+
+func TestNewConsumerRejectsUnknownInitialOffset(t *testing.T) {
+	_, err := NewConsumer(ConsumerOptions{Brokers: []string{"localhost:9092"}, GroupID: "worker", InitialOffset: "latest"}, EventHandlers{}, nil)
+	if err == nil || !strings.Contains(err.Error(), "invalid initial offset") {
+		t.Errorf("NewConsumer error = %v, want an invalid initial offset", err)
+	}
+}
+
+func TestProcessRetriesTransientErrors(t *testing.T) {
+	deadLetters := mocks.NewSyncProducer(t, nil)
+	defer deadLetters.Close()
+	c := &Consumer{
+		retry:       RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond},
+		deadLetters: &DeadLetterQueue{producer: deadLetters},
+	}
+
+	calls := 0
+	err := c.process(context.Background(), bookingMessage(1, ""), func(ctx context.Context, message *sarama.ConsumerMessage) error {
+		calls++
+		if calls < 3 {
+			return errors.New("connection refused")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("process failed: %v", err)
+	}
+	if calls != 3 {
+		t.Errorf("handler ran %d times, want 3", calls)
+	}
+}
+
+func TestProcessDeadLettersFailedMessages(t *testing.T) {
+	tests := []struct {
+		name         string
+		err          error
+		wantAttempts string
+	}{
+		{"permanent error is not retried", Permanent(errors.New("user not found with identifier: alice")), "1"},
+		{"transient error runs out of attempts", errors.New("connection refused"), "3"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			deadLetters := mocks.NewSyncProducer(t, nil)
+			deadLetters.ExpectSendMessageWithMessageCheckerFunctionAndSucceed(func(msg *sarama.ProducerMessage) error {
+				headers := producerCarrier{headers: &msg.Headers}
+				switch {
+				case msg.Topic != "booking-events.dlq":
+					return fmt.Errorf("sent to %s", msg.Topic)
+				case headers.Get(BaggageHeader) != "divert=alice":
+					return errors.New("original baggage header was dropped")
+				case headers.Get(HeaderOriginalTopic) != "booking-events" || headers.Get(HeaderOriginalOffset) != "7":
+					return fmt.Errorf("origin headers are %v", headers.Keys())
+				case headers.Get(HeaderAttempts) != tt.wantAttempts:
+					return fmt.Errorf("%s = %s, want %s", HeaderAttempts, headers.Get(HeaderAttempts), tt.wantAttempts)
+				case headers.Get(HeaderError) != tt.err.Error():
+					return fmt.Errorf("%s = %q", HeaderError, headers.Get(HeaderError))
+				}
+				return nil
+			})
+			defer deadLetters.Close()
+
+			c := &Consumer{
+				retry:       RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond},
+				deadLetters: &DeadLetterQueue{producer: deadLetters},
+			}
+			calls := 0
+			err := c.process(context.Background(), bookingMessage(7, "divert=alice"), func(ctx context.Context, message *sarama.ConsumerMessage) error {
+				calls++
+				return tt.err
+			})
+			if err != nil {
+				t.Fatalf("process failed: %v", err)
+			}
+			if fmt.Sprint(calls) != tt.wantAttempts {
+				t.Errorf("handler ran %d times, want %s", calls, tt.wantAttempts)
+			}
+		})
+	}
+}
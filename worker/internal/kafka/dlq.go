@@ -0,0 +1,255 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/IBM/sarama"
+
+	"worker/internal/logger"
+)
+
+// DeadLetterSuffix is appended to a topic name to get its dead-letter topic.
+const DeadLetterSuffix = ".dlq"
+
+// Headers added to dead-lettered messages next to the original headers.
+const (
+	HeaderOriginalTopic     = "dlq-original-topic"
+	HeaderOriginalPartition = "dlq-original-partition"
+	HeaderOriginalOffset    = "dlq-original-offset"
+	HeaderError             = "dlq-error"
+	HeaderAttempts          = "dlq-attempts"
+	HeaderFailedAt          = "dlq-failed-at"
+)
+
+// DeadLetterQueue publishes messages that could not be handled to the
+// dead-letter topic of their original topic.
+type DeadLetterQueue struct {
+	producer sarama.SyncProducer
+}
+
+func NewDeadLetterQueue(brokers []string) (*DeadLetterQueue, error) {
+	producer, err := sarama.NewSyncProducer(brokers, newProducerConfig())
+	if err != nil {
+		return nil, fmt.Errorf("failed to create dead-letter producer: %w", err)
+	}
+	return &DeadLetterQueue{producer: producer}, nil
+}
+
+// Publish sends message to its dead-letter topic with the same key, value
+// and headers, plus headers describing where it came from and why it failed.
+func (q *DeadLetterQueue) Publish(ctx context.Context, message *sarama.ConsumerMessage, handlerErr error, attempts int) error {
+	topic := message.Topic + DeadLetterSuffix
+
+	msg := &sarama.ProducerMessage{
+		Topic: topic,
+		Value: sarama.ByteEncoder(message.Value),
+	}
+	if message.Key != nil {
+		msg.Key = sarama.ByteEncoder(message.Key)
+	}
+	carrier := producerCarrier{headers: &msg.Headers}
+	for _, h := range message.Headers {
+		if h != nil {
+			carrier.Set(string(h.Key), string(h.Value))
+		}
+	}
+	carrier.Set(HeaderOriginalTopic, message.Topic)
+	carrier.Set(HeaderOriginalPartition, strconv.Itoa(int(message.Partition)))
+	carrier.Set(HeaderOriginalOffset, strconv.FormatInt(message.Offset, 10))
+	carrier.Set(HeaderError, handlerErr.Error())
+	carrier.Set(HeaderAttempts, strconv.Itoa(attempts))
+	carrier.Set(HeaderFailedAt, time.Now().UTC().Format(time.RFC3339))
+
+	partition, offset, err := q.producer.SendMessage(msg)
+	if err != nil {
+		return fmt.Errorf("failed to publish to %s: %w", topic, err)
+	}
+
+	logger.Info(ctx, "Message sent to dead-letter topic", "topic", topic, "partition", partition, "offset", offset)
+	return nil
+}
+
+func (q *DeadLetterQueue) Close() error {
+	return q.producer.Close()
+}
+
+// RedriveOptions configure a dead-letter re-drive.
+type RedriveOptions struct {
+	Brokers []string
+	// Topic is the dead-letter topic to drain, e.g. booking-events.dlq.
+	Topic string
+	// GroupID stores how far the dead-letter topic has been re-driven, so
+	// each message is re-driven once.
+	GroupID string
+	// Limit stops after this many messages; zero means no limit.
+	Limit int
+	// DryRun logs the messages without publishing or committing them.
+	DryRun bool
+}
+
+// Redrive publishes the messages waiting in a dead-letter topic back to
+// their original topic, without the dead-letter headers, and returns how
+// many it re-drove. It stops at the end of the topic as seen when it started.
+func Redrive(ctx context.Context, opts RedriveOptions) (int, error) {
+	config := newProducerConfig()
+	config.Consumer.Offsets.Initial = sarama.OffsetOldest
+
+	client, err := sarama.NewClient(opts.Brokers, config)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create Kafka client: %w", err)
+	}
+	defer client.Close()
+
+	producer, err := sarama.NewSyncProducerFromClient(client)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create producer: %w", err)
+	}
+	defer producer.Close()
+
+	consumer, err := sarama.NewConsumerFromClient(client)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create consumer: %w", err)
+	}
+	defer consumer.Close()
+
+	offsets, err := sarama.NewOffsetManagerFromClient(opts.GroupID, client)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create offset manager: %w", err)
+	}
+	defer offsets.Close()
+
+	partitions, err := consumer.Partitions(opts.Topic)
+	if errors.Is(err, sarama.ErrUnknownTopicOrPartition) {
+		// Nothing has been dead-lettered yet
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to get partitions for topic %s: %w", opts.Topic, err)
+	}
+
+	redriven := 0
+	for _, partition := range partitions {
+		if opts.Limit > 0 && redriven >= opts.Limit {
+			break
+		}
+
+		pom, err := offsets.ManagePartition(opts.Topic, partition)
+		if err != nil {
+			return redriven, fmt.Errorf("failed to manage offsets of partition %d: %w", partition, err)
+		}
+		n, err := redrivePartition(ctx, client, consumer, producer, pom, opts, partition, opts.Limit-redriven)
+		redriven += n
+		if !opts.DryRun {
+			offsets.Commit()
+		}
+		pom.Close()
+		if err != nil {
+			return redriven, err
+		}
+	}
+
+	return redriven, nil
+}
+
+func redrivePartition(ctx context.Context, client sarama.Client, consumer sarama.Consumer, producer sarama.SyncProducer, pom sarama.PartitionOffsetManager, opts RedriveOptions, partition int32, limit int) (int, error) {
+	oldest, err := client.GetOffset(opts.Topic, partition, sarama.OffsetOldest)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get oldest offset of partition %d: %w", partition, err)
+	}
+	newest, err := client.GetOffset(opts.Topic, partition, sarama.OffsetNewest)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get newest offset of partition %d: %w", partition, err)
+	}
+
+	next, _ := pom.NextOffset()
+	if next < oldest {
+		next = oldest
+	}
+	if next >= newest {
+		return 0, nil
+	}
+
+	pc, err := consumer.ConsumePartition(opts.Topic, partition, next)
+	if err != nil {
+		return 0, fmt.Errorf("failed to consume partition %d: %w", partition, err)
+	}
+	defer pc.Close()
+
+	redriven := 0
+	for {
+		var message *sarama.ConsumerMessage
+		select {
+		case message = <-pc.Messages():
+		case <-time.After(10 * time.Second):
+			return redriven, fmt.Errorf("timed out reading partition %d at offset %d", partition, next)
+		case <-ctx.Done():
+			return redriven, ctx.Err()
+		}
+
+		if err := redriveMessage(ctx, producer, message, opts); err != nil {
+			return redriven, err
+		}
+		if !opts.DryRun {
+			pom.MarkOffset(message.Offset+1, "")
+		}
+		redriven++
+		next = message.Offset + 1
+
+		if next >= newest || (limit > 0 && redriven >= limit) {
+			return redriven, nil
+		}
+	}
+}
+
+func redriveMessage(ctx context.Context, producer sarama.SyncProducer, message *sarama.ConsumerMessage, opts RedriveOptions) error {
+	dlqHeaders := consumerCarrier(message.Headers)
+	topic := dlqHeaders.Get(HeaderOriginalTopic)
+	if topic == "" {
+		topic = strings.TrimSuffix(message.Topic, DeadLetterSuffix)
+	}
+
+	msg := &sarama.ProducerMessage{
+		Topic: topic,
+		Value: sarama.ByteEncoder(message.Value),
+	}
+	if message.Key != nil {
+		msg.Key = sarama.ByteEncoder(message.Key)
+	}
+	for _, h := range message.Headers {
+		if h != nil && !strings.HasPrefix(strings.ToLower(string(h.Key)), "dlq-") {
+			msg.Headers = append(msg.Headers, *h)
+		}
+	}
+
+	logArgs := []any{
+		"from", message.Topic,
+		"offset", message.Offset,
+		"to", topic,
+		"key", string(message.Key),
+		"error", dlqHeaders.Get(HeaderError),
+		"attempts", dlqHeaders.Get(HeaderAttempts),
+	}
+	if opts.DryRun {
+		logger.Info(ctx, "Would re-drive dead-lettered message", logArgs...)
+		return nil
+	}
+
+	if _, _, err := producer.SendMessage(msg); err != nil {
+		return fmt.Errorf("failed to re-drive offset %d to %s: %w", message.Offset, topic, err)
+	}
+	logger.Info(ctx, "Re-drove dead-lettered message", logArgs...)
+	return nil
+}
+
+func newProducerConfig() *sarama.Config {
+	config := sarama.NewConfig()
+	config.Producer.Return.Successes = true
+	config.Producer.RequiredAcks = sarama.WaitForAll
+	config.Producer.Retry.Max = 5
+	return config
+}
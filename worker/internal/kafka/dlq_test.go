@@ -0,0 +1,77 @@
+package kafka
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/IBM/sarama"
+	"github.com/IBM/sarama/mocks"
+)
+
+// deadLetteredMessage returns a message as Publish stores it in the
+// dead-letter topic of booking-events.
+func deadLetteredMessage() *sarama.ConsumerMessage {
+	return &sarama.ConsumerMessage{
+		Topic:  "booking-events.dlq",
+		Offset: 4,
+		Key:    []byte("booking_1"),
+		Value:  []byte(`{"bookingId":"booking_1"}`),
+		Headers: []*sarama.RecordHeader{
+			{Key: []byte(BaggageHeader), Value: []byte("divert=alice")},
+			{Key: []byte(HeaderOriginalTopic), Value: []byte("booking-events")},
+			{Key: []byte(HeaderOriginalOffset), Value: []byte("7")},
+			{Key: []byte(HeaderError), Value: []byte("user not found with identifier: alice")},
+			{Key: []byte(HeaderAttempts), Value: []byte("1")},
+		},
+	}
+}
+
+func TestRedriveMessage(t *testing.T) {
+	producer := mocks.NewSyncProducer(t, nil)
+	producer.ExpectSendMessageWithMessageCheckerFunctionAndSucceed(func(msg *sarama.ProducerMessage) error {
+		key, _ := msg.Key.Encode()
+		switch {
+		case msg.Topic != "booking-events":
+			return fmt.Errorf("re-drove to %s", msg.Topic)
+		case string(key) != "booking_1":
+			return fmt.Errorf("key is %q", key)
+		case len(msg.Headers) != 1 || string(msg.Headers[0].Key) != BaggageHeader:
+			// The dead-letter headers would describe a failure that the
+			// re-driven message has not had
+			return fmt.Errorf("headers are %v, want only the baggage", msg.Headers)
+		}
+		return nil
+	})
+	defer producer.Close()
+
+	if err := redriveMessage(context.Background(), producer, deadLetteredMessage(), RedriveOptions{Topic: "booking-events.dlq"}); err != nil {
+		t.Fatalf("redriveMessage failed: %v", err)
+	}
+}
+
+func TestRedriveMessageDryRun(t *testing.T) {
+	// The mock fails the test on any send, since none is expected
+	producer := mocks.NewSyncProducer(t, nil)
+	defer producer.Close()
+
+	if err := redriveMessage(context.Background(), producer, deadLetteredMessage(), RedriveOptions{Topic: "booking-events.dlq", DryRun: true}); err != nil {
+		t.Fatalf("redriveMessage failed: %v", err)
+	}
+}
+
+func TestRedriveMessageWithoutOriginHeader(t *testing.T) {
+	producer := mocks.NewSyncProducer(t, nil)
+	producer.ExpectSendMessageWithMessageCheckerFunctionAndSucceed(func(msg *sarama.ProducerMessage) error {
+		if msg.Topic != "booking-cancellations" {
+			return fmt.Errorf("re-drove to %s", msg.Topic)
+		}
+		return nil
+	})
+	defer producer.Close()
+
+	message := &sarama.ConsumerMessage{Topic: "booking-cancellations.dlq", Value: []byte(`{}`)}
+	if err := redriveMessage(context.Background(), producer, message, RedriveOptions{Topic: "booking-cancellations.dlq"}); err != nil {
+		t.Fatalf("redriveMessage failed: %v", err)
+	}
+}
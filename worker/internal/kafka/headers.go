@@ -0,0 +1,94 @@
+package kafka
+
+import (
+	"context"
+	"strings"
+
+	"github.com/IBM/sarama"
+	"go.opentelemetry.io/otel"
+
+	"worker/internal/middleware"
+)
+
+// BaggageHeader is the record header carrying the W3C baggage string. It is
+// written in lower case; readers match header names case-insensitively so
+// producers that write "Baggage" are understood too.
+const BaggageHeader = "baggage"
+
+// InjectHeaders copies the baggage and trace context of ctx into the headers
+// of msg, replacing any existing values.
+func InjectHeaders(ctx context.Context, msg *sarama.ProducerMessage) {
+	carrier := producerCarrier{headers: &msg.Headers}
+	if baggage := middleware.GetBaggageFromContext(ctx); baggage != "" {
+		carrier.Set(BaggageHeader, baggage)
+	}
+	otel.GetTextMapPropagator().Inject(ctx, carrier)
+}
+
+// ExtractHeaders returns ctx carrying the baggage and trace context found in
+// the headers of a consumed message.
+func ExtractHeaders(ctx context.Context, headers []*sarama.RecordHeader) context.Context {
+	carrier := consumerCarrier(headers)
+	if baggage := carrier.Get(BaggageHeader); baggage != "" {
+		ctx = middleware.WithBaggage(ctx, baggage)
+	}
+	return otel.GetTextMapPropagator().Extract(ctx, carrier)
+}
+
+// producerCarrier adapts producer record headers to the OpenTelemetry
+// TextMapCarrier interface.
+type producerCarrier struct {
+	headers *[]sarama.RecordHeader
+}
+
+func (c producerCarrier) Get(key string) string {
+	for _, h := range *c.headers {
+		if strings.EqualFold(string(h.Key), key) {
+			return string(h.Value)
+		}
+	}
+	return ""
+}
+
+func (c producerCarrier) Set(key, value string) {
+	for i, h := range *c.headers {
+		if strings.EqualFold(string(h.Key), key) {
+			(*c.headers)[i] = sarama.RecordHeader{Key: []byte(key), Value: []byte(value)}
+			return
+		}
+	}
+	*c.headers = append(*c.headers, sarama.RecordHeader{Key: []byte(key), Value: []byte(value)})
+}
+
+func (c producerCarrier) Keys() []string {
+	keys := make([]string, 0, len(*c.headers))
+	for _, h := range *c.headers {
+		keys = append(keys, string(h.Key))
+	}
+	return keys
+}
+
+// consumerCarrier adapts consumed record headers to the OpenTelemetry
+// TextMapCarrier interface. Consumed headers are read-only, so Set is a no-op.
+type consumerCarrier []*sarama.RecordHeader
+
+func (c consumerCarrier) Get(key string) string {
+	for _, h := range c {
+		if h != nil && strings.EqualFold(string(h.Key), key) {
+			return string(h.Value)
+		}
+	}
+	return ""
+}
+
+func (c consumerCarrier) Set(key, value string) {}
+
+func (c consumerCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for _, h := range c {
+		if h != nil {
+			keys = append(keys, string(h.Key))
+		}
+	}
+	return keys
+}
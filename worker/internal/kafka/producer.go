@@ -0,0 +1,75 @@
+package kafka
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/IBM/sarama"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"worker/internal/logger"
+)
+
+// CancellationResultsTopic receives the outcome of every cancellation event.
+const CancellationResultsTopic = "booking-cancellation-results"
+
+// Producer publishes the events the worker emits, carrying the baggage and
+// trace context of the message being handled.
+type Producer struct {
+	producer sarama.SyncProducer
+}
+
+func NewProducer(brokers []string) (*Producer, error) {
+	producer, err := sarama.NewSyncProducer(brokers, newProducerConfig())
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Kafka producer: %w", err)
+	}
+	return &Producer{producer: producer}, nil
+}
+
+// SendMessage publishes message as JSON to topic under key.
+func (p *Producer) SendMessage(ctx context.Context, topic string, key string, message interface{}) error {
+	messageBytes, err := json.Marshal(message)
+	if err != nil {
+		return fmt.Errorf("failed to marshal message: %w", err)
+	}
+
+	msg := &sarama.ProducerMessage{
+		Topic: topic,
+		Key:   sarama.StringEncoder(key),
+		Value: sarama.ByteEncoder(messageBytes),
+	}
+
+	ctx, span := tracer.Start(ctx, topic+" publish",
+		trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithAttributes(
+			semconv.MessagingSystemKafka,
+			semconv.MessagingDestinationName(topic),
+			semconv.MessagingOperationTypePublish,
+		),
+	)
+	defer span.End()
+	InjectHeaders(ctx, msg)
+
+	partition, offset, err := p.producer.SendMessage(msg)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return fmt.Errorf("failed to send message to %s: %w", topic, err)
+	}
+	span.SetAttributes(
+		semconv.MessagingDestinationPartitionID(strconv.Itoa(int(partition))),
+		semconv.MessagingKafkaMessageOffset(int(offset)),
+	)
+
+	logger.Info(ctx, "Message sent to Kafka", "topic", topic, "key", key, "partition", partition, "offset", offset)
+	return nil
+}
+
+func (p *Producer) Close() error {
+	return p.producer.Close()
+}
@@ -0,0 +1,80 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"time"
+)
+
+// RetryPolicy retries messages whose handler failed with a transient error
+// before they are dead-lettered. MaxAttempts includes the first attempt.
+// Retries wait a random delay, so partitions that failed together, e.g.
+// while the database was down, do not retry in lockstep. The gateway jitters
+// its upstream retries too, but counts retries rather than attempts; the
+// worker is its own module and shares no code with it.
+type RetryPolicy struct {
+	MaxAttempts int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+}
+
+// permanentError marks a failure that retrying cannot fix.
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string {
+	return e.err.Error()
+}
+
+func (e *permanentError) Unwrap() error {
+	return e.err
+}
+
+// Permanent marks err as a failure that retrying cannot fix, such as a
+// malformed event or a reference to a user that does not exist. The
+// message goes straight to the dead-letter topic.
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err: err}
+}
+
+// IsPermanent reports whether err was marked with Permanent.
+func IsPermanent(err error) bool {
+	var permanent *permanentError
+	return errors.As(err, &permanent)
+}
+
+// delay returns how long to wait after failed attempts of a message:
+// a random duration up to BaseDelay, doubled for every attempt after the
+// first and capped at MaxDelay.
+func (p RetryPolicy) delay(failed int) time.Duration {
+	limit := p.MaxDelay
+	if failed > 0 && failed < 63 {
+		if doubled := p.BaseDelay << (failed - 1); doubled > 0 && doubled < limit {
+			limit = doubled
+		}
+	}
+	if limit <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(limit) + 1))
+}
+
+// sleep waits out the delay after failed attempts. It returns early with
+// the error of ctx when the consumer session ends, since the message is then
+// redelivered anyway.
+func (p RetryPolicy) sleep(ctx context.Context, failed int) error {
+	timer := time.NewTimer(p.delay(failed))
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,57 @@
+package kafka
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestRetryDelayBounds(t *testing.T) {
+	policy := RetryPolicy{MaxAttempts: 5, BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second}
+
+	tests := []struct {
+		failed int
+		max    time.Duration
+	}{
+		{1, 100 * time.Millisecond},
+		{2, 200 * time.Millisecond},
+		{4, 800 * time.Millisecond},
+		{5, time.Second},
+		{200, time.Second},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprint(tt.failed), func(t *testing.T) {
+			var longest time.Duration
+			for range 2000 {
+				delay := policy.delay(tt.failed)
+				if delay < 0 || delay > tt.max {
+					t.Fatalf("delay(%d) = %s, want within [0, %s]", tt.failed, delay, tt.max)
+				}
+				longest = max(longest, delay)
+			}
+			// Delays are jittered over the whole range
+			if longest < tt.max/2 {
+				t.Errorf("longest of 2000 delays is %s, want jitter up to %s", longest, tt.max)
+			}
+		})
+	}
+
+	if delay := (RetryPolicy{}).delay(3); delay != 0 {
+		t.Errorf("delay without a base delay = %s, want 0", delay)
+	}
+}
+
+func TestIsPermanent(t *testing.T) {
+	permanent := Permanent(errors.New("malformed event"))
+
+	if !IsPermanent(permanent) || !IsPermanent(fmt.Errorf("handling: %w", permanent)) {
+		t.Error("Permanent error not recognised")
+	}
+	if IsPermanent(errors.New("connection refused")) {
+		t.Error("plain error reported permanent")
+	}
+	if Permanent(nil) != nil {
+		t.Error("Permanent(nil) is not nil")
+	}
+}
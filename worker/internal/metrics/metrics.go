@@ -0,0 +1,98 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+var (
+	messagesConsumed = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "kafka_consumer_messages_total",
+		Help: "Kafka messages received by topic.",
+	}, []string{"topic"})
+
+	handlerErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "kafka_consumer_handler_errors_total",
+		Help: "Kafka messages whose handler returned an error, by topic.",
+	}, []string{"topic"})
+
+	handlerDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "kafka_consumer_handler_duration_seconds",
+		Help:    "Time spent handling a Kafka message, by topic.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"topic"})
+
+	messagesSkipped = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "kafka_consumer_messages_skipped_total",
+		Help: "Kafka messages not handled because they belong to another divert namespace, by topic and reason.",
+	}, []string{"topic", "reason"})
+
+	messageRetries = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "kafka_consumer_retries_total",
+		Help: "Handler retries after a transient failure, by topic.",
+	}, []string{"topic"})
+
+	messagesDeadLettered = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "kafka_consumer_dead_lettered_total",
+		Help: "Messages sent to the dead-letter topic, by topic and reason (permanent or exhausted).",
+	}, []string{"topic", "reason"})
+
+	consumerLag = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "kafka_consumer_lag",
+		Help: "Messages between the last consumed offset and the partition high water mark.",
+	}, []string{"topic", "partition"})
+)
+
+// NewServer returns the HTTP server exposing /metrics on port. The worker
+// has no other HTTP surface, so this is its only listener.
+func NewServer(port string) *http.Server {
+	mux := http.NewServeMux()
+	mux.Handle("GET /metrics", promhttp.Handler())
+
+	return &http.Server{
+		Addr:         ":" + port,
+		Handler:      mux,
+		ReadTimeout:  5 * time.Second,
+		WriteTimeout: 15 * time.Second,
+	}
+}
+
+// ObserveReceived counts a received message and records the partition lag
+// measured against highWaterMark, the offset the next produced message will get.
+func ObserveReceived(topic string, partition int32, offset, highWaterMark int64) {
+	messagesConsumed.WithLabelValues(topic).Inc()
+
+	lag := highWaterMark - offset - 1
+	if lag < 0 {
+		lag = 0
+	}
+	consumerLag.WithLabelValues(topic, strconv.Itoa(int(partition))).Set(float64(lag))
+}
+
+// ObserveHandled records how long a handler took and whether it failed.
+func ObserveHandled(topic string, duration time.Duration, err error) {
+	handlerDuration.WithLabelValues(topic).Observe(duration.Seconds())
+	if err != nil {
+		handlerErrors.WithLabelValues(topic).Inc()
+	}
+}
+
+// IncSkipped counts a message left for another consumer, by topic and reason.
+func IncSkipped(topic, reason string) {
+	messagesSkipped.WithLabelValues(topic, reason).Inc()
+}
+
+// IncRetry counts a retried message.
+func IncRetry(topic string) {
+	messageRetries.WithLabelValues(topic).Inc()
+}
+
+// IncDeadLettered counts a message sent to the dead-letter topic.
+func IncDeadLettered(topic, reason string) {
+	messagesDeadLettered.WithLabelValues(topic, reason).Inc()
+}
@@ -0,0 +1,31 @@
+package metrics
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestObserveReceivedLag(t *testing.T) {
+	tests := []struct {
+		name          string
+		offset        int64
+		highWaterMark int64
+		want          float64
+	}{
+		{"behind", 4, 10, 5},
+		{"caught up", 9, 10, 0},
+		{"high water mark read before the message arrived", 12, 10, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ObserveReceived("metrics-test", 0, tt.offset, tt.highWaterMark)
+			if got := testutil.ToFloat64(consumerLag.WithLabelValues("metrics-test", "0")); got != tt.want {
+				t.Errorf("lag = %v, want %v", got, tt.want)
+			}
+		})
+	}
+	if got := testutil.ToFloat64(messagesConsumed.WithLabelValues("metrics-test")); got != float64(len(tests)) {
+		t.Errorf("messages counted = %v, want %d", got, len(tests))
+	}
+}
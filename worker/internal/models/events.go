@@ -16,4 +16,29 @@ type CancellationEvent struct {
 	BookingID string    `json:"bookingId"`
 	UserID    string    `json:"userId"`
 	Timestamp time.Time `json:"timestamp"`
+}
+
+// Booking statuses. A booking is Pending until the worker accepts or
+// refuses it, and only Pending and Accepted bookings can be Cancelled.
+const (
+	BookingPending   = "Pending"
+	BookingAccepted  = "Accepted"
+	BookingRefused   = "Refused"
+	BookingCancelled = "Cancelled"
+)
+
+// Outcomes of a cancellation event, published in CancellationResultEvent.
+const (
+	CancellationCancelled        = "cancelled"
+	CancellationAlreadyCancelled = "already_cancelled"
+	CancellationRefused          = "refused"
+	CancellationNotFound         = "not_found"
+	CancellationNotOwned         = "not_owned"
+)
+
+type CancellationResultEvent struct {
+	BookingID string    `json:"bookingId"`
+	UserID    string    `json:"userId"`
+	Result    string    `json:"result"`
+	Timestamp time.Time `json:"timestamp"`
 }
\ No newline at end of file
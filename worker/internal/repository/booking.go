@@ -3,12 +3,42 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"time"
 
 	"worker/internal/models"
 )
 
+// Errors for events that are incomplete or reference data which does not
+// exist. Retrying them cannot succeed, see IsPermanent.
+var (
+	ErrUserNotFound     = errors.New("user not found")
+	ErrRoomNotFound     = errors.New("room not found")
+	ErrMissingBookingID = errors.New("booking event has no booking ID")
+)
+
+// Reasons recorded for refused bookings.
+const (
+	RefusalUserNotFound = "User not found"
+	RefusalRoomNotFound = "Room not found"
+	RefusalUnavailable  = "Room is not available for the specified dates"
+)
+
+// querier is implemented by both *sql.DB and *sql.Tx.
+type querier interface {
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// BookingOutcome is the status of a booking after its event was handled.
+// Decided is false when an earlier delivery of the event had already
+// accepted or refused it.
+type BookingOutcome struct {
+	Status        string
+	RefusalReason string
+	Decided       bool
+}
+
 type BookingRepository struct {
 	db *sql.DB
 }
@@ -17,46 +47,167 @@ func NewBookingRepository(db *sql.DB) *BookingRepository {
 	return &BookingRepository{db: db}
 }
 
-func (r *BookingRepository) CreateBooking(ctx context.Context, event models.BookingEvent) error {
-	// First, get the user ID from the UserID string (assuming it's the user's ID)
-	userID, err := r.getUserIDByIdentifier(ctx, event.UserID)
-	if err != nil {
-		return fmt.Errorf("failed to get user ID: %w", err)
+// CreateBooking accepts or refuses the booking of event and stores it under
+// its external booking ID. It is refused when the user or room does not
+// exist, the room is too small, or the room is taken for the dates; the room
+// row is locked while deciding so two bookings cannot both take it, and a
+// booking the overlap constraint on bookings rejects is refused too. Events
+// are delivered at least once, so a booking that was already decided is left
+// untouched, while a Pending one is decided now.
+func (r *BookingRepository) CreateBooking(ctx context.Context, event models.BookingEvent) (BookingOutcome, error) {
+	if event.BookingID == "" {
+		return BookingOutcome{}, ErrMissingBookingID
 	}
 
-	// Get the room ID from the internal_id
-	roomID, err := r.getRoomIDByInternalID(ctx, event.RoomID)
+	tx, err := r.db.BeginTx(ctx, nil)
 	if err != nil {
-		return fmt.Errorf("failed to get room ID: %w", err)
+		return BookingOutcome{}, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	existing, err := r.getBookingStatus(ctx, tx, event.BookingID, true)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return BookingOutcome{}, err
 	}
+	if err == nil && existing.Status != models.BookingPending {
+		return existing, nil
+	}
+
+	outcome := BookingOutcome{Status: models.BookingAccepted, Decided: true}
+	var userID, roomID sql.NullInt64
 
-	// Insert the booking
+	// First, get the user ID from the UserID string (assuming it's the user's ID)
+	id, err := r.getUserIDByIdentifier(ctx, tx, event.UserID)
+	switch {
+	case errors.Is(err, ErrUserNotFound):
+		outcome = refused(RefusalUserNotFound)
+	case err != nil:
+		return BookingOutcome{}, fmt.Errorf("failed to get user ID: %w", err)
+	default:
+		userID = sql.NullInt64{Int64: int64(id), Valid: true}
+	}
+
+	// Get the room from the internal_id, locking it until the booking is stored
+	room, err := r.lockRoomByInternalID(ctx, tx, event.RoomID)
+	switch {
+	case errors.Is(err, ErrRoomNotFound):
+		if outcome.Status == models.BookingAccepted {
+			outcome = refused(RefusalRoomNotFound)
+		}
+	case err != nil:
+		return BookingOutcome{}, fmt.Errorf("failed to get room ID: %w", err)
+	default:
+		roomID = sql.NullInt64{Int64: int64(room.id), Valid: true}
+	}
+
+	if outcome.Status == models.BookingAccepted {
+		if event.Guests > room.capacity {
+			outcome = refused(fmt.Sprintf("Number of guests (%d) exceeds room capacity (%d)", event.Guests, room.capacity))
+		} else {
+			available, err := r.isRoomAvailable(ctx, tx, room.id, event)
+			if err != nil {
+				return BookingOutcome{}, err
+			}
+			if !available {
+				outcome = refused(RefusalUnavailable)
+			}
+		}
+	}
+
+	// A Pending booking stored earlier is decided in place
 	query := `
-		INSERT INTO bookings (user_id, room_id, number_of_guests, start_date, end_date, payment_id, status, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		INSERT INTO bookings (external_id, user_id, room_id, number_of_guests, start_date, end_date, payment_id, status, refusal_reason, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		ON CONFLICT (external_id) DO UPDATE
+		SET status = EXCLUDED.status, refusal_reason = EXCLUDED.refusal_reason, updated_at = EXCLUDED.updated_at
+		WHERE bookings.status = 'Pending'
 	`
 
-	now := time.Now()
-	_, err = r.db.ExecContext(ctx, query,
-		userID,
-		roomID,
-		event.Guests,
-		event.StartDate,
-		event.EndDate,
-		event.PaymentID,
-		"Accepted",
-		now,
-		now,
-	)
+	store := func(outcome BookingOutcome) (sql.Result, error) {
+		var refusalReason sql.NullString
+		if outcome.RefusalReason != "" {
+			refusalReason = sql.NullString{String: outcome.RefusalReason, Valid: true}
+		}
+
+		now := time.Now()
+		return tx.ExecContext(ctx, query,
+			event.BookingID,
+			userID,
+			roomID,
+			event.Guests,
+			event.StartDate,
+			event.EndDate,
+			event.PaymentID,
+			outcome.Status,
+			refusalReason,
+			now,
+			now,
+		)
+	}
+
+	// The overlap constraint on bookings has the last word on whether the
+	// room is free: a booking written without locking the room may have
+	// taken it since it was checked. The savepoint lets the booking be
+	// stored as refused instead.
+	if _, err := tx.ExecContext(ctx, `SAVEPOINT store_booking`); err != nil {
+		return BookingOutcome{}, fmt.Errorf("failed to create savepoint: %w", err)
+	}
+	result, err := store(outcome)
+	if IsOverlapViolation(err) {
+		if _, err := tx.ExecContext(ctx, `ROLLBACK TO SAVEPOINT store_booking`); err != nil {
+			return BookingOutcome{}, fmt.Errorf("failed to roll back to savepoint: %w", err)
+		}
+		outcome = refused(RefusalUnavailable)
+		result, err = store(outcome)
+	}
+	if err != nil {
+		return BookingOutcome{}, fmt.Errorf("failed to insert booking: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return BookingOutcome{}, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		// Another delivery of the same event decided the booking first
+		existing, err := r.getBookingStatus(ctx, tx, event.BookingID, false)
+		if err != nil {
+			return BookingOutcome{}, err
+		}
+		return existing, nil
+	}
+
+	if err := tx.Commit(); err != nil {
+		return BookingOutcome{}, fmt.Errorf("failed to commit booking: %w", err)
+	}
+	return outcome, nil
+}
+
+func refused(reason string) BookingOutcome {
+	return BookingOutcome{Status: models.BookingRefused, RefusalReason: reason, Decided: true}
+}
+
+func (r *BookingRepository) getBookingStatus(ctx context.Context, q querier, externalID string, forUpdate bool) (BookingOutcome, error) {
+	query := `SELECT status, refusal_reason FROM bookings WHERE external_id = $1`
+	if forUpdate {
+		query += ` FOR UPDATE`
+	}
 
+	var outcome BookingOutcome
+	var refusalReason sql.NullString
+	err := q.QueryRowContext(ctx, query, externalID).Scan(&outcome.Status, &refusalReason)
 	if err != nil {
-		return fmt.Errorf("failed to insert booking: %w", err)
+		if errors.Is(err, sql.ErrNoRows) {
+			return BookingOutcome{}, err
+		}
+		return BookingOutcome{}, fmt.Errorf("failed to query booking: %w", err)
 	}
 
-	return nil
+	outcome.RefusalReason = refusalReason.String
+	return outcome, nil
 }
 
-func (r *BookingRepository) getUserIDByIdentifier(ctx context.Context, userIdentifier string) (int, error) {
+func (r *BookingRepository) getUserIDByIdentifier(ctx context.Context, q querier, userIdentifier string) (int, error) {
 	var userID int
 
 	// Use CASE WHEN to safely handle numeric string conversion
@@ -67,10 +218,10 @@ func (r *BookingRepository) getUserIDByIdentifier(ctx context.Context, userIdent
 		   OR (CASE WHEN $1 ~ '^[0-9]+$' THEN id = CAST($1 AS INTEGER) ELSE false END)
 	`
 
-	err := r.db.QueryRowContext(ctx, query, userIdentifier).Scan(&userID)
+	err := q.QueryRowContext(ctx, query, userIdentifier).Scan(&userID)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return 0, fmt.Errorf("user not found with identifier: %s", userIdentifier)
+			return 0, fmt.Errorf("%w with identifier: %s", ErrUserNotFound, userIdentifier)
 		}
 		return 0, fmt.Errorf("failed to query user: %w", err)
 	}
@@ -78,49 +229,106 @@ func (r *BookingRepository) getUserIDByIdentifier(ctx context.Context, userIdent
 	return userID, nil
 }
 
-func (r *BookingRepository) getRoomIDByInternalID(ctx context.Context, internalID string) (int, error) {
-	var roomID int
-	query := `SELECT id FROM rooms WHERE internal_id = $1`
+type lockedRoom struct {
+	id       int
+	capacity int
+}
+
+func (r *BookingRepository) lockRoomByInternalID(ctx context.Context, q querier, internalID string) (lockedRoom, error) {
+	var room lockedRoom
+	query := `SELECT id, capacity FROM rooms WHERE internal_id = $1 FOR UPDATE`
 
-	err := r.db.QueryRowContext(ctx, query, internalID).Scan(&roomID)
+	err := q.QueryRowContext(ctx, query, internalID).Scan(&room.id, &room.capacity)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return 0, fmt.Errorf("room not found with internal ID: %s", internalID)
+			return lockedRoom{}, fmt.Errorf("%w with internal ID: %s", ErrRoomNotFound, internalID)
 		}
-		return 0, fmt.Errorf("failed to query room: %w", err)
+		return lockedRoom{}, fmt.Errorf("failed to query room: %w", err)
 	}
 
-	return roomID, nil
+	return room, nil
 }
 
-func (r *BookingRepository) CancelBooking(ctx context.Context, event models.CancellationEvent) error {
-	// Update the booking status to 'Cancelled' based on booking ID and user ID
+// isRoomAvailable reports whether no other Pending or Accepted booking of
+// the room overlaps the dates of event.
+func (r *BookingRepository) isRoomAvailable(ctx context.Context, q querier, roomID int, event models.BookingEvent) (bool, error) {
 	query := `
-		UPDATE bookings
-		SET status = 'Cancelled', updated_at = $1
-		WHERE id = $2 AND user_id = $3 AND status != 'Cancelled'
+		SELECT NOT EXISTS (
+			SELECT 1 FROM bookings
+			WHERE room_id = $1
+			AND external_id != $2
+			AND status IN ('Pending', 'Accepted')
+			AND start_date < $4
+			AND end_date > $3
+		)
 	`
 
-	// First, get the user ID from the UserID string
-	userID, err := r.getUserIDByIdentifier(ctx, event.UserID)
+	var available bool
+	err := q.QueryRowContext(ctx, query, roomID, event.BookingID, event.StartDate, event.EndDate).Scan(&available)
 	if err != nil {
-		return fmt.Errorf("failed to get user ID for cancellation: %w", err)
+		return false, fmt.Errorf("failed to check room availability: %w", err)
 	}
 
-	now := time.Now()
-	result, err := r.db.ExecContext(ctx, query, now, event.BookingID, userID)
+	return available, nil
+}
+
+// CancelBooking cancels the booking with the external ID of event on behalf
+// of its user and returns the outcome, one of the models.Cancellation*
+// results. Only failures to reach the database are returned as errors.
+func (r *BookingRepository) CancelBooking(ctx context.Context, event models.CancellationEvent) (string, error) {
+	var bookingID int
+	var ownerID sql.NullInt64
+	var status string
+	query := `SELECT id, user_id, status FROM bookings WHERE external_id = $1`
+
+	err := r.db.QueryRowContext(ctx, query, event.BookingID).Scan(&bookingID, &ownerID, &status)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.CancellationNotFound, nil
+	}
 	if err != nil {
-		return fmt.Errorf("failed to update booking status to cancelled: %w", err)
+		return "", fmt.Errorf("failed to query booking: %w", err)
+	}
+
+	// A user that does not exist cannot own the booking
+	userID, err := r.getUserIDByIdentifier(ctx, r.db, event.UserID)
+	if errors.Is(err, ErrUserNotFound) {
+		return models.CancellationNotOwned, nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get user ID for cancellation: %w", err)
+	}
+	if !ownerID.Valid || int64(userID) != ownerID.Int64 {
+		return models.CancellationNotOwned, nil
+	}
+
+	switch status {
+	case models.BookingCancelled:
+		return models.CancellationAlreadyCancelled, nil
+	case models.BookingRefused:
+		return models.CancellationRefused, nil
+	}
+
+	// The status check is repeated so a concurrent cancellation is reported
+	// as already cancelled rather than cancelled twice
+	update := `
+		UPDATE bookings
+		SET status = 'Cancelled', updated_at = $1
+		WHERE id = $2 AND status IN ('Pending', 'Accepted')
+	`
+
+	result, err := r.db.ExecContext(ctx, update, time.Now(), bookingID)
+	if err != nil {
+		return "", fmt.Errorf("failed to update booking status to cancelled: %w", err)
 	}
 
 	rowsAffected, err := result.RowsAffected()
 	if err != nil {
-		return fmt.Errorf("failed to get rows affected: %w", err)
+		return "", fmt.Errorf("failed to get rows affected: %w", err)
 	}
 
 	if rowsAffected == 0 {
-		return fmt.Errorf("no booking found to cancel with ID %s for user %s, or booking already cancelled", event.BookingID, event.UserID)
+		return models.CancellationAlreadyCancelled, nil
 	}
 
-	return nil
-}
\ No newline at end of file
+	return models.CancellationCancelled, nil
+}
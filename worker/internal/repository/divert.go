@@ -0,0 +1,67 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// DivertRepository stores the heartbeats of diverted workers in the
+// divert_consumers table, which the worker owns. The table is created by the
+// booking-management migrations.
+type DivertRepository struct {
+	db *sql.DB
+}
+
+func NewDivertRepository(db *sql.DB) *DivertRepository {
+	return &DivertRepository{db: db}
+}
+
+func (r *DivertRepository) Heartbeat(ctx context.Context, key, consumerID string) error {
+	query := `
+		INSERT INTO divert_consumers (divert_key, consumer_id, last_heartbeat)
+		VALUES ($1, $2, NOW())
+		ON CONFLICT (divert_key, consumer_id) DO UPDATE SET last_heartbeat = NOW()
+	`
+
+	if _, err := r.db.ExecContext(ctx, query, key, consumerID); err != nil {
+		return fmt.Errorf("failed to record divert heartbeat: %w", err)
+	}
+	return nil
+}
+
+func (r *DivertRepository) Release(ctx context.Context, key, consumerID string) error {
+	query := `DELETE FROM divert_consumers WHERE divert_key = $1 AND consumer_id = $2`
+
+	if _, err := r.db.ExecContext(ctx, query, key, consumerID); err != nil {
+		return fmt.Errorf("failed to release divert key: %w", err)
+	}
+	return nil
+}
+
+// ActiveKeys returns the divert keys with a heartbeat newer than ttl. The
+// cutoff uses the database clock, the same one that stamps heartbeats.
+func (r *DivertRepository) ActiveKeys(ctx context.Context, ttl time.Duration) ([]string, error) {
+	query := `
+		SELECT DISTINCT divert_key
+		FROM divert_consumers
+		WHERE last_heartbeat > NOW() - make_interval(secs => $1)
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, ttl.Seconds())
+	if err != nil {
+		return nil, fmt.Errorf("failed to query divert keys: %w", err)
+	}
+	defer rows.Close()
+
+	var keys []string
+	for rows.Next() {
+		var key string
+		if err := rows.Scan(&key); err != nil {
+			return nil, fmt.Errorf("failed to scan divert key: %w", err)
+		}
+		keys = append(keys, key)
+	}
+	return keys, rows.Err()
+}
@@ -0,0 +1,38 @@
+package repository
+
+import (
+	"errors"
+
+	"github.com/lib/pq"
+)
+
+// IsPermanent reports whether err will happen again however often the same
+// event is retried: it has no booking ID, references a missing user or room,
+// or the database rejected its data (SQLSTATE class 22, data exception, or
+// 23, integrity constraint violation). Connection and timeout errors are transient.
+func IsPermanent(err error) bool {
+	if errors.Is(err, ErrUserNotFound) || errors.Is(err, ErrRoomNotFound) || errors.Is(err, ErrMissingBookingID) {
+		return true
+	}
+
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		switch pqErr.Code.Class() {
+		case "22", "23":
+			return true
+		}
+	}
+	return false
+}
+
+// IsOverlapViolation reports whether err is the database refusing a Pending
+// or Accepted booking because another one holds the room for overlapping
+// dates (SQLSTATE 23P01, exclusion violation on bookings). The constraint
+// and its name belong to booking-management, which defines them with
+// migration 5 as db.BookingOverlapConstraint; it is the only exclusion
+// constraint on bookings, so the worker matches on the table and does not
+// repeat the name.
+func IsOverlapViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23P01" && pqErr.Table == "bookings"
+}
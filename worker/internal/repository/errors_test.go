@@ -0,0 +1,57 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/lib/pq"
+)
+
+func TestIsOverlapViolation(t *testing.T) {
+	overlap := &pq.Error{Code: "23P01", Table: "bookings", Constraint: "bookings_no_overlap"}
+
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"overlap", overlap, true},
+		{"wrapped overlap", fmt.Errorf("failed to create booking: %w", overlap), true},
+		{"exclusion violation on another table", &pq.Error{Code: "23P01", Table: "rooms", Constraint: "rooms_no_overlap"}, false},
+		{"unique violation", &pq.Error{Code: "23505", Table: "bookings", Constraint: "bookings_external_id_key"}, false},
+		{"plain error", errors.New("23P01 bookings_no_overlap"), false},
+		{"nil", nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsOverlapViolation(tt.err); got != tt.want {
+				t.Errorf("IsOverlapViolation(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsPermanent(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"missing user", fmt.Errorf("lookup: %w", ErrUserNotFound), true},
+		{"missing room", ErrRoomNotFound, true},
+		{"missing booking ID", ErrMissingBookingID, true},
+		{"data exception", &pq.Error{Code: "22007"}, true},
+		{"overlap", &pq.Error{Code: "23P01", Constraint: "bookings_no_overlap"}, true},
+		{"connection failure", &pq.Error{Code: "08006"}, false},
+		{"serialization failure", &pq.Error{Code: "40001"}, false},
+		{"plain error", errors.New("connection refused"), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsPermanent(tt.err); got != tt.want {
+				t.Errorf("IsPermanent(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
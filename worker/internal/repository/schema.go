@@ -0,0 +1,36 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/lib/pq"
+)
+
+// SchemaVersion is the oldest version of the booking-management schema
+// this worker works with. Migrations only ever add to the schema, so newer
+// versions are accepted and a deploy can migrate before replacing the
+// worker. Raise it only when the worker starts to rely on a migration that
+// changes the users, rooms, bookings or divert_consumers tables; the
+// migrations each service needs are listed in booking-management/db.
+const SchemaVersion = 6
+
+// CheckSchemaVersion fails unless the database is at SchemaVersion or newer.
+// The version is the newest required migration recorded in schema_migrations.
+func CheckSchemaVersion(ctx context.Context, db *sql.DB) error {
+	var version int
+	err := db.QueryRowContext(ctx, `SELECT COALESCE(MAX(version), 0) FROM schema_migrations WHERE NOT optional`).Scan(&version)
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "42P01" { // undefined_table
+		return errors.New("database has no schema_migrations table; run the booking-management migrate command")
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read schema version: %w", err)
+	}
+	if version < SchemaVersion {
+		return fmt.Errorf("database schema is at version %d but this worker requires version %d or newer", version, SchemaVersion)
+	}
+	return nil
+}
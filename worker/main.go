@@ -2,25 +2,45 @@ package main
 
 import (
 	"context"
+	"database/sql"
 	"log"
+	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
 	"worker/internal/config"
 	"worker/internal/database"
+	"worker/internal/divert"
 	"worker/internal/kafka"
 	"worker/internal/logger"
+	"worker/internal/metrics"
 	"worker/internal/models"
 	"worker/internal/repository"
+	"worker/internal/tracing"
 )
 
 func main() {
 	cfg := config.Load()
 
+	if len(os.Args) > 1 && os.Args[1] == "redrive-dlq" {
+		redriveDLQ(cfg, os.Args[2:])
+		return
+	}
+
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
+	shutdownTracing, err := tracing.Init(ctx, tracing.Options{
+		ServiceName:  "worker",
+		OTLPEndpoint: cfg.OTLPEndpoint,
+		File:         cfg.TracesFile,
+	})
+	if err != nil {
+		log.Fatalf("Failed to initialize tracing: %v", err)
+	}
+
 	db, err := database.NewConnection(cfg.DBHost, cfg.DBPort, cfg.DBUser, cfg.DBPass, cfg.DBName)
 	if err != nil {
 		log.Fatalf("Failed to connect to database: %v", err)
@@ -29,16 +49,43 @@ func main() {
 
 	logger.Info(ctx, "Connected to database successfully")
 
+	// Refuse to write to a schema this worker was not written for
+	if err := repository.CheckSchemaVersion(ctx, db.DB); err != nil {
+		log.Fatalf("Unexpected database schema version: %v", err)
+	}
+
 	// Create repositories
 	bookingRepo := repository.NewBookingRepository(db.DB)
 
+	// Create the producer for the events the worker emits
+	producer, err := kafka.NewProducer(cfg.KafkaBrokers)
+	if err != nil {
+		log.Fatalf("Failed to create Kafka producer: %v", err)
+	}
+	defer producer.Close()
+
 	// Create event handlers with dependency injection
 	handlers := kafka.EventHandlers{
 		BookingHandler:      createBookingHandler(bookingRepo),
-		CancellationHandler: createCancellationHandler(bookingRepo),
+		CancellationHandler: createCancellationHandler(bookingRepo, producer),
+	}
+
+	// Decide which messages this worker handles when services are diverted
+	selector, releaseDivert, err := setupDivert(ctx, cfg, db.DB)
+	if err != nil {
+		log.Fatalf("Failed to set up divert selection: %v", err)
 	}
 
-	consumer, err := kafka.NewConsumer(cfg.KafkaBrokers, handlers)
+	consumer, err := kafka.NewConsumer(kafka.ConsumerOptions{
+		Brokers:       cfg.KafkaBrokers,
+		GroupID:       cfg.KafkaGroupID,
+		InitialOffset: cfg.KafkaInitialOffset,
+		Retry: kafka.RetryPolicy{
+			MaxAttempts: cfg.RetryMaxAttempts,
+			BaseDelay:   cfg.RetryBaseDelay,
+			MaxDelay:    cfg.RetryMaxDelay,
+		},
+	}, handlers, selector)
 	if err != nil {
 		log.Fatalf("Failed to create Kafka consumer: %v", err)
 	}
@@ -50,12 +97,72 @@ func main() {
 		}
 	}()
 
+	metricsServer := metrics.NewServer(cfg.MetricsPort)
+	go func() {
+		logger.Info(ctx, "Metrics server listening", "port", cfg.MetricsPort)
+		if err := metricsServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			logger.Error(ctx, "Metrics server error", "error", err)
+		}
+	}()
+
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
 	<-sigChan
 
 	logger.Info(ctx, "Shutting down worker service")
 	cancel()
+
+	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer shutdownCancel()
+	releaseDivert(shutdownCtx)
+	if err := metricsServer.Shutdown(shutdownCtx); err != nil {
+		logger.Error(ctx, "Failed to shut down metrics server", "error", err)
+	}
+	if err := shutdownTracing(shutdownCtx); err != nil {
+		logger.Error(ctx, "Failed to flush traces", "error", err)
+	}
+}
+
+// setupDivert builds the message selector for the configured divert mode.
+// Diverted workers claim their namespace with a heartbeat so shared workers
+// leave its messages alone; the returned function drops that claim.
+func setupDivert(ctx context.Context, cfg *config.Config, db *sql.DB) (*divert.Selector, func(context.Context), error) {
+	noop := func(context.Context) {}
+
+	mode, err := divert.ParseMode(cfg.DivertMode, cfg.DivertNamespace)
+	if err != nil {
+		return nil, noop, err
+	}
+	if mode == divert.ModeAll || cfg.DivertBaggageKey == "" {
+		logger.Info(ctx, "Divert selection disabled, handling every message")
+		return nil, noop, nil
+	}
+
+	repo := repository.NewDivertRepository(db)
+	registry := divert.NewRegistry(repo, cfg.DivertClaimedKeys, cfg.DivertClaimTTL)
+	selector := divert.NewSelector(mode, cfg.DivertBaggageKey, cfg.DivertNamespace, registry)
+
+	if mode == divert.ModeDiverted {
+		consumerID, _ := os.Hostname()
+		if err := registry.Claim(ctx, cfg.DivertNamespace, consumerID, cfg.DivertHeartbeatInterval); err != nil {
+			return nil, noop, err
+		}
+		logger.Info(ctx, "Handling diverted messages only", "divertKey", cfg.DivertBaggageKey, "namespace", cfg.DivertNamespace)
+
+		return selector, func(ctx context.Context) {
+			if err := registry.Release(ctx, cfg.DivertNamespace, consumerID); err != nil {
+				logger.Error(ctx, "Failed to release divert claim", "namespace", cfg.DivertNamespace, "error", err)
+			}
+		}, nil
+	}
+
+	if err := registry.Refresh(ctx); err != nil {
+		return nil, noop, err
+	}
+	go registry.Watch(ctx, cfg.DivertHeartbeatInterval)
+	logger.Info(ctx, "Handling shared messages, skipping namespaces claimed by diverted workers", "divertKey", cfg.DivertBaggageKey)
+
+	return selector, noop, nil
 }
 
 func createBookingHandler(repo *repository.BookingRepository) func(context.Context, models.BookingEvent) error {
@@ -67,14 +174,33 @@ func createBookingHandler(repo *repository.BookingRepository) func(context.Conte
 			"paymentId", event.PaymentID,
 			"guests", event.Guests)
 
-		err := repo.CreateBooking(ctx, event)
+		outcome, err := repo.CreateBooking(ctx, event)
 		if err != nil {
 			logger.Error(ctx, "Failed to create booking in database",
 				"bookingId", event.BookingID,
 				"error", err)
+			if repository.IsPermanent(err) {
+				return kafka.Permanent(err)
+			}
 			return err
 		}
 
+		if !outcome.Decided {
+			logger.Info(ctx, "Booking already decided, ignoring redelivered event",
+				"bookingId", event.BookingID,
+				"status", outcome.Status)
+			return nil
+		}
+
+		if outcome.Status == models.BookingRefused {
+			logger.Warn(ctx, "Booking refused",
+				"bookingId", event.BookingID,
+				"userId", event.UserID,
+				"roomId", event.RoomID,
+				"reason", outcome.RefusalReason)
+			return nil
+		}
+
 		logger.Info(ctx, "Successfully created booking in database",
 			"bookingId", event.BookingID,
 			"userId", event.UserID,
@@ -84,25 +210,49 @@ func createBookingHandler(repo *repository.BookingRepository) func(context.Conte
 	}
 }
 
-func createCancellationHandler(repo *repository.BookingRepository) func(context.Context, models.CancellationEvent) error {
+func createCancellationHandler(repo *repository.BookingRepository, producer *kafka.Producer) func(context.Context, models.CancellationEvent) error {
 	return func(ctx context.Context, event models.CancellationEvent) error {
 		logger.Info(ctx, "Processing cancellation event",
 			"bookingId", event.BookingID,
 			"userId", event.UserID,
 			"timestamp", event.Timestamp)
 
-		err := repo.CancelBooking(ctx, event)
+		result, err := repo.CancelBooking(ctx, event)
 		if err != nil {
 			logger.Error(ctx, "Failed to cancel booking in database",
 				"bookingId", event.BookingID,
 				"userId", event.UserID,
 				"error", err)
+			if repository.IsPermanent(err) {
+				return kafka.Permanent(err)
+			}
 			return err
 		}
 
-		logger.Info(ctx, "Successfully cancelled booking in database",
-			"bookingId", event.BookingID,
-			"userId", event.UserID)
+		if result == models.CancellationCancelled {
+			logger.Info(ctx, "Successfully cancelled booking in database",
+				"bookingId", event.BookingID,
+				"userId", event.UserID)
+		} else {
+			logger.Warn(ctx, "Booking was not cancelled",
+				"bookingId", event.BookingID,
+				"userId", event.UserID,
+				"result", result)
+		}
+
+		resultEvent := models.CancellationResultEvent{
+			BookingID: event.BookingID,
+			UserID:    event.UserID,
+			Result:    result,
+			Timestamp: time.Now(),
+		}
+		if err := producer.SendMessage(ctx, kafka.CancellationResultsTopic, event.BookingID, resultEvent); err != nil {
+			logger.Error(ctx, "Failed to publish cancellation result",
+				"bookingId", event.BookingID,
+				"result", result,
+				"error", err)
+			return err
+		}
 
 		return nil
 	}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"worker/internal/config"
+	"worker/internal/kafka"
+	"worker/internal/logger"
+)
+
+// redriveDLQ implements the redrive-dlq subcommand, which publishes
+// dead-lettered messages back to their original topics once the cause of
+// the failure has been fixed.
+func redriveDLQ(cfg *config.Config, args []string) {
+	flags := flag.NewFlagSet("redrive-dlq", flag.ExitOnError)
+	topic := flags.String("topic", "", "dead-letter topic to re-drive (default: every booking dead-letter topic)")
+	groupID := flags.String("group", cfg.KafkaGroupID+"-redrive", "consumer group recording re-driven offsets")
+	limit := flags.Int("limit", 0, "maximum number of messages to re-drive per topic (0 means all)")
+	dryRun := flags.Bool("dry-run", false, "log the messages without re-driving them")
+	flags.Parse(args)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	topics := []string{"booking-events" + kafka.DeadLetterSuffix, "booking-cancellations" + kafka.DeadLetterSuffix}
+	if *topic != "" {
+		topics = []string{*topic}
+	}
+
+	for _, t := range topics {
+		n, err := kafka.Redrive(ctx, kafka.RedriveOptions{
+			Brokers: cfg.KafkaBrokers,
+			Topic:   t,
+			GroupID: *groupID,
+			Limit:   *limit,
+			DryRun:  *dryRun,
+		})
+		if err != nil {
+			logger.Error(ctx, "Failed to re-drive dead-letter topic", "topic", t, "redriven", n, "error", err)
+			log.Fatal(err)
+		}
+		logger.Info(ctx, "Dead-letter topic re-driven", "topic", t, "redriven", n, "dryRun", *dryRun)
+	}
+}